@@ -2,7 +2,9 @@
 Package go_hidrive is a simple client SDK library for HiDrive cloud storage
 (mainly provided by [Strato](https://www.strato.de/cloud-speicher/) provider)
 
-Currently, the following implementation are available: [Dir], [File] and [Share].
+Currently, the following implementation are available: [Dir], [File], [Meta], [Permission], [Share],
+[Sharelink], [Snapshot] and [User].
+All of them can be created at once sharing the same configuration with [NewClient].
 
 All methods accept url.Values as a set of request parameters.
 You can also use [Parameters] objects to simplify parameters gathering required for request.
@@ -37,10 +39,9 @@ Example reading file from HiDrive:
 			RefreshToken: "hi_drive_oauth2_refresh_token",
 		}
 
-		client := oauth2config.Client(context.Background(), token)
-		fileApi := hidrive.NewFile(client, hidrive.StratoHiDriveAPIV21)
+		client := hidrive.NewClient(hidrive.WithHTTPClient(oauth2config.Client(context.Background(), token)))
 
-		rdr, err := fileApi.Get(context.Background(), hidrive.NewParameters().SetPath("/public/test_file.txt").Values)
+		rdr, err := client.File.Get(context.Background(), hidrive.NewParameters().SetPath("/public/test_file.txt").Values)
 
 		if err != nil {
 			log.Fatal(err)
@@ -63,6 +64,7 @@ import (
 	"net/http"
 	"net/url"
 	"strings"
+	"time"
 )
 
 const (
@@ -78,6 +80,12 @@ Property `HTTPClient` should be a [http.Client] type and retrieved from `oauth2`
 i.e. it should be pre-configured to perform OAuth2 authentication against HiDrive API before
 underlying method send any data.
 
+Property `RetryPolicy` defines how failed requests are retried, see [RetryPolicy] for details.
+When it is nil (the default), every request is sent exactly once.
+
+Property `UserAgent`, when set, is sent in `User-Agent` header of every request.
+Property `Logger`, when set, receives a message for every request sent and every retry.
+
 Property `APIEndpoint` should be set to proper HiDrive API endpoint.
 Use [NewApi] function to create new instances of this type, it supports empty `endpoint` and
 injects default from [StratoHiDriveAPIV21] constant.
@@ -85,6 +93,21 @@ injects default from [StratoHiDriveAPIV21] constant.
 type Api struct {
 	APIEndpoint string
 	HTTPClient  *http.Client
+	RetryPolicy *RetryPolicy
+	UserAgent   string
+	Logger      Logger
+}
+
+// Logger - minimal logging interface used by [Api], it is satisfied by *log.Logger.
+type Logger interface {
+	Printf(format string, v ...any)
+}
+
+// logf writes the message to the logger if it is set.
+func (a Api) logf(format string, v ...any) {
+	if a.Logger != nil {
+		a.Logger.Printf(format, v...)
+	}
 }
 
 func NewApi(client *http.Client, endpoint string) Api {
@@ -121,24 +144,59 @@ func (a Api) doPATCH(ctx context.Context, uri string, params url.Values, okCodes
 	return a.doHTTPRequest(ctx, "PATCH", uri, params, okCodes, body)
 }
 
+func (a Api) doGETWithHeader(ctx context.Context, uri string, params url.Values, header http.Header, okCodes []int) (*http.Response, error) {
+	return a.doHTTPRequestWithHeader(ctx, "GET", uri, params, header, okCodes, nil)
+}
+
 func (a Api) doHTTPRequest(ctx context.Context, method, uri string, params url.Values, okCodes []int, body io.ReadCloser) (*http.Response, error) {
+	return a.doHTTPRequestWithHeader(ctx, method, uri, params, nil, okCodes, body)
+}
+
+func (a Api) doHTTPRequestWithHeader(ctx context.Context, method, uri string, params url.Values, header http.Header, okCodes []int, body io.ReadCloser) (*http.Response, error) {
 	var (
-		req *http.Request
 		res *http.Response
+		err error
 	)
 
-	{
-		var err error
-		if req, err = a.newHTTPRequest(ctx, method, uri, body); err != nil {
-			return nil, err
+	var replay *replayableBody
+	attempts := a.RetryPolicy.attempts()
+	if attempts > 1 && body != nil {
+		if replay = newReplayableBody(body); replay == nil {
+			attempts = 1
+		} else {
+			defer body.Close()
 		}
 	}
 
-	req.URL.RawQuery = params.Encode()
+	idempotent := isRepeatable(method, params, replay != nil)
+	for attempt := 1; ; attempt++ {
+		var retryAfter time.Duration
+		var reason string
+		if res, err = a.doHTTPAttempt(ctx, method, uri, params, header, body, replay); err != nil {
+			if attempt >= attempts || !a.RetryPolicy.shouldRetryError(idempotent, err) {
+				a.logf("hidrive: %s %s: %v", method, uri, err)
+				return nil, err
+			}
+			reason = err.Error()
+		} else {
+			a.logf("hidrive: %s %s: %s", method, uri, res.Status)
+			if isItemInSlice(okCodes, res.StatusCode) || attempt >= attempts ||
+				!a.RetryPolicy.shouldRetryStatus(idempotent, res.StatusCode) {
+				break
+			}
+			retryAfter = parseRetryAfter(res.Header.Get("Retry-After"), time.Now())
+			if a.RetryPolicy.exceedsMaxBackoff(retryAfter) {
+				a.logf("hidrive: %s %s: not retrying, Retry-After %v exceeds maximum backoff", method, uri, retryAfter)
+				break
+			}
+			_, _ = io.Copy(io.Discard, res.Body)
+			res.Body.Close()
+			reason = res.Status
+		}
 
-	{
-		var err error
-		if res, err = a.HTTPClient.Do(req); err != nil {
+		delay := a.RetryPolicy.backoff(attempt, retryAfter)
+		a.logf("hidrive: %s %s: retrying in %v after attempt %d of %d (%s)", method, uri, delay, attempt, attempts, reason)
+		if err := sleepContext(ctx, delay); err != nil {
 			return nil, err
 		}
 	}
@@ -153,24 +211,68 @@ func (a Api) doHTTPRequest(ctx context.Context, method, uri string, params url.V
 	return res, nil
 }
 
+// doHTTPAttempt sends a single request, if `replay` is not nil it is used to obtain the body instead of `body`.
+func (a Api) doHTTPAttempt(ctx context.Context, method, uri string, params url.Values, header http.Header, body io.ReadCloser, replay *replayableBody) (*http.Response, error) {
+	var req *http.Request
+
+	if replay != nil {
+		body = replay.reader()
+	}
+
+	{
+		var err error
+		if req, err = a.newHTTPRequest(ctx, method, uri, body); err != nil {
+			return nil, err
+		}
+	}
+
+	if replay != nil {
+		req.ContentLength = replay.size
+		req.GetBody = func() (io.ReadCloser, error) { return replay.reader(), nil }
+	}
+	if a.UserAgent != "" {
+		req.Header.Set("User-Agent", a.UserAgent)
+	}
+	for k, v := range header {
+		req.Header[k] = v
+	}
+	req.URL.RawQuery = params.Encode()
+
+	return a.HTTPClient.Do(req)
+}
+
 func (a Api) checkHTTPStatusError(okCodes []int, res *http.Response) error {
 	var err error
 	var body []byte
 
 	if !isItemInSlice(okCodes, res.StatusCode) {
-		hdErr := &Error{}
+		defer res.Body.Close()
 		if body, err = io.ReadAll(res.Body); err != nil {
 			return err
 		}
-		if err := json.Unmarshal(body, hdErr); err != nil {
-			return err
+
+		method, uri, params := "", "", url.Values(nil)
+		if req := res.Request; req != nil {
+			method = req.Method
+			uri = strings.TrimPrefix(strings.TrimPrefix(req.URL.Path, a.apiPath()), "/")
+			params = req.URL.Query()
 		}
+		hdErr := newHTTPError(method, uri, params, res.StatusCode, body)
+		hdErr.Header = res.Header
 		return hdErr
 	}
 
 	return nil
 }
 
+// apiPath returns path part of the API endpoint, e.g. "/2.1" for [StratoHiDriveAPIV21].
+func (a Api) apiPath() string {
+	if u, err := url.Parse(a.APIEndpoint); err == nil {
+		return u.Path
+	}
+	return ""
+}
+
 func (a Api) unmarshalBody(res *http.Response, obj any) error {
 	var body []byte
 	var err error
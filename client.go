@@ -0,0 +1,98 @@
+package go_hidrive
+
+import "net/http"
+
+/*
+Client - aggregates all HiDrive API implementations sharing the same configuration and transport.
+
+Use [NewClient] with [ClientOption] functions to create new instances of this type:
+
+	client := hidrive.NewClient(
+		hidrive.WithHTTPClient(oauth2config.Client(ctx, token)),
+		hidrive.WithRetryPolicy(hidrive.NewRetryPolicy()),
+		hidrive.WithUserAgent("my-app/1.0"),
+	)
+	obj, err := client.Meta.Get(ctx, hidrive.NewParameters().SetPath("/public").Values)
+*/
+type Client struct {
+	api Api
+
+	Dir        Dir
+	File       File
+	Meta       Meta
+	Permission Permission
+	Share      Share
+	Sharelink  Sharelink
+	Snapshot   Snapshot
+	User       User
+}
+
+// ClientOption - configures [Client] created by [NewClient].
+type ClientOption func(a *Api)
+
+// WithEndpoint sets HiDrive API endpoint, defaults to [StratoHiDriveAPIV21].
+func WithEndpoint(endpoint string) ClientOption {
+	return func(a *Api) {
+		a.APIEndpoint = endpoint
+	}
+}
+
+// WithHTTPClient sets HTTP client used for requests, it should be pre-configured to perform OAuth2 authentication.
+// Defaults to [http.DefaultClient].
+func WithHTTPClient(client *http.Client) ClientOption {
+	return func(a *Api) {
+		a.HTTPClient = client
+	}
+}
+
+// WithUserAgent sets `User-Agent` header sent with every request.
+func WithUserAgent(userAgent string) ClientOption {
+	return func(a *Api) {
+		a.UserAgent = userAgent
+	}
+}
+
+// WithRetryPolicy sets the policy of retrying failed requests, see [RetryPolicy].
+func WithRetryPolicy(policy *RetryPolicy) ClientOption {
+	return func(a *Api) {
+		a.RetryPolicy = policy
+	}
+}
+
+// WithLogger sets the logger receiving messages about requests and retries.
+func WithLogger(logger Logger) ClientOption {
+	return func(a *Api) {
+		a.Logger = logger
+	}
+}
+
+// NewClient - create new instance of [Client] configured with the given options.
+func NewClient(opts ...ClientOption) *Client {
+	api := NewApi(http.DefaultClient, "")
+	for _, opt := range opts {
+		opt(&api)
+	}
+	if api.APIEndpoint == "" {
+		api.APIEndpoint = StratoHiDriveAPIV21
+	}
+	if api.HTTPClient == nil {
+		api.HTTPClient = http.DefaultClient
+	}
+
+	return &Client{
+		api:        api,
+		Dir:        Dir{api},
+		File:       File{api},
+		Meta:       Meta{api},
+		Permission: Permission{api},
+		Share:      Share{api},
+		Sharelink:  Sharelink{api},
+		Snapshot:   Snapshot{api},
+		User:       User{api},
+	}
+}
+
+// Api returns the configuration shared by all API implementations of the client.
+func (c *Client) Api() Api {
+	return c.api
+}
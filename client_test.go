@@ -0,0 +1,108 @@
+package go_hidrive_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	hidrive "github.com/Burmuley/go-hidrive"
+	"github.com/Burmuley/go-hidrive/hidrivetest"
+)
+
+// headerTransport records `User-Agent` header of requests made through it.
+type headerTransport struct {
+	base       http.RoundTripper
+	userAgents []string
+}
+
+func (t *headerTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	t.userAgents = append(t.userAgents, req.Header.Get("User-Agent"))
+	return t.base.RoundTrip(req)
+}
+
+// testLogger collects messages written with Printf.
+type testLogger struct {
+	messages []string
+}
+
+func (l *testLogger) Printf(format string, v ...any) {
+	l.messages = append(l.messages, fmt.Sprintf(format, v...))
+}
+
+func TestNewClient(t *testing.T) {
+	srv := hidrivetest.NewServer()
+	defer srv.Close()
+	if err := srv.AddFile("/public/a.txt", []byte("hello"), time.Now()); err != nil {
+		t.Fatalf("AddFile() error = %v", err)
+	}
+	srv.InjectError(http.MethodGet, "meta", http.StatusServiceUnavailable, 1)
+
+	transport := &headerTransport{base: srv.Client().Transport}
+	logger := &testLogger{}
+	policy := hidrive.NewRetryPolicy()
+	policy.MinBackoff, policy.MaxBackoff = time.Millisecond, time.Millisecond
+
+	client := hidrive.NewClient(
+		hidrive.WithEndpoint(srv.Endpoint()),
+		hidrive.WithHTTPClient(&http.Client{Transport: transport}),
+		hidrive.WithUserAgent("go-hidrive-test/1.0"),
+		hidrive.WithRetryPolicy(policy),
+		hidrive.WithLogger(logger),
+	)
+	ctx := context.Background()
+
+	if obj, err := client.Meta.Get(ctx, hidrive.NewParameters().SetPath("/public/a.txt").Values); err != nil || obj.Size != 5 {
+		t.Fatalf("Meta.Get() = %v, %v", obj, err)
+	}
+	if _, err := client.Dir.Create(ctx, hidrive.NewParameters().SetPath("/public/dir").Values); err != nil {
+		t.Fatalf("Dir.Create() error = %v", err)
+	}
+	if _, err := client.File.Copy(ctx, hidrive.NewParameters().SetSrc("/public/a.txt").SetDst("/public/dir/a.txt").Values); err != nil {
+		t.Fatalf("File.Copy() error = %v", err)
+	}
+	if _, err := client.Share.Create(ctx, hidrive.NewParameters().SetPath("/public/dir").Values); err != nil {
+		t.Fatalf("Share.Create() error = %v", err)
+	}
+	if _, err := client.Sharelink.Create(ctx, hidrive.NewParameters().SetPath("/public/a.txt").Values); err != nil {
+		t.Fatalf("Sharelink.Create() error = %v", err)
+	}
+	if _, err := client.Snapshot.Get(ctx, nil); err != nil {
+		t.Fatalf("Snapshot.Get() error = %v", err)
+	}
+
+	// 6 calls and one retry
+	if len(transport.userAgents) != 7 {
+		t.Errorf("requests = %d, want 7", len(transport.userAgents))
+	}
+	for _, ua := range transport.userAgents {
+		if ua != "go-hidrive-test/1.0" {
+			t.Errorf("User-Agent = %q, want %q", ua, "go-hidrive-test/1.0")
+		}
+	}
+	retried := false
+	for _, msg := range logger.messages {
+		retried = retried || strings.Contains(msg, "retrying")
+	}
+	if !retried {
+		t.Errorf("logger messages %q do not report retry", logger.messages)
+	}
+	if api := client.Api(); api.APIEndpoint != srv.Endpoint() || api.RetryPolicy != policy {
+		t.Errorf("Api() = %+v, configuration is not shared", api)
+	}
+}
+
+func TestNewClient_Defaults(t *testing.T) {
+	api := hidrive.NewClient().Api()
+	if api.APIEndpoint != hidrive.StratoHiDriveAPIV21 {
+		t.Errorf("APIEndpoint = %q, want %q", api.APIEndpoint, hidrive.StratoHiDriveAPIV21)
+	}
+	if api.HTTPClient != http.DefaultClient {
+		t.Errorf("HTTPClient = %v, want http.DefaultClient", api.HTTPClient)
+	}
+	if api.RetryPolicy != nil || api.Logger != nil || api.UserAgent != "" {
+		t.Errorf("NewClient() = %+v, want no retry policy, logger and user agent", api)
+	}
+}
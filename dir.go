@@ -31,7 +31,7 @@ Get - this method allows to query information about a given directory and all it
 
 In short; A few things to be aware of:
 - path and name values are returned as URL-encoded strings
-- an implicit limit of 5000 is used by default
+- an implicit limit of 5000 is used by default, use [Dir.List] or [Dir.All] to iterate over all members
 - this also works for snapshots
 
 Usage details:
@@ -55,6 +55,7 @@ Supported parameters:
   - fields ([Parameters.SetFields])
   - sort ([Parameters.SetSortBy])
   - sort_lang ([Parameters.SetSortLang])
+  - snapshot ([Parameters.SetSnapshot])
 
 Returns [Object] with information about given directory.
 */
@@ -185,3 +186,149 @@ func (d Dir) Delete(ctx context.Context, params url.Values) error {
 	}
 	return nil
 }
+
+/*
+Copy - copy a directory with all its contents.
+
+The parameters `src` and `src_id` as well as `dst` and `dst_id` identify the source and destination for the operation.
+At least one source identifier and `dst` are always mandatory.
+It is allowed to use the related parameters together, in which case `src_id` and `dst_id` each address a parent
+directory and the values of `src` and `dst` are considered relative to that directory (e.g.<src_id>/<src>).
+
+With `on_exist` set to `overwrite` an existing destination directory is replaced, not merged.
+Adding the `snapshot` parameter copies the directory from the given snapshot, see [Snapshot.Restore].
+
+Status codes:
+  - 200 - OK
+  - 400 - Bad Request (e.g. invalid parameter)
+  - 401 - Unauthorized (password required)
+  - 403 - Forbidden (wrong password)
+  - 404 - Not Found (ID does not exist or given path is not shared).
+  - 409 - Conflict
+  - 422 - Unprocessable Entity (e.g. name too long)
+  - 500 - Internal Error
+  - 507 - Insufficient Storage
+
+Supported parameters:
+  - src ([Parameters.SetSrc])
+  - src_id ([Parameters.SetSrcId])
+  - dst ([Parameters.SetDst])
+  - dst_id ([Parameters.SetDstId])
+  - on_exist ([Parameters.SetOnExist]) (possible values: `autoname`, `overwrite`)
+  - dst_parent_mtime ([Parameters.SetDstParentMTime])
+  - preserve_mtime ([Parameters.SetPreserveMTime])
+  - snapshot ([Parameters.SetSnapshot])
+
+Returns [Object] with information about the directory created.
+*/
+func (d Dir) Copy(ctx context.Context, params url.Values) (*Object, error) {
+	var (
+		res *http.Response
+		err error
+	)
+
+	if res, err = d.doPOST(ctx, "dir/copy", params, []int{http.StatusOK}, nil); err != nil {
+		return nil, err
+	}
+
+	obj := &Object{}
+	if err := d.unmarshalBody(res, obj); err != nil {
+		return nil, err
+	}
+
+	return obj, nil
+}
+
+/*
+Move - move a directory with all its contents.
+
+The parameters `src` and `src_id` as well as `dst` and `dst_id` identify the source and destination for the operation.
+At least one source identifier and `dst` are always mandatory.
+It is allowed to use the related parameters together, in which case `src_id` and `dst_id` each address a parent
+directory and the values of `src` and `dst` are considered relative to that directory (e.g.<src_id>/<src>).
+
+A directory can not be moved into itself or any of its subdirectories. Note that the pids of the directory and all its
+contents change.
+
+Status codes:
+  - 200 - OK
+  - 400 - Bad Request (e.g. invalid parameter)
+  - 401 - Unauthorized (password required)
+  - 403 - Forbidden (wrong password)
+  - 404 - Not Found (ID does not exist or given path is not shared).
+  - 409 - Conflict
+  - 422 - Unprocessable Entity (e.g. name too long)
+  - 500 - Internal Error
+
+Supported parameters:
+  - src ([Parameters.SetSrc])
+  - src_id ([Parameters.SetSrcId])
+  - dst ([Parameters.SetDst])
+  - dst_id ([Parameters.SetDstId])
+  - on_exist ([Parameters.SetOnExist]) (possible values: `autoname`, `overwrite`)
+  - src_parent_mtime ([Parameters.SetSrcParentMTime])
+  - dst_parent_mtime ([Parameters.SetDstParentMTime])
+
+Returns [Object] with information about the moved directory.
+*/
+func (d Dir) Move(ctx context.Context, params url.Values) (*Object, error) {
+	var (
+		res *http.Response
+		err error
+	)
+
+	if res, err = d.doPOST(ctx, "dir/move", params, []int{http.StatusOK}, nil); err != nil {
+		return nil, err
+	}
+
+	obj := &Object{}
+	if err := d.unmarshalBody(res, obj); err != nil {
+		return nil, err
+	}
+
+	return obj, nil
+}
+
+/*
+Rename - rename a directory.
+
+Both, the `pid` and `path` parameters identify a filesystem object, at least one of them is always mandatory.
+It is allowed to use both together, in which case `pid` addresses a parent directory and the value of `path` is then
+considered relative to that directory (<pid>/<path>).
+
+Status codes:
+  - 201 - Created
+  - 400 - Bad Request (e.g. invalid parameter)
+  - 401 - Unauthorized (password required)
+  - 403 - Forbidden (wrong password)
+  - 404 - Not Found (ID does not exist or given path is not shared).
+  - 409 - Conflict
+  - 422 - Unprocessable Entity (e.g. name too long)
+  - 500 - Internal Error
+
+Supported parameters:
+  - path ([Parameters.SetPath])
+  - pid ([Parameters.SetPid])
+  - name ([Parameters.SetName])
+  - on_exist ([Parameters.SetOnExist]) (possible values: `autoname`, `overwrite`)
+  - parent_mtime ([Parameters.SetParentMTime])
+
+Returns [Object] with information about the renamed directory.
+*/
+func (d Dir) Rename(ctx context.Context, params url.Values) (*Object, error) {
+	var (
+		res *http.Response
+		err error
+	)
+
+	if res, err = d.doPOST(ctx, "dir/rename", params, []int{http.StatusCreated}, nil); err != nil {
+		return nil, err
+	}
+
+	obj := &Object{}
+	if err := d.unmarshalBody(res, obj); err != nil {
+		return nil, err
+	}
+
+	return obj, nil
+}
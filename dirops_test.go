@@ -0,0 +1,151 @@
+package go_hidrive_test
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"testing"
+	"time"
+
+	hidrive "github.com/Burmuley/go-hidrive"
+	"github.com/Burmuley/go-hidrive/hidrivetest"
+)
+
+func TestDir_CopyMoveRename(t *testing.T) {
+	mtime := time.Unix(1600000000, 0)
+	parentMTime := time.Unix(1700000000, 0)
+	files := map[string]string{
+		"/public/src/a.txt":     "a",
+		"/public/src/sub/b.txt": "b",
+		"/public/dst/old.txt":   "old",
+	}
+	copyOp := func(d hidrive.Dir, ctx context.Context, params url.Values) (*hidrive.Object, error) {
+		return d.Copy(ctx, params)
+	}
+	moveOp := func(d hidrive.Dir, ctx context.Context, params url.Values) (*hidrive.Object, error) {
+		return d.Move(ctx, params)
+	}
+	renameOp := func(d hidrive.Dir, ctx context.Context, params url.Values) (*hidrive.Object, error) {
+		return d.Rename(ctx, params)
+	}
+
+	tests := []struct {
+		name      string
+		op        func(d hidrive.Dir, ctx context.Context, params url.Values) (*hidrive.Object, error)
+		params    *hidrive.Parameters
+		wantPath  string
+		wantFiles map[string]string
+		gone      []string
+		wantMTime map[string]time.Time
+		wantErr   error
+	}{
+		{
+			name:      "copy",
+			op:        copyOp,
+			params:    hidrive.NewParameters().SetSrc("/public/src").SetDst("/public/dst/copy").SetPreserveMTime(true),
+			wantPath:  "/public/dst/copy",
+			wantFiles: map[string]string{"/public/dst/copy/a.txt": "a", "/public/dst/copy/sub/b.txt": "b", "/public/src/a.txt": "a"},
+			wantMTime: map[string]time.Time{"/public/dst/copy": mtime},
+		},
+		{
+			name:    "copy conflict",
+			op:      copyOp,
+			params:  hidrive.NewParameters().SetSrc("/public/src").SetDst("/public/dst"),
+			wantErr: hidrive.ErrConflict,
+		},
+		{
+			name:      "copy autoname",
+			op:        copyOp,
+			params:    hidrive.NewParameters().SetSrc("/public/src").SetDst("/public/dst").SetOnExist("autoname"),
+			wantPath:  "/public/dst (1)",
+			wantFiles: map[string]string{"/public/dst (1)/a.txt": "a", "/public/dst/old.txt": "old"},
+		},
+		{
+			name:      "copy overwrite",
+			op:        copyOp,
+			params:    hidrive.NewParameters().SetSrc("/public/src").SetDst("/public/dst").SetOnExist("overwrite"),
+			wantPath:  "/public/dst",
+			wantFiles: map[string]string{"/public/dst/a.txt": "a"},
+			gone:      []string{"/public/dst/old.txt"},
+		},
+		{
+			name:    "copy into itself",
+			op:      copyOp,
+			params:  hidrive.NewParameters().SetSrc("/public/src").SetDst("/public/src/sub/copy"),
+			wantErr: hidrive.ErrBadRequest,
+		},
+		{
+			name:    "copy of a file",
+			op:      copyOp,
+			params:  hidrive.NewParameters().SetSrc("/public/src/a.txt").SetDst("/public/a.txt"),
+			wantErr: hidrive.ErrBadRequest,
+		},
+		{
+			name: "move",
+			op:   moveOp,
+			params: hidrive.NewParameters().SetSrc("/public/src").SetDst("/public/dst/moved").
+				SetSrcParentMTime(parentMTime).SetDstParentMTime(parentMTime),
+			wantPath:  "/public/dst/moved",
+			wantFiles: map[string]string{"/public/dst/moved/a.txt": "a", "/public/dst/moved/sub/b.txt": "b"},
+			gone:      []string{"/public/src"},
+			wantMTime: map[string]time.Time{"/public": parentMTime, "/public/dst": parentMTime},
+		},
+		{
+			name:    "move into itself",
+			op:      moveOp,
+			params:  hidrive.NewParameters().SetSrc("/public/src").SetDst("/public/src/sub/moved"),
+			wantErr: hidrive.ErrBadRequest,
+		},
+		{
+			name:      "rename",
+			op:        renameOp,
+			params:    hidrive.NewParameters().SetPath("/public/src").SetName("renamed").SetParentMTime(parentMTime),
+			wantPath:  "/public/renamed",
+			wantFiles: map[string]string{"/public/renamed/sub/b.txt": "b"},
+			gone:      []string{"/public/src"},
+			wantMTime: map[string]time.Time{"/public": parentMTime},
+		},
+		{
+			name:    "rename conflict",
+			op:      renameOp,
+			params:  hidrive.NewParameters().SetPath("/public/src").SetName("dst"),
+			wantErr: hidrive.ErrConflict,
+		},
+		{
+			name:    "rename missing",
+			op:      renameOp,
+			params:  hidrive.NewParameters().SetPath("/public/missing").SetName("renamed"),
+			wantErr: hidrive.ErrNotFound,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv, client := hidrivetest.NewClient(t, files, mtime)
+			obj, err := tt.op(client.Dir, context.Background(), tt.params.Values)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("error = %v, want %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if got, _ := url.PathUnescape(obj.Path); got != tt.wantPath || obj.Type != "dir" {
+				t.Errorf("path = %q (%s), want %q", got, obj.Type, tt.wantPath)
+			}
+			for p, want := range tt.wantFiles {
+				if got, err := srv.ReadFile(p); err != nil || string(got) != want {
+					t.Errorf("ReadFile(%q) = %q, %v, want %q", p, got, err, want)
+				}
+			}
+			for _, p := range tt.gone {
+				if srv.Exists(p) {
+					t.Errorf("%s exists", p)
+				}
+			}
+			for p, want := range tt.wantMTime {
+				if got, _ := srv.ModTime(p); !got.Equal(want) {
+					t.Errorf("ModTime(%q) = %v, want %v", p, got, want)
+				}
+			}
+		})
+	}
+}
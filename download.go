@@ -0,0 +1,376 @@
+package go_hidrive
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"maps"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// statFields - object fields required to download a file in parts, added to `fields` parameter given by the caller.
+var statFields = []string{"id", "path", "type", "size"}
+
+/*
+DownloadOptions - options for [File.Download].
+
+Properties `Offset` and `Length` define the byte range to be requested:
+  - Offset = 0, Length = 0 - the whole file (no range requested)
+  - Offset > 0, Length = 0 - from `Offset` to the end of the file
+  - Length > 0             - `Length` bytes starting from `Offset`
+  - Offset < 0             - the last -Offset bytes of the file (`Length` is ignored)
+
+Properties `IfModifiedSince` and `IfNoneMatch` make the request conditional: if the file has not been modified since
+the given time or its ETag matches the given value, HiDrive responds with 304 Not Modified and
+[DownloadResponse.NotModified] is set to true.
+*/
+type DownloadOptions struct {
+	Offset          int64
+	Length          int64
+	IfModifiedSince time.Time
+	IfNoneMatch     string
+}
+
+// header builds HTTP request header for the options.
+func (o *DownloadOptions) header() http.Header {
+	h := http.Header{}
+	if o == nil {
+		return h
+	}
+
+	switch {
+	case o.Offset < 0:
+		h.Set("Range", fmt.Sprintf("bytes=%d", o.Offset))
+	case o.Length > 0:
+		h.Set("Range", fmt.Sprintf("bytes=%d-%d", o.Offset, o.Offset+o.Length-1))
+	case o.Offset > 0:
+		h.Set("Range", fmt.Sprintf("bytes=%d-", o.Offset))
+	}
+	if !o.IfModifiedSince.IsZero() {
+		h.Set("If-Modified-Since", o.IfModifiedSince.UTC().Format(http.TimeFormat))
+	}
+	if o.IfNoneMatch != "" {
+		h.Set("If-None-Match", o.IfNoneMatch)
+	}
+	return h
+}
+
+// ContentRange - represents the value of `Content-Range` response header.
+type ContentRange struct {
+	Start int64 // first byte position, -1 if the response does not contain data (e.g. 416)
+	End   int64 // last byte position (inclusive), -1 if the response does not contain data
+	Total int64 // total size of the file, -1 if not known
+}
+
+/*
+DownloadResponse - result of [File.Download].
+
+`Body` must be closed by the caller. When the file has not been modified (304), `NotModified` is true and
+`Body` is empty.
+
+`ContentRange` is nil when the whole file is returned, i.e. no range was requested or HiDrive ignored it.
+*/
+type DownloadResponse struct {
+	Body          io.ReadCloser
+	StatusCode    int
+	NotModified   bool
+	ContentLength int64
+	ContentRange  *ContentRange
+	LastModified  time.Time
+	ETag          string
+	MIMEType      string
+}
+
+/*
+Download - retrieve a given file from the HiDrive, optionally a byte range of it and/or conditionally.
+
+This is an extended version of [File.Get] which also provides response metadata (content length and range,
+modification time, ETag and MIME type). See [DownloadOptions] for details on range and conditional requests.
+
+Status codes:
+  - 200 - OK
+  - 206 - Partial Content
+  - 304 - Not Modified (not an error, [DownloadResponse.NotModified] is set)
+  - 400 - Bad Request (e.g. invalid parameter)
+  - 401 - Unauthorized (password required)
+  - 403 - Forbidden (wrong password)
+  - 404 - Not Found (ID does not exist or given path is not shared).
+  - 416 - Requested Range Not Satisfiable
+  - 500 - Internal Error
+
+Supported parameters:
+  - path ([Parameters.SetPath])
+  - pid ([Parameters.SetPid])
+  - snapshot ([Parameters.SetSnapshot])
+
+Returns [DownloadResponse] with the file contents and metadata.
+*/
+func (f File) Download(ctx context.Context, params url.Values, opts *DownloadOptions) (*DownloadResponse, error) {
+	var (
+		res *http.Response
+		err error
+	)
+
+	okCodes := []int{http.StatusOK, http.StatusPartialContent, http.StatusNotModified}
+	if res, err = f.doGETWithHeader(ctx, "file", params, opts.header(), okCodes); err != nil {
+		return nil, err
+	}
+
+	dr := &DownloadResponse{
+		Body:          res.Body,
+		StatusCode:    res.StatusCode,
+		NotModified:   res.StatusCode == http.StatusNotModified,
+		ContentLength: res.ContentLength,
+		ETag:          res.Header.Get("ETag"),
+		MIMEType:      res.Header.Get("Content-Type"),
+	}
+	if lm, err := http.ParseTime(res.Header.Get("Last-Modified")); err == nil {
+		dr.LastModified = lm
+	}
+	if res.StatusCode == http.StatusPartialContent {
+		if dr.ContentRange, err = parseContentRange(res.Header.Get("Content-Range")); err != nil {
+			res.Body.Close()
+			return nil, err
+		}
+	}
+	if dr.NotModified {
+		res.Body.Close()
+		dr.Body = http.NoBody
+		dr.ContentLength = 0
+	}
+
+	return dr, nil
+}
+
+// parseContentRange parses `Content-Range` header value, e.g. "bytes 0-99/1234" or "bytes */1234".
+func parseContentRange(value string) (*ContentRange, error) {
+	if !strings.HasPrefix(value, "bytes ") {
+		return nil, fmt.Errorf("invalid Content-Range %q", value)
+	}
+	rng, total, ok := strings.Cut(strings.TrimPrefix(value, "bytes "), "/")
+	if !ok {
+		return nil, fmt.Errorf("invalid Content-Range %q", value)
+	}
+
+	cr := &ContentRange{Start: -1, End: -1, Total: -1}
+	if total != "*" {
+		t, err := strconv.ParseInt(total, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid Content-Range %q", value)
+		}
+		cr.Total = t
+	}
+	if rng != "*" {
+		start, end, ok := strings.Cut(rng, "-")
+		if !ok {
+			return nil, fmt.Errorf("invalid Content-Range %q", value)
+		}
+		var err1, err2 error
+		cr.Start, err1 = strconv.ParseInt(start, 10, 64)
+		cr.End, err2 = strconv.ParseInt(end, 10, 64)
+		if err1 != nil || err2 != nil || cr.End < cr.Start {
+			return nil, fmt.Errorf("invalid Content-Range %q", value)
+		}
+	}
+	return cr, nil
+}
+
+const (
+	DefaultPartSize        = 16 << 20 // Default size of a part for [File.ParallelDownload]
+	DefaultDownloadWorkers = 4        // Default number of concurrent requests for [File.ParallelDownload]
+	DefaultMaxPartAttempts = 3        // Default number of attempts to download a single part
+)
+
+/*
+ParallelDownloadOptions - options for [File.ParallelDownload].
+
+Property `PartSize` defines the size of a single range requested, defaults to [DefaultPartSize].
+Property `Workers` defines the number of concurrent requests, defaults to [DefaultDownloadWorkers].
+Property `MaxPartAttempts` defines how many times a single part is tried to be downloaded if the download fails
+with a transient error (network failure, 5xx status or incomplete response), defaults to [DefaultMaxPartAttempts].
+It is the only retry count for part requests: [Api.RetryPolicy] does not retry them, it only defines the backoff.
+Property `Progress` is an optional callback called after every part with the total number of bytes downloaded,
+it is called from worker goroutines but never concurrently.
+*/
+type ParallelDownloadOptions struct {
+	PartSize        int64
+	Workers         int
+	MaxPartAttempts int
+	Progress        func(downloaded int64)
+}
+
+// withDefaults returns a copy of options with default values applied.
+func (o *ParallelDownloadOptions) withDefaults() ParallelDownloadOptions {
+	out := ParallelDownloadOptions{}
+	if o != nil {
+		out = *o
+	}
+	if out.PartSize <= 0 {
+		out.PartSize = DefaultPartSize
+	}
+	if out.Workers <= 0 {
+		out.Workers = DefaultDownloadWorkers
+	}
+	if out.MaxPartAttempts <= 0 {
+		out.MaxPartAttempts = DefaultMaxPartAttempts
+	}
+	return out
+}
+
+// stat queries the file with [Meta.Get] requesting at least statFields, it fails if the object is not a file.
+func (f File) stat(ctx context.Context, params url.Values) (*Object, error) {
+	if fields := params.Get("fields"); fields != "" {
+		list := strings.Split(fields, ",")
+		for _, field := range statFields {
+			if !slices.Contains(list, field) {
+				list = append(list, field)
+			}
+		}
+		params = maps.Clone(params)
+		params.Set("fields", strings.Join(list, ","))
+	}
+
+	obj, err := Meta{f.Api}.Get(ctx, params)
+	if err != nil {
+		return nil, err
+	}
+	if obj.Type != "file" {
+		return nil, fmt.Errorf("%s: not a file", obj.Path)
+	}
+	return obj, nil
+}
+
+/*
+ParallelDownload - download a file splitting it into ranges which are fetched concurrently and written into `w`
+(e.g. an *os.File) at the corresponding offsets.
+
+The file is first queried with [Meta.Get] to find out its size (fields required for that are added to `fields`
+parameter if it is given), then ranges of `opts.PartSize` bytes are requested
+with [File.Download] by a pool of `opts.Workers` workers. Each range is tried up to `opts.MaxPartAttempts` times
+on transient errors, instead of [Api.RetryPolicy] retries. If the file size reported by HiDrive changes during the
+download (i.e. the file has been modified), or the number of bytes written does not match [Object.Size], an error
+wrapping [ErrSizeMismatch] is returned.
+
+On error, `w` may contain partially written data.
+
+Supported parameters:
+  - path ([Parameters.SetPath])
+  - pid ([Parameters.SetPid])
+
+Returns [Object] with information about the downloaded file.
+*/
+func (f File) ParallelDownload(ctx context.Context, params url.Values, w io.WriterAt, opts *ParallelDownloadOptions) (*Object, error) {
+	o := opts.withDefaults()
+
+	obj, err := f.stat(ctx, params)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	parts := make(chan int64)
+	errs := make(chan error, o.Workers)
+	var (
+		mu         sync.Mutex
+		downloaded int64
+		wg         sync.WaitGroup
+	)
+
+	for i := 0; i < o.Workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for offset := range parts {
+				length := o.PartSize
+				if offset+length > obj.Size {
+					length = obj.Size - offset
+				}
+				if err := f.downloadPart(ctx, obj, offset, length, w, o.MaxPartAttempts); err != nil {
+					errs <- err
+					cancel()
+					return
+				}
+
+				mu.Lock()
+				downloaded += length
+				if o.Progress != nil {
+					o.Progress(downloaded)
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+
+feed:
+	for offset := int64(0); offset < obj.Size; offset += o.PartSize {
+		select {
+		case parts <- offset:
+		case <-ctx.Done():
+			break feed
+		}
+	}
+	close(parts)
+	wg.Wait()
+	close(errs)
+
+	if err := <-errs; err != nil {
+		return nil, err
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	if downloaded != obj.Size {
+		return nil, fmt.Errorf("%w: downloaded %d bytes, file size is %d", ErrSizeMismatch, downloaded, obj.Size)
+	}
+	return obj, nil
+}
+
+// downloadPart downloads `length` bytes of the file starting at `offset` into `w`, see Api.retryAttempts.
+func (f File) downloadPart(ctx context.Context, obj *Object, offset, length int64, w io.WriterAt, attempts int) error {
+	err := f.retryAttempts(ctx, attempts, func(single Api) error {
+		return File{single}.downloadRange(ctx, obj, offset, length, w)
+	})
+	if err != nil {
+		return fmt.Errorf("part at offset %d: %w", offset, err)
+	}
+	return nil
+}
+
+// downloadRange performs a single range request and writes the data into `w`.
+func (f File) downloadRange(ctx context.Context, obj *Object, offset, length int64, w io.WriterAt) error {
+	res, err := f.Download(ctx, NewParameters().SetPid(obj.ID).Values, &DownloadOptions{Offset: offset, Length: length})
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	switch {
+	case res.ContentRange != nil:
+		if res.ContentRange.Total != obj.Size {
+			return fmt.Errorf("%w: file size changed from %d to %d", ErrSizeMismatch, obj.Size, res.ContentRange.Total)
+		}
+		if res.ContentRange.Start != offset || res.ContentRange.End != offset+length-1 {
+			return fmt.Errorf("unexpected range %d-%d returned, requested %d-%d",
+				res.ContentRange.Start, res.ContentRange.End, offset, offset+length-1)
+		}
+	case offset != 0 || length != obj.Size:
+		return fmt.Errorf("range request for %d-%d was ignored", offset, offset+length-1)
+	}
+
+	n, err := io.Copy(io.NewOffsetWriter(w, offset), io.LimitReader(res.Body, length))
+	if err != nil {
+		return err
+	}
+	if n != length {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
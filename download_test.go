@@ -0,0 +1,258 @@
+package go_hidrive_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	hidrive "github.com/Burmuley/go-hidrive"
+	"github.com/Burmuley/go-hidrive/hidrivetest"
+)
+
+func TestFile_Download(t *testing.T) {
+	srv := hidrivetest.NewServer()
+	defer srv.Close()
+	fileApi := hidrive.NewFile(srv.Client(), srv.Endpoint())
+	ctx := context.Background()
+
+	mtime := time.Unix(1600000000, 0)
+	if err := srv.AddFile("/public/data.txt", []byte("0123456789"), mtime); err != nil {
+		t.Fatalf("AddFile() error = %v", err)
+	}
+	full, err := fileApi.Download(ctx, hidrive.NewParameters().SetPath("/public/data.txt").Values, nil)
+	if err != nil {
+		t.Fatalf("Download() error = %v", err)
+	}
+	full.Body.Close()
+
+	tests := []struct {
+		name            string
+		opts            *hidrive.DownloadOptions
+		want            string
+		wantRange       *hidrive.ContentRange
+		wantNotModified bool
+		wantErr         error
+	}{
+		{
+			name: "whole file",
+			want: "0123456789",
+		},
+		{
+			name:      "range with length",
+			opts:      &hidrive.DownloadOptions{Offset: 2, Length: 3},
+			want:      "234",
+			wantRange: &hidrive.ContentRange{Start: 2, End: 4, Total: 10},
+		},
+		{
+			name:      "range to the end",
+			opts:      &hidrive.DownloadOptions{Offset: 7},
+			want:      "789",
+			wantRange: &hidrive.ContentRange{Start: 7, End: 9, Total: 10},
+		},
+		{
+			name:      "suffix range",
+			opts:      &hidrive.DownloadOptions{Offset: -4},
+			want:      "6789",
+			wantRange: &hidrive.ContentRange{Start: 6, End: 9, Total: 10},
+		},
+		{
+			name:    "range not satisfiable",
+			opts:    &hidrive.DownloadOptions{Offset: 20, Length: 5},
+			wantErr: hidrive.ErrRangeNotSatisfiable,
+		},
+		{
+			name:            "not modified since",
+			opts:            &hidrive.DownloadOptions{IfModifiedSince: mtime.Add(time.Hour)},
+			wantNotModified: true,
+		},
+		{
+			name:            "etag matches",
+			opts:            &hidrive.DownloadOptions{IfNoneMatch: full.ETag},
+			wantNotModified: true,
+		},
+		{
+			name: "etag does not match",
+			opts: &hidrive.DownloadOptions{IfNoneMatch: `"other"`},
+			want: "0123456789",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res, err := fileApi.Download(ctx, hidrive.NewParameters().SetPath("/public/data.txt").Values, tt.opts)
+			if tt.wantErr != nil || err != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Errorf("Download() error = %v, wantErr %v", err, tt.wantErr)
+				}
+				return
+			}
+			defer res.Body.Close()
+
+			if res.NotModified != tt.wantNotModified {
+				t.Errorf("Download() NotModified = %v, want %v", res.NotModified, tt.wantNotModified)
+			}
+			if body, _ := io.ReadAll(res.Body); string(body) != tt.want {
+				t.Errorf("Download() body = %q, want %q", body, tt.want)
+			}
+			if (res.ContentRange == nil) != (tt.wantRange == nil) || (res.ContentRange != nil && *res.ContentRange != *tt.wantRange) {
+				t.Errorf("Download() ContentRange = %+v, want %+v", res.ContentRange, tt.wantRange)
+			}
+			if !tt.wantNotModified && (!res.LastModified.Equal(mtime) || res.ETag == "" || res.MIMEType != "text/plain; charset=utf-8") {
+				t.Errorf("Download() metadata = %v, %q, %q", res.LastModified, res.ETag, res.MIMEType)
+			}
+		})
+	}
+}
+
+// writerAtBuffer is an in-memory io.WriterAt safe for concurrent use.
+type writerAtBuffer struct {
+	mu  sync.Mutex
+	buf []byte
+}
+
+func (w *writerAtBuffer) WriteAt(p []byte, off int64) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if end := int(off) + len(p); end > len(w.buf) {
+		w.buf = append(w.buf, make([]byte, end-len(w.buf))...)
+	}
+	return copy(w.buf[off:], p), nil
+}
+
+func TestFile_ParallelDownload(t *testing.T) {
+	type args struct {
+		size     int
+		partSize int64
+		workers  int
+		faults   int
+		modify   bool // change the file after the first part is downloaded
+	}
+
+	tests := []struct {
+		name    string
+		args    args
+		wantErr error
+	}{
+		{
+			name: "single part",
+			args: args{size: 100, partSize: 1024, workers: 4},
+		},
+		{
+			name: "empty file",
+			args: args{size: 0, partSize: 1024, workers: 4},
+		},
+		{
+			name: "many parts with last part shorter",
+			args: args{size: 10000, partSize: 1024, workers: 3},
+		},
+		{
+			name: "more workers than parts",
+			args: args{size: 4096, partSize: 1024, workers: 16},
+		},
+		{
+			name: "failed parts are retried",
+			args: args{size: 10000, partSize: 1024, workers: 2, faults: 2},
+		},
+		{
+			name:    "part retries are exhausted",
+			args:    args{size: 10000, partSize: 1024, workers: 2, faults: 100},
+			wantErr: hidrive.ErrInternal,
+		},
+		{
+			name:    "file modified during download",
+			args:    args{size: 10000, partSize: 1024, workers: 1, modify: true},
+			wantErr: hidrive.ErrSizeMismatch,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := hidrivetest.NewServer()
+			defer srv.Close()
+			fileApi := newTestFile(srv)
+
+			data := randomBytes(tt.args.size)
+			if err := srv.AddFile("/public/big.bin", data, time.Unix(1600000000, 0)); err != nil {
+				t.Fatalf("AddFile() error = %v", err)
+			}
+			srv.InjectError(http.MethodGet, "file", http.StatusInternalServerError, tt.args.faults)
+
+			var progress int64
+			opts := &hidrive.ParallelDownloadOptions{
+				PartSize: tt.args.partSize,
+				Workers:  tt.args.workers,
+				Progress: func(downloaded int64) {
+					if tt.args.modify && progress == 0 {
+						_ = srv.AddFile("/public/big.bin", append(data, 'x'), time.Now())
+					}
+					progress = downloaded
+				},
+			}
+			w := &writerAtBuffer{}
+			obj, err := fileApi.ParallelDownload(context.Background(), hidrive.NewParameters().SetPath("/public/big.bin").Values, w, opts)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("ParallelDownload() error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParallelDownload() error = %v", err)
+			}
+			if obj.Size != int64(tt.args.size) || progress != int64(tt.args.size) {
+				t.Errorf("ParallelDownload() size = %d, progress = %d, want %d", obj.Size, progress, tt.args.size)
+			}
+			if !bytes.Equal(w.buf, data) {
+				t.Errorf("ParallelDownload() content differs from remote file")
+			}
+		})
+	}
+
+	t.Run("fields without size", func(t *testing.T) {
+		srv := hidrivetest.NewServer()
+		defer srv.Close()
+		data := randomBytes(5000)
+		if err := srv.AddFile("/public/big.bin", data, time.Unix(1600000000, 0)); err != nil {
+			t.Fatalf("AddFile() error = %v", err)
+		}
+		fileApi := newTestFile(srv)
+
+		params := hidrive.NewParameters().SetPath("/public/big.bin").SetFields([]string{"name", "mtime"})
+		w := &writerAtBuffer{}
+		obj, err := fileApi.ParallelDownload(context.Background(), params.Values, w, &hidrive.ParallelDownloadOptions{PartSize: 1024})
+		if err != nil {
+			t.Fatalf("ParallelDownload() error = %v", err)
+		}
+		if obj.Name != "big.bin" || obj.Size != 5000 || !bytes.Equal(w.buf, data) {
+			t.Errorf("ParallelDownload() = %+v, content equal = %v", obj, bytes.Equal(w.buf, data))
+		}
+		if params.Get("fields") != "name,mtime" {
+			t.Errorf("ParallelDownload() modified parameters: fields = %q", params.Get("fields"))
+		}
+	})
+
+	t.Run("retry policy does not multiply part attempts", func(t *testing.T) {
+		srv := hidrivetest.NewServer()
+		defer srv.Close()
+		if err := srv.AddFile("/public/big.bin", randomBytes(100), time.Unix(1600000000, 0)); err != nil {
+			t.Fatalf("AddFile() error = %v", err)
+		}
+		transport := &countingTransport{base: srv.Client().Transport}
+		fileApi := newTestFile(srv)
+		fileApi.HTTPClient = &http.Client{Transport: transport}
+
+		srv.InjectError(http.MethodGet, "file", http.StatusInternalServerError, 100)
+		opts := &hidrive.ParallelDownloadOptions{MaxPartAttempts: 3}
+		_, err := fileApi.ParallelDownload(context.Background(), hidrive.NewParameters().SetPath("/public/big.bin").Values, &writerAtBuffer{}, opts)
+		if !errors.Is(err, hidrive.ErrInternal) {
+			t.Fatalf("ParallelDownload() error = %v, want %v", err, hidrive.ErrInternal)
+		}
+		// one meta request and three attempts of the single part
+		if transport.count != 4 {
+			t.Errorf("ParallelDownload() requests = %d, want 4", transport.count)
+		}
+	})
+}
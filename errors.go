@@ -4,6 +4,9 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
 )
 
 /*
@@ -11,10 +14,27 @@ Error - represents HiDrive JSON-encoded errors.
 
 Every time an API call receives non-OK code HiDrive also provides explanation in the response body.
 This response is converted into this type and returned as error on each method.
+
+Besides the `code` and `msg` values sent by HiDrive the error carries details of the failed request: HTTP status,
+method, URI, request parameters (values of sensitive ones like `password` are redacted) and raw response body. If the response body is not a valid HiDrive error
+(e.g. an HTML page returned by a proxy or an empty body), `Code` is set to the HTTP status code and `Message`
+to the status text.
+
+Use [errors.Is] with sentinel errors like [ErrNotFound] or [ErrConflict] to check for particular failures:
+
+	if _, err := dirApi.Create(ctx, params); errors.Is(err, hidrive.ErrConflict) {
+		// directory already exists
+	}
 */
 type Error struct {
-	Code    json.Number `json:"code"`
-	Message string      `json:"msg"`
+	Code       json.Number `json:"code"`
+	Message    string      `json:"msg"`
+	StatusCode int         `json:"-"`
+	Method     string      `json:"-"`
+	URI        string      `json:"-"`
+	Params     url.Values  `json:"-"`
+	Body       []byte      `json:"-"`
+	Header     http.Header `json:"-"`
 }
 
 // Error returns a string for the error and satisfies the error interface.
@@ -23,9 +43,95 @@ func (e *Error) Error() string {
 	if e.Message != "" {
 		out += ": " + e.Message
 	}
+	if e.Method != "" {
+		out = fmt.Sprintf("%s %s: %s", e.Method, e.URI, out)
+	}
+	return out
+}
+
+// Is reports whether the error matches target sentinel error, allows using [errors.Is] with e.g. [ErrNotFound].
+func (e *Error) Is(target error) bool {
+	code := e.StatusCode
+	if code == 0 {
+		if c, err := strconv.Atoi(e.Code.String()); err == nil {
+			code = c
+		}
+	}
+
+	sentinel, ok := statusErrors[code]
+	return ok && sentinel == target
+}
+
+// newHTTPError creates [Error] from the response body, falls back to HTTP status if body is not a HiDrive error.
+func newHTTPError(method, uri string, params url.Values, statusCode int, body []byte) *Error {
+	hdErr := &Error{}
+	if err := json.Unmarshal(body, hdErr); err != nil || hdErr.Code == "" {
+		hdErr = &Error{
+			Code:    json.Number(strconv.Itoa(statusCode)),
+			Message: http.StatusText(statusCode),
+		}
+	}
+
+	hdErr.StatusCode = statusCode
+	hdErr.Method = method
+	hdErr.URI = uri
+	hdErr.Params = redactParams(params)
+	hdErr.Body = body
+	return hdErr
+}
+
+// sensitiveParams - request parameters whose values are not kept in [Error.Params].
+var sensitiveParams = []string{"password", "pw_sharekey", "share_access_key"}
+
+// redactedValue - replaces values of sensitive parameters in [Error.Params].
+const redactedValue = "REDACTED"
+
+// redactParams returns a copy of params with values of sensitiveParams replaced with redactedValue.
+func redactParams(params url.Values) url.Values {
+	if params == nil {
+		return nil
+	}
+	out := make(url.Values, len(params))
+	for k, v := range params {
+		if isItemInSlice(sensitiveParams, k) {
+			v = []string{redactedValue}
+		}
+		out[k] = v
+	}
 	return out
 }
 
 var (
 	ErrShouldNotBeEmpty = errors.New("value should not be empty")
+
+	ErrBadRequest          = errors.New("bad request")            // 400 - Bad Request
+	ErrUnauthorized        = errors.New("unauthorized")           // 401 - Unauthorized
+	ErrForbidden           = errors.New("forbidden")              // 403 - Forbidden
+	ErrNotFound            = errors.New("not found")              // 404 - Not Found
+	ErrConflict            = errors.New("conflict")               // 409 - Conflict (e.g. object already exists)
+	ErrGone                = errors.New("gone")                   // 410 - Gone
+	ErrTooLarge            = errors.New("request too large")      // 413 - Request Entity Too Large
+	ErrUnsupportedMedia    = errors.New("unsupported media type") // 415 - Unsupported Media Type
+	ErrRangeNotSatisfiable = errors.New("range not satisfiable")  // 416 - Requested Range Not Satisfiable
+	ErrUnprocessable       = errors.New("unprocessable entity")   // 422 - Unprocessable Entity (e.g. name too long)
+	ErrTooManyRequests     = errors.New("too many requests")      // 429 - Too Many Requests
+	ErrInternal            = errors.New("internal error")         // 500 - Internal Error
+	ErrInsufficientStorage = errors.New("insufficient storage")   // 507 - Insufficient Storage (quota exceeded)
 )
+
+// statusErrors maps HTTP status codes to sentinel errors.
+var statusErrors = map[int]error{
+	http.StatusBadRequest:                   ErrBadRequest,
+	http.StatusUnauthorized:                 ErrUnauthorized,
+	http.StatusForbidden:                    ErrForbidden,
+	http.StatusNotFound:                     ErrNotFound,
+	http.StatusConflict:                     ErrConflict,
+	http.StatusGone:                         ErrGone,
+	http.StatusRequestEntityTooLarge:        ErrTooLarge,
+	http.StatusUnsupportedMediaType:         ErrUnsupportedMedia,
+	http.StatusRequestedRangeNotSatisfiable: ErrRangeNotSatisfiable,
+	http.StatusUnprocessableEntity:          ErrUnprocessable,
+	http.StatusTooManyRequests:              ErrTooManyRequests,
+	http.StatusInternalServerError:          ErrInternal,
+	http.StatusInsufficientStorage:          ErrInsufficientStorage,
+}
@@ -0,0 +1,103 @@
+package go_hidrive
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestApi_checkHTTPStatusError(t *testing.T) {
+	type args struct {
+		status int
+		body   string
+	}
+
+	tests := []struct {
+		name        string
+		args        args
+		wantCode    string
+		wantMessage string
+		wantIs      error
+	}{
+		{
+			name:        "HiDrive JSON error",
+			args:        args{status: http.StatusConflict, body: `{"code":"409","msg":"File exists"}`},
+			wantCode:    "409",
+			wantMessage: "File exists",
+			wantIs:      ErrConflict,
+		},
+		{
+			name:        "HTML page from proxy",
+			args:        args{status: http.StatusBadGateway, body: `<html><body>Bad Gateway</body></html>`},
+			wantCode:    "502",
+			wantMessage: "Bad Gateway",
+		},
+		{
+			name:        "empty body",
+			args:        args{status: http.StatusNotFound, body: ``},
+			wantCode:    "404",
+			wantMessage: "Not Found",
+			wantIs:      ErrNotFound,
+		},
+		{
+			name:        "quota exceeded",
+			args:        args{status: http.StatusInsufficientStorage, body: `{"code":"507","msg":"Insufficient Storage"}`},
+			wantCode:    "507",
+			wantMessage: "Insufficient Storage",
+			wantIs:      ErrInsufficientStorage,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tt.args.status)
+				_, _ = w.Write([]byte(tt.args.body))
+			}))
+			defer srv.Close()
+
+			dirApi := NewDir(srv.Client(), srv.URL+"/2.1")
+			_, err := dirApi.Get(context.Background(), NewParameters().SetPath("/public").Values)
+
+			hdErr := &Error{}
+			if !errors.As(err, &hdErr) {
+				t.Fatalf("Get() error = %v, want *Error", err)
+			}
+			if hdErr.Code.String() != tt.wantCode || hdErr.Message != tt.wantMessage {
+				t.Errorf("Get() error code = %q, message = %q, want %q, %q", hdErr.Code, hdErr.Message, tt.wantCode, tt.wantMessage)
+			}
+			if hdErr.StatusCode != tt.args.status || hdErr.Method != http.MethodGet || hdErr.URI != "dir" {
+				t.Errorf("Get() error status = %d, method = %q, uri = %q", hdErr.StatusCode, hdErr.Method, hdErr.URI)
+			}
+			if hdErr.Params.Get("path") != "/public" || string(hdErr.Body) != tt.args.body {
+				t.Errorf("Get() error params = %v, body = %q", hdErr.Params, string(hdErr.Body))
+			}
+			if tt.wantIs != nil && !errors.Is(err, tt.wantIs) {
+				t.Errorf("errors.Is(%v, %v) = false, want true", err, tt.wantIs)
+			}
+			if errors.Is(err, ErrUnauthorized) {
+				t.Errorf("errors.Is(%v, %v) = true, want false", err, ErrUnauthorized)
+			}
+		})
+	}
+}
+
+func TestNewHTTPError_RedactsParams(t *testing.T) {
+	params := NewParameters().SetPath("/public/shared").SetPassword("secret")
+	params.Set("share_access_key", "key")
+	hdErr := newHTTPError(http.MethodPost, "share", params.Values, http.StatusBadRequest, nil)
+
+	if got := hdErr.Params.Get("password"); got != redactedValue {
+		t.Errorf("Params password = %q, want %q", got, redactedValue)
+	}
+	if got := hdErr.Params.Get("share_access_key"); got != redactedValue {
+		t.Errorf("Params share_access_key = %q, want %q", got, redactedValue)
+	}
+	if got := hdErr.Params.Get("path"); got != "/public/shared" {
+		t.Errorf("Params path = %q, want %q", got, "/public/shared")
+	}
+	if params.Get("password") != "secret" {
+		t.Errorf("request parameters are modified")
+	}
+}
@@ -45,8 +45,10 @@ Status codes:
 Supported parameters:
   - path ([Parameters.SetPath])
   - pid ([Parameters.SetPid])
+  - snapshot ([Parameters.SetSnapshot])
 
 Returns an io.ReadCloser object to read file contents using standard Go mechanisms.
+To request a byte range of the file or make a conditional request use [File.Download].
 */
 func (f File) Get(ctx context.Context, params url.Values) (io.ReadCloser, error) {
 	var (
@@ -74,7 +76,7 @@ If both are given, dir_id addresses a directory and the value of dir is taken as
 
 The size of the request body to upload is limited to 2147483648 bytes (2G). The size of the complete request, including
 header, and after possible decoding of chunked encoding and decompression is limited to 3206545408 bytes (3058MB).
-Larger requests are rejected with 413 Request Entity Too Large.
+Larger requests are rejected with 413 Request Entity Too Large. Use [File.ChunkedUpload] to upload larger files.
 
 As existence of the target file will be checked only after the upload is complete, the target file may have sprung into
 existence during the upload. To avoid losing the uploaded content in this case, the optional on_exist parameter can be
@@ -237,6 +239,7 @@ Supported parameters:
   - on_exist ([Parameters.SetOnExist]) (possible values: `autoname`, `overwrite`)
   - dst_parent_mtime
   - preserve_mtime
+  - snapshot ([Parameters.SetSnapshot]) - copy the source from the snapshot, see [Snapshot.Restore]
 */
 func (f File) Copy(ctx context.Context, params url.Values) (*Object, error) {
 	var (
@@ -342,3 +345,86 @@ func (f File) Rename(ctx context.Context, params url.Values) (*Object, error) {
 
 	return obj, nil
 }
+
+/*
+Patch - partially update a file by writing uploaded content at the given offset.
+
+The file must already exist. The `offset` must not be greater than the current file size, writing at the offset equal
+to the file size appends content to the file. Existing content in range of the uploaded data is overwritten.
+
+Both, the `pid` and `path` parameters identify a filesystem object, at least one of them is always mandatory.
+It is allowed to use both together, in which case `pid` addresses a parent directory and the value of `path` is then
+considered relative to that directory (<pid>/<path>).
+
+The same request body size limits as for [File.Upload] apply.
+To upload files of any size use [File.ChunkedUpload].
+
+Status codes:
+  - 204 - No Content
+  - 400 - Bad Request (e.g. invalid parameter)
+  - 401 - Unauthorized (password required)
+  - 403 - Forbidden (wrong password)
+  - 404 - Not Found (ID does not exist or given path is not shared).
+  - 413 - Request Entity Too Large
+  - 416 - Requested Range Not Satisfiable (offset is greater than file size)
+  - 500 - Internal Error
+  - 507 - Insufficient Storage
+
+Supported parameters:
+  - path ([Parameters.SetPath])
+  - pid ([Parameters.SetPid])
+  - offset ([Parameters.SetOffset])
+*/
+func (f File) Patch(ctx context.Context, params url.Values, fileBody io.ReadCloser) error {
+	if _, err := f.doPATCH(ctx, "file", params, []int{http.StatusNoContent}, fileBody); err != nil {
+		return err
+	}
+	return nil
+}
+
+/*
+Hash - retrieve checksums of the given level of the file content hash for the given ranges.
+
+The checksums can be compared with the ones computed locally with [LevelHashes] to verify an upload
+or to find out which blocks of a large file differ without downloading it: a level 1 checksum covers 1 MiB of data,
+level 0 checksums cover 4 KiB blocks.
+
+Both, the `pid` and `path` parameters identify a filesystem object, at least one of them is always mandatory.
+It is allowed to use both together, in which case `pid` addresses a parent directory and the value of `path` is then
+considered relative to that directory (<pid>/<path>).
+
+Status codes:
+  - 200 - OK
+  - 400 - Bad Request (e.g. invalid parameter)
+  - 401 - Unauthorized (password required)
+  - 403 - Forbidden (wrong password)
+  - 404 - Not Found (ID does not exist or given path is not shared).
+  - 416 - Requested Range Not Satisfiable
+  - 500 - Internal Error
+
+Supported parameters:
+  - path ([Parameters.SetPath])
+  - pid ([Parameters.SetPid])
+  - level ([Parameters.SetLevel])
+  - ranges ([Parameters.SetRanges])
+  - snapshot ([Parameters.SetSnapshot])
+
+Returns [FileHash] with the list of checksums for every range.
+*/
+func (f File) Hash(ctx context.Context, params url.Values) (*FileHash, error) {
+	var (
+		res *http.Response
+		err error
+	)
+
+	if res, err = f.doGET(ctx, "file/hash", params, []int{http.StatusOK}); err != nil {
+		return nil, err
+	}
+
+	fh := &FileHash{}
+	if err := f.unmarshalBody(res, fh); err != nil {
+		return nil, err
+	}
+
+	return fh, nil
+}
@@ -0,0 +1,319 @@
+package go_hidrive
+
+import (
+	"context"
+	"errors"
+	"io"
+	"io/fs"
+	"path"
+	"sort"
+	"time"
+)
+
+// fsFields - object fields requested by [FS] to build fs.FileInfo.
+var fsFields = []string{"id", "name", "type", "size", "mtime", "writable"}
+
+// fsFileOptions - options of [RemoteFile] used for files opened by [FS].
+var fsFileOptions = RemoteFileOptions{BlockSize: 1 << 20, CacheBlocks: 4, ReadAhead: 3}
+
+/*
+FS - implementation of fs.FS, fs.ReadDirFS, fs.StatFS and fs.ReadFileFS over a HiDrive directory.
+
+It allows using HiDrive with standard library consumers like fs.WalkDir, fs.Glob, template.ParseFS or http.FS:
+
+	fsys := hidrive.NewFS(client.Api(), "/users/john")
+	err := fs.WalkDir(fsys, ".", func(p string, d fs.DirEntry, err error) error {
+		...
+	})
+
+Names passed to the methods are slash-separated paths relative to the root directory as defined by fs.ValidPath.
+Files are opened as [RemoteFile] with a small block cache, so they implement io.Seeker and io.ReaderAt as well.
+Errors are returned as *fs.PathError, the underlying [Error] matches both its sentinel (e.g. [ErrNotFound])
+and the corresponding fs error (e.g. fs.ErrNotExist) with [errors.Is].
+
+Use [FS.Snapshot] to get a read-only view of a snapshot and [FS.WithContext] to set the context used for requests.
+*/
+type FS struct {
+	ctx      context.Context
+	api      Api
+	root     string
+	snapshot string
+}
+
+// NewFS - create new instance of [FS] rooted at the given absolute HiDrive path.
+func NewFS(api Api, root string) *FS {
+	if root == "" {
+		root = "/"
+	}
+	return &FS{ctx: context.Background(), api: api, root: path.Clean(root)}
+}
+
+// FS returns [FS] rooted at the given path using the configuration of the client, see [NewFS].
+func (c *Client) FS(root string) *FS {
+	return NewFS(c.api, root)
+}
+
+// WithContext returns a copy of the filesystem using `ctx` for all requests, including reads of opened files.
+func (fsys *FS) WithContext(ctx context.Context) *FS {
+	out := *fsys
+	out.ctx = ctx
+	return &out
+}
+
+// Snapshot returns a copy of the filesystem showing the state of the snapshot with the given name.
+func (fsys *FS) Snapshot(name string) *FS {
+	out := *fsys
+	out.snapshot = name
+	return &out
+}
+
+// Root returns the HiDrive path of the root directory.
+func (fsys *FS) Root() string {
+	return fsys.root
+}
+
+// remotePath returns HiDrive path for the name.
+func (fsys *FS) remotePath(op, name string) (string, error) {
+	if !fs.ValidPath(name) {
+		return "", &fs.PathError{Op: op, Path: name, Err: fs.ErrInvalid}
+	}
+	return path.Join(fsys.root, name), nil
+}
+
+// params returns request parameters addressing the HiDrive path.
+func (fsys *FS) params(p string) *Parameters {
+	params := NewParameters().SetPath(p)
+	if fsys.snapshot != "" {
+		params.SetSnapshot(fsys.snapshot)
+	}
+	return params
+}
+
+// stat queries information about the object with the given name.
+func (fsys *FS) stat(op, name string) (*Object, error) {
+	p, err := fsys.remotePath(op, name)
+	if err != nil {
+		return nil, err
+	}
+
+	obj, err := Meta{fsys.api}.Get(fsys.ctx, fsys.params(p).SetFields(fsFields).Values)
+	if err != nil {
+		return nil, newPathError(op, name, err)
+	}
+	return obj, nil
+}
+
+// Open opens the named file or directory for reading, implements fs.FS.
+func (fsys *FS) Open(name string) (fs.File, error) {
+	obj, err := fsys.stat("open", name)
+	if err != nil {
+		return nil, err
+	}
+
+	info := fsys.fileInfo(path.Base(name), obj)
+	if obj.Type == "dir" {
+		return &fsDir{fsys: fsys, name: name, info: info}, nil
+	}
+
+	params := NewParameters().SetPid(obj.ID)
+	if fsys.snapshot != "" {
+		p, _ := fsys.remotePath("open", name)
+		params = fsys.params(p)
+	}
+	return &fsFile{RemoteFile: File{fsys.api}.openRemote(fsys.ctx, obj, params.Values, &fsFileOptions), info: info}, nil
+}
+
+// Stat returns fs.FileInfo describing the named file or directory, implements fs.StatFS.
+func (fsys *FS) Stat(name string) (fs.FileInfo, error) {
+	obj, err := fsys.stat("stat", name)
+	if err != nil {
+		return nil, err
+	}
+	return fsys.fileInfo(path.Base(name), obj), nil
+}
+
+// ReadFile reads the whole named file with a single request, implements fs.ReadFileFS.
+func (fsys *FS) ReadFile(name string) ([]byte, error) {
+	p, err := fsys.remotePath("readfile", name)
+	if err != nil {
+		return nil, err
+	}
+
+	rc, err := File{fsys.api}.Get(fsys.ctx, fsys.params(p).Values)
+	if err != nil {
+		return nil, newPathError("readfile", name, err)
+	}
+	defer rc.Close()
+
+	data, err := io.ReadAll(rc)
+	if err != nil {
+		return nil, newPathError("readfile", name, err)
+	}
+	return data, nil
+}
+
+// ReadDir reads the named directory and returns its entries sorted by name, implements fs.ReadDirFS.
+func (fsys *FS) ReadDir(name string) ([]fs.DirEntry, error) {
+	p, err := fsys.remotePath("readdir", name)
+	if err != nil {
+		return nil, err
+	}
+
+	fields := []string{"type", "nmembers"}
+	for _, f := range fsFields {
+		fields = append(fields, "members."+f)
+	}
+	// the type of the directory itself is returned with the members, so a single request lists a small directory
+	var entries []fs.DirEntry
+	it := Dir{fsys.api}.List(fsys.ctx, fsys.params(p).SetFields(fields).Values, 0)
+	for it.Next() {
+		entries = append(entries, fs.FileInfoToDirEntry(fsys.fileInfo(it.Object().Name, it.Object())))
+	}
+	if err := it.Err(); err != nil {
+		return nil, newPathError("readdir", name, err)
+	}
+	if it.dir != nil && it.dir.Type != "dir" {
+		return nil, &fs.PathError{Op: "readdir", Path: name, Err: errNotDir}
+	}
+
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Name() < entries[j].Name() })
+	return entries, nil
+}
+
+// fileInfo creates fs.FileInfo for the object.
+func (fsys *FS) fileInfo(name string, obj *Object) *fileInfo {
+	if name == "." {
+		name = path.Base(fsys.root)
+	}
+	return &fileInfo{name: name, obj: obj, readOnly: fsys.snapshot != ""}
+}
+
+var (
+	errNotDir = errors.New("not a directory")
+	errIsDir  = errors.New("is a directory")
+)
+
+// newPathError wraps the error returned by API call into *fs.PathError.
+func newPathError(op, name string, err error) error {
+	var kind error
+	switch {
+	case errors.Is(err, ErrNotFound):
+		kind = fs.ErrNotExist
+	case errors.Is(err, ErrForbidden), errors.Is(err, ErrUnauthorized):
+		kind = fs.ErrPermission
+	case errors.Is(err, ErrConflict):
+		kind = fs.ErrExist
+	}
+	if kind != nil {
+		err = &fsError{err: err, kind: kind}
+	}
+	return &fs.PathError{Op: op, Path: name, Err: err}
+}
+
+// fsError - error of API call which also matches the corresponding fs error.
+type fsError struct {
+	err  error
+	kind error
+}
+
+func (e *fsError) Error() string   { return e.err.Error() }
+func (e *fsError) Unwrap() []error { return []error{e.err, e.kind} }
+
+/*
+fileInfo - implementation of fs.FileInfo for [Object].
+
+Files have mode 0444 and directories 0555 plus fs.ModeDir, write permission for the owner is added
+if the object is writable and the filesystem is not a snapshot view.
+*/
+type fileInfo struct {
+	name     string
+	obj      *Object
+	readOnly bool
+}
+
+func (fi *fileInfo) Name() string { return fi.name }
+func (fi *fileInfo) Size() int64 {
+	if fi.obj.Size < 0 || fi.IsDir() {
+		return 0
+	}
+	return fi.obj.Size
+}
+func (fi *fileInfo) ModTime() time.Time { return time.Time(fi.obj.MTime) }
+func (fi *fileInfo) IsDir() bool        { return fi.obj.Type == "dir" }
+func (fi *fileInfo) Sys() any           { return fi.obj }
+
+func (fi *fileInfo) Mode() fs.FileMode {
+	mode := fs.FileMode(0o444)
+	if fi.IsDir() {
+		mode = fs.ModeDir | 0o555
+	}
+	if fi.obj.Writable && !fi.readOnly {
+		mode |= 0o200
+	}
+	return mode
+}
+
+// fsFile - regular file opened by [FS].
+type fsFile struct {
+	*RemoteFile
+	info *fileInfo
+}
+
+func (f *fsFile) Stat() (fs.FileInfo, error) {
+	return f.info, nil
+}
+
+// fsDir - directory opened by [FS], implements fs.ReadDirFile.
+type fsDir struct {
+	fsys    *FS
+	name    string
+	info    *fileInfo
+	entries []fs.DirEntry
+	read    bool
+	closed  bool
+}
+
+func (d *fsDir) Stat() (fs.FileInfo, error) {
+	return d.info, nil
+}
+
+func (d *fsDir) Read([]byte) (int, error) {
+	return 0, &fs.PathError{Op: "read", Path: d.name, Err: errIsDir}
+}
+
+func (d *fsDir) Close() error {
+	if d.closed {
+		return &fs.PathError{Op: "close", Path: d.name, Err: fs.ErrClosed}
+	}
+	d.closed = true
+	return nil
+}
+
+// ReadDir returns the next `n` entries of the directory or all remaining entries if `n` <= 0.
+func (d *fsDir) ReadDir(n int) ([]fs.DirEntry, error) {
+	if d.closed {
+		return nil, &fs.PathError{Op: "readdir", Path: d.name, Err: fs.ErrClosed}
+	}
+	if !d.read {
+		entries, err := d.fsys.ReadDir(d.name)
+		if err != nil {
+			return nil, err
+		}
+		d.entries, d.read = entries, true
+	}
+
+	if n <= 0 {
+		out := d.entries
+		d.entries = nil
+		return out, nil
+	}
+	if len(d.entries) == 0 {
+		return nil, io.EOF
+	}
+	if n > len(d.entries) {
+		n = len(d.entries)
+	}
+	out := d.entries[:n:n]
+	d.entries = d.entries[n:]
+	return out, nil
+}
@@ -0,0 +1,151 @@
+package go_hidrive_test
+
+import (
+	"errors"
+	"io"
+	"io/fs"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	hidrive "github.com/Burmuley/go-hidrive"
+	"github.com/Burmuley/go-hidrive/hidrivetest"
+)
+
+// queryTransport records query parameters of requests made through it.
+type queryTransport struct {
+	base    http.RoundTripper
+	queries []map[string][]string
+}
+
+func (t *queryTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	t.queries = append(t.queries, req.URL.Query())
+	return t.base.RoundTrip(req)
+}
+
+func newTestFS(t *testing.T) (*hidrivetest.Server, *hidrive.FS) {
+	t.Helper()
+	srv, _ := newWalkServer(t)
+	if err := srv.AddFile("/public/root/big.bin", randomBytes(3<<20), time.Unix(1600000000, 0)); err != nil {
+		t.Fatalf("AddFile() error = %v", err)
+	}
+	return srv, hidrive.NewFS(hidrive.NewApi(srv.Client(), srv.Endpoint()), "/public/root")
+}
+
+func TestFS(t *testing.T) {
+	srv, fsys := newTestFS(t)
+	defer srv.Close()
+
+	if err := fstest.TestFS(fsys, "a.txt", "b/c.txt", "b/d/e.txt", "h", "i/k/l.txt", "big.bin"); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestFS_Consumers(t *testing.T) {
+	srv, fsys := newTestFS(t)
+	defer srv.Close()
+
+	t.Run("fs.Glob", func(t *testing.T) {
+		got, err := fs.Glob(fsys, "b/*.txt")
+		if want := []string{"b/c.txt", "b/g.txt"}; err != nil || !reflect.DeepEqual(got, want) {
+			t.Errorf("fs.Glob() = %v, %v, want %v", got, err, want)
+		}
+	})
+
+	t.Run("fs.ReadFile", func(t *testing.T) {
+		got, err := fs.ReadFile(fsys, "b/d/e.txt")
+		if err != nil || string(got) != "root/b/d/e.txt" {
+			t.Errorf("fs.ReadFile() = %q, %v", got, err)
+		}
+	})
+
+	t.Run("http.FS", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/i/k/l.txt", nil)
+		req.Header.Set("Range", "bytes=5-")
+		http.FileServer(http.FS(fsys)).ServeHTTP(rec, req)
+		if body, _ := io.ReadAll(rec.Body); rec.Code != http.StatusPartialContent || string(body) != "i/k/l.txt" {
+			t.Errorf("http.FS response = %d %q", rec.Code, body)
+		}
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		_, err := fsys.Stat("missing.txt")
+		var pathErr *fs.PathError
+		if !errors.As(err, &pathErr) || !errors.Is(err, fs.ErrNotExist) || !errors.Is(err, hidrive.ErrNotFound) {
+			t.Errorf("Stat() error = %v, want *fs.PathError matching fs.ErrNotExist", err)
+		}
+		if _, err := fsys.Open("../etc"); !errors.Is(err, fs.ErrInvalid) {
+			t.Errorf("Open() error = %v, want %v", err, fs.ErrInvalid)
+		}
+		if _, err := fsys.ReadDir("a.txt"); err == nil {
+			t.Errorf("ReadDir() of a file error = nil")
+		}
+	})
+}
+
+func TestFS_ReadDir_Requests(t *testing.T) {
+	srv, _ := newTestFS(t)
+	defer srv.Close()
+	transport := &queryTransport{base: srv.Client().Transport}
+	fsys := hidrive.NewFS(hidrive.NewApi(&http.Client{Transport: transport}, srv.Endpoint()), "/public/root")
+
+	entries, err := fsys.ReadDir("b")
+	if err != nil || len(entries) == 0 {
+		t.Fatalf("ReadDir() = %v, %v", entries, err)
+	}
+	if len(transport.queries) != 1 {
+		t.Errorf("ReadDir() made %d requests, want 1", len(transport.queries))
+	}
+}
+
+func TestFS_Snapshot(t *testing.T) {
+	srv, _ := newWalkServer(t)
+	defer srv.Close()
+	if err := srv.AddSnapshot("daily", time.Now()); err != nil {
+		t.Fatalf("AddSnapshot() error = %v", err)
+	}
+	if err := srv.AddFile("/public/root/a.txt", []byte("modified"), time.Now()); err != nil {
+		t.Fatalf("AddFile() error = %v", err)
+	}
+	transport := &queryTransport{base: srv.Client().Transport}
+	fsys := hidrive.NewFS(hidrive.NewApi(&http.Client{Transport: transport}, srv.Endpoint()), "/public/root")
+	snap := fsys.Snapshot("daily")
+
+	info, err := snap.Stat("a.txt")
+	if err != nil {
+		t.Fatalf("Stat() error = %v", err)
+	}
+	if info.Mode().Perm()&0o222 != 0 {
+		t.Errorf("Stat() mode = %v, want read-only", info.Mode())
+	}
+	if _, err := snap.ReadDir("b"); err != nil {
+		t.Fatalf("ReadDir() error = %v", err)
+	}
+	f, err := snap.Open("a.txt")
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	if data, err := io.ReadAll(f); err != nil || string(data) != "root/a.txt" {
+		t.Fatalf("ReadAll() = %q, %v, want content as of the snapshot", data, err)
+	}
+	f.Close()
+
+	for _, q := range transport.queries {
+		if got := q["snapshot"]; len(got) != 1 || got[0] != "daily" {
+			t.Errorf("request %v does not address snapshot", q)
+		}
+	}
+
+	// the original filesystem is not affected
+	transport.queries = nil
+	if _, err := fsys.Stat("a.txt"); err != nil {
+		t.Fatalf("Stat() error = %v", err)
+	}
+	if _, ok := transport.queries[0]["snapshot"]; ok {
+		t.Errorf("request %v addresses snapshot", transport.queries[0])
+	}
+}
@@ -1,10 +1,11 @@
 module github.com/Burmuley/go-hidrive
 
-go 1.19
+go 1.23
 
 require (
 	github.com/google/uuid v1.3.0
 	golang.org/x/oauth2 v0.4.0
+	golang.org/x/text v0.13.0
 )
 
 require (
@@ -0,0 +1,205 @@
+package go_hidrive
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"hash"
+	"io"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+const (
+	HashBlockSize = 4096      // Size of data blocks the content hash is computed over
+	HashSize      = sha1.Size // Size of the content and name hash in bytes
+	hashLevelSums = 256       // Number of checksums aggregated in a single level of the content hash
+)
+
+/*
+NewHash - create new hash.Hash computing HiDrive content hash, the value of `chash` field of [Object] for files.
+
+The algorithm splits the data into 4 KiB blocks, the last block is padded with null bytes. Every block is hashed
+with SHA-1, blocks consisting only of null bytes produce a checksum of 20 null bytes instead. Checksums are then
+aggregated hierarchically: the checksums of up to 256 consecutive blocks form level 1, every checksum is hashed with
+SHA-1 together with its position within the level (a single byte) and the results are added up as 160-bit
+big-endian unsigned integers, null checksums are skipped. Each full level is aggregated into the next level the same
+way. The checksum of the highest level is the content hash, unless the level holds a single checksum - then this
+checksum itself is the content hash (so the hash of a single block file is SHA-1 of the block). Empty files (and files
+containing only null bytes) have a hash of 20 null bytes. Use [ContentHash] to hash the whole reader at once.
+*/
+func NewHash() hash.Hash {
+	return &contentHash{}
+}
+
+// ContentHash returns hex-encoded HiDrive content hash of the data read from `r`, see [NewHash].
+func ContentHash(r io.Reader) (string, error) {
+	h := NewHash()
+	if _, err := io.Copy(h, r); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// NameHash returns hex-encoded HiDrive name hash, the value of `nhash` field of [Object], which is SHA-1 of the name.
+// The name is normalized to Unicode NFC form first, as HiDrive stores names in this form.
+func NameHash(name string) string {
+	sum := sha1.Sum([]byte(norm.NFC.String(name)))
+	return hex.EncodeToString(sum[:])
+}
+
+// contentHash - implementation of HiDrive content hash.
+type contentHash struct {
+	block  [HashBlockSize]byte
+	n      int // number of bytes in block
+	levels []hashLevel
+}
+
+// hashLevel - aggregated checksum of a single level.
+type hashLevel struct {
+	sum   [HashSize]byte
+	last  [HashSize]byte // last checksum added to the level
+	count int
+}
+
+// add aggregates the checksum of the next position into the level.
+func (l *hashLevel) add(sum []byte) {
+	if !isZero(sum) {
+		h := sha1.New()
+		h.Write(sum)
+		h.Write([]byte{byte(l.count)})
+		add160(&l.sum, h.Sum(nil))
+	}
+	copy(l.last[:], sum)
+	l.count++
+}
+
+func (h *contentHash) Write(p []byte) (int, error) {
+	n := len(p)
+	for len(p) > 0 {
+		k := copy(h.block[h.n:], p)
+		h.n += k
+		p = p[k:]
+		if h.n == HashBlockSize {
+			h.levels = pushSum(h.levels, blockSum(h.block[:]))
+			h.n = 0
+		}
+	}
+	return n, nil
+}
+
+func (h *contentHash) Sum(b []byte) []byte {
+	levels := append([]hashLevel(nil), h.levels...)
+	if h.n > 0 {
+		var block [HashBlockSize]byte
+		copy(block[:], h.block[:h.n])
+		levels = pushSum(levels, blockSum(block[:]))
+	}
+	if len(levels) == 0 {
+		return append(b, make([]byte, HashSize)...)
+	}
+
+	for i := 0; i < len(levels)-1; i++ {
+		if levels[i].count > 0 {
+			levels[i+1].add(levels[i].sum[:])
+		}
+	}
+	top := levels[len(levels)-1]
+	if top.count == 1 {
+		return append(b, top.last[:]...)
+	}
+	return append(b, top.sum[:]...)
+}
+
+func (h *contentHash) Reset() {
+	h.n = 0
+	h.levels = h.levels[:0]
+}
+
+func (h *contentHash) Size() int      { return HashSize }
+func (h *contentHash) BlockSize() int { return HashBlockSize }
+
+// blockSum returns the checksum of a full block.
+func blockSum(block []byte) []byte {
+	if isZero(block) {
+		return make([]byte, HashSize)
+	}
+	sum := sha1.Sum(block)
+	return sum[:]
+}
+
+// pushSum adds the block checksum to level 1, full levels are aggregated into the next ones.
+func pushSum(levels []hashLevel, sum []byte) []hashLevel {
+	for i := 0; ; i++ {
+		if i == len(levels) {
+			levels = append(levels, hashLevel{})
+		}
+		levels[i].add(sum)
+		if levels[i].count < hashLevelSums {
+			return levels
+		}
+		sum = append([]byte(nil), levels[i].sum[:]...)
+		levels[i] = hashLevel{}
+	}
+}
+
+// add160 adds `b` to `a` as 160-bit big-endian unsigned integers, the overflow is discarded.
+func add160(a *[HashSize]byte, b []byte) {
+	carry := 0
+	for i := HashSize - 1; i >= 0; i-- {
+		s := int(a[i]) + int(b[i]) + carry
+		a[i] = byte(s)
+		carry = s >> 8
+	}
+}
+
+func isZero(p []byte) bool {
+	for _, b := range p {
+		if b != 0 {
+			return false
+		}
+	}
+	return true
+}
+
+/*
+LevelHashes - compute hex-encoded checksums of the given level of HiDrive content hash over the data read from `r`,
+to be compared with the result of [File.Hash].
+
+Level 0 contains checksums of every 4 KiB block, level 1 - aggregated checksums of every 256 blocks (1 MiB),
+level N - aggregated checksums of every 256 checksums of level N-1. See [NewHash] for details of the algorithm.
+*/
+func LevelHashes(r io.Reader, level int) ([]string, error) {
+	var sums [][]byte
+	block := make([]byte, HashBlockSize)
+	for {
+		n, err := io.ReadFull(r, block)
+		if n > 0 {
+			clear(block[n:])
+			sums = append(sums, blockSum(block))
+		}
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	for i := 0; i < level; i++ {
+		var next [][]byte
+		for start := 0; start < len(sums); start += hashLevelSums {
+			var l hashLevel
+			for _, sum := range sums[start:min(start+hashLevelSums, len(sums))] {
+				l.add(sum)
+			}
+			next = append(next, l.sum[:])
+		}
+		sums = next
+	}
+
+	out := make([]string, len(sums))
+	for i, sum := range sums {
+		out[i] = hex.EncodeToString(sum)
+	}
+	return out, nil
+}
@@ -0,0 +1,241 @@
+package go_hidrive_test
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"strings"
+	"testing"
+	"time"
+
+	hidrive "github.com/Burmuley/go-hidrive"
+	"github.com/Burmuley/go-hidrive/hidrivetest"
+)
+
+/*
+referenceHash computes the content hash level by level over the whole data to check the incremental implementation,
+the algorithm itself is checked against values computed by HiDrive by TestContentHash_Recorded.
+*/
+func referenceHash(data []byte) []byte {
+	zero := make([]byte, hidrive.HashSize)
+	var sums [][]byte
+	for off := 0; off < len(data); off += hidrive.HashBlockSize {
+		block := make([]byte, hidrive.HashBlockSize)
+		copy(block, data[off:])
+		if bytes.Equal(block, make([]byte, hidrive.HashBlockSize)) {
+			sums = append(sums, zero)
+			continue
+		}
+		sum := sha1.Sum(block)
+		sums = append(sums, sum[:])
+	}
+	if len(sums) == 0 {
+		return zero
+	}
+
+	// a single checksum is the hash itself, it is not aggregated
+	for len(sums) > 1 {
+		var next [][]byte
+		for start := 0; start < len(sums); start += 256 {
+			end := min(start+256, len(sums))
+			next = append(next, aggregate(sums[start:end]))
+		}
+		sums = next
+	}
+	return sums[0]
+}
+
+func aggregate(sums [][]byte) []byte {
+	acc := make([]byte, hidrive.HashSize)
+	for i, s := range sums {
+		if bytes.Equal(s, make([]byte, hidrive.HashSize)) {
+			continue
+		}
+		h := sha1.Sum(append(append([]byte(nil), s...), byte(i)))
+		carry := 0
+		for j := hidrive.HashSize - 1; j >= 0; j-- {
+			v := int(acc[j]) + int(h[j]) + carry
+			acc[j], carry = byte(v), v>>8
+		}
+	}
+	return acc
+}
+
+func TestNewHash(t *testing.T) {
+	const block = hidrive.HashBlockSize
+	sizes := []int{1, block - 1, block, block + 1, 255 * block, 256 * block, 256*block + 1, 257 * block, 512*block + 3}
+	for _, size := range sizes {
+		data := randomBytes(size)
+		// a block of null bytes in the middle is skipped
+		if size > 3*block {
+			copy(data[block:], make([]byte, block))
+		}
+		want := referenceHash(data)
+
+		for _, writeSize := range []int{1000, block, 7 * block} {
+			h := hidrive.NewHash()
+			for off := 0; off < len(data); off += writeSize {
+				h.Write(data[off:min(off+writeSize, len(data))])
+				// Sum must not change the state
+				h.Sum(nil)
+			}
+			if got := h.Sum(nil); !bytes.Equal(got, want) {
+				t.Errorf("size %d, writes of %d: Sum() = %x, want %x", size, writeSize, got, want)
+			}
+
+			h.Reset()
+			h.Write([]byte("a"))
+			if got, want := h.Sum(nil), referenceHash([]byte("a")); !bytes.Equal(got, want) {
+				t.Errorf("Sum() after Reset() = %x, want %x", got, want)
+			}
+		}
+	}
+}
+
+func TestNameHash(t *testing.T) {
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"hello", "aaf4c61ddcc5e8a2dabede0f3b482cd9aea9434d"},
+		{"Übung.txt", "eb1e4e4fd5d75c056f5607c1c04d7f5c5ee3d1a5"},
+		{"U\u0308bung.txt", "eb1e4e4fd5d75c056f5607c1c04d7f5c5ee3d1a5"}, // decomposed form is normalized to NFC
+	}
+	for _, tt := range tests {
+		if got := hidrive.NameHash(tt.name); got != tt.want {
+			t.Errorf("NameHash(%q) = %s, want %s", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestContentHash_MatchesObject(t *testing.T) {
+	srv := hidrivetest.NewServer()
+	defer srv.Close()
+	data := randomBytes(100000)
+	if err := srv.AddFile("/public/data.bin", data, time.Now()); err != nil {
+		t.Fatalf("AddFile() error = %v", err)
+	}
+
+	obj, err := hidrive.NewMeta(srv.Client(), srv.Endpoint()).Get(context.Background(),
+		hidrive.NewParameters().SetPath("/public/data.bin").SetFields([]string{"chash", "nhash"}).Values)
+	if err != nil {
+		t.Fatalf("Meta.Get() error = %v", err)
+	}
+	if want, _ := hidrive.ContentHash(bytes.NewReader(data)); obj.CHash != want {
+		t.Errorf("Object.CHash = %s, want %s", obj.CHash, want)
+	}
+	if want := hidrive.NameHash("data.bin"); obj.NHash != want {
+		t.Errorf("Object.NHash = %s, want %s", obj.NHash, want)
+	}
+}
+
+func TestLevelHashes(t *testing.T) {
+	const block = hidrive.HashBlockSize
+	data := randomBytes(300*block + 5)
+
+	level0, err := hidrive.LevelHashes(bytes.NewReader(data), 0)
+	if err != nil || len(level0) != 301 {
+		t.Fatalf("LevelHashes(0) = %d sums, %v, want 301", len(level0), err)
+	}
+	for i, off := range []int{0, 150 * block, 300 * block} {
+		padded := make([]byte, block)
+		copy(padded, data[off:])
+		sum := sha1.Sum(padded)
+		if level0[i*150] != hex.EncodeToString(sum[:]) {
+			t.Errorf("level 0 sum %d = %s, want %x", i*150, level0[i*150], sum)
+		}
+	}
+
+	level1, err := hidrive.LevelHashes(bytes.NewReader(data), 1)
+	if err != nil || len(level1) != 2 {
+		t.Fatalf("LevelHashes(1) = %d sums, %v, want 2", len(level1), err)
+	}
+	var blockSums [][]byte
+	for _, sum := range level0[:256] {
+		b, _ := hex.DecodeString(sum)
+		blockSums = append(blockSums, b)
+	}
+	if want := hex.EncodeToString(aggregate(blockSums)); level1[0] != want {
+		t.Errorf("level 1 sum 0 = %s, want %s", level1[0], want)
+	}
+
+	// the top level of a file with less than 256 blocks is the content hash
+	level2, _ := hidrive.LevelHashes(bytes.NewReader(data[:200*block]), 1)
+	if want, _ := hidrive.ContentHash(bytes.NewReader(data[:200*block])); len(level2) != 1 || level2[0] != want {
+		t.Errorf("LevelHashes(1) = %v, want [%s]", level2, want)
+	}
+}
+
+func TestFile_Hash(t *testing.T) {
+	const block = hidrive.HashBlockSize
+	srv := hidrivetest.NewServer()
+	defer srv.Close()
+	data := randomBytes(600 * block)
+	if err := srv.AddFile("/public/data.bin", data, time.Now()); err != nil {
+		t.Fatalf("AddFile() error = %v", err)
+	}
+	fileApi := hidrive.NewFile(srv.Client(), srv.Endpoint())
+	ctx := context.Background()
+
+	tests := []struct {
+		name   string
+		params *hidrive.Parameters
+		level  int
+		want   func(sums []string) [][]string
+	}{
+		{"level 0", hidrive.NewParameters().SetLevel(0), 0, func(s []string) [][]string { return [][]string{s} }},
+		{"level 1", hidrive.NewParameters().SetLevel(1), 1, func(s []string) [][]string { return [][]string{s} }},
+		{
+			"ranges", hidrive.NewParameters().SetLevel(0).SetRanges(hidrive.HashRange{0, 9}, hidrive.HashRange{590, 700}), 0,
+			func(s []string) [][]string { return [][]string{s[0:10], s[590:]} },
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := fileApi.Hash(ctx, tt.params.SetPath("/public/data.bin").Values)
+			if err != nil {
+				t.Fatalf("Hash() error = %v", err)
+			}
+			sums, _ := hidrive.LevelHashes(bytes.NewReader(data), tt.level)
+			want := tt.want(sums)
+			if got.Level != tt.level || len(got.List) != len(want) {
+				t.Fatalf("Hash() = level %d, %d lists, want level %d, %d lists", got.Level, len(got.List), tt.level, len(want))
+			}
+			for i := range want {
+				if strings.Join(got.List[i], ",") != strings.Join(want[i], ",") {
+					t.Errorf("Hash() list %d = %v, want %v", i, got.List[i], want[i])
+				}
+			}
+			if chash, _ := hidrive.ContentHash(bytes.NewReader(data)); got.CHash != chash {
+				t.Errorf("Hash() chash = %s, want %s", got.CHash, chash)
+			}
+		})
+	}
+
+	t.Run("differing blocks", func(t *testing.T) {
+		local := bytes.Clone(data)
+		local[300*block+10] ^= 0xff
+		remote, err := fileApi.Hash(ctx, hidrive.NewParameters().SetPath("/public/data.bin").SetLevel(1).Values)
+		if err != nil {
+			t.Fatalf("Hash() error = %v", err)
+		}
+		sums, _ := hidrive.LevelHashes(bytes.NewReader(local), 1)
+		var diff []int
+		for i := range sums {
+			if sums[i] != remote.List[0][i] {
+				diff = append(diff, i)
+			}
+		}
+		if len(diff) != 1 || diff[0] != 1 {
+			t.Errorf("differing level 1 checksums = %v, want [1]", diff)
+		}
+	})
+
+	t.Run("range not satisfiable", func(t *testing.T) {
+		params := hidrive.NewParameters().SetPath("/public/data.bin").SetLevel(1).SetRanges(hidrive.HashRange{5, 6})
+		if _, err := fileApi.Hash(ctx, params.Values); err == nil {
+			t.Errorf("Hash() error = nil, want error")
+		}
+	})
+}
@@ -0,0 +1,73 @@
+package go_hidrive
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"os"
+	"testing"
+)
+
+/*
+hashVectorsFile - content hashes computed by HiDrive: examples from HiDrive Synchronization specification, values
+checked by rclone and values recorded by TestContentHash_HiDrive (integration tests).
+*/
+const hashVectorsFile = "testdata/chash_vectors.json"
+
+// hashVector - content hash computed by HiDrive for the data described by `Data` and `Pattern`.
+type hashVector struct {
+	Name   string `json:"name"`
+	Source string `json:"source,omitempty"`
+	Data   string `json:"data,omitempty"`
+	// Pattern - entries at even indices repeat `Data` the given number of times,
+	// entries at odd indices add the given number of null bytes
+	Pattern []int  `json:"pattern,omitempty"`
+	CHash   string `json:"chash"`
+}
+
+// reader returns the data described by the vector.
+func (v hashVector) reader() io.Reader {
+	var parts []io.Reader
+	for i, n := range v.Pattern {
+		if i%2 == 0 {
+			parts = append(parts, bytes.NewReader(bytes.Repeat([]byte(v.Data), n)))
+		} else {
+			parts = append(parts, io.LimitReader(zeroReader{}, int64(n)))
+		}
+	}
+	return io.MultiReader(parts...)
+}
+
+type zeroReader struct{}
+
+func (zeroReader) Read(p []byte) (int, error) {
+	clear(p)
+	return len(p), nil
+}
+
+func readHashVectors(t *testing.T) []hashVector {
+	t.Helper()
+	raw, err := os.ReadFile(hashVectorsFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var vectors []hashVector
+	if err := json.Unmarshal(raw, &vectors); err != nil {
+		t.Fatalf("%s: %v", hashVectorsFile, err)
+	}
+	if len(vectors) == 0 {
+		t.Fatalf("%s: no content hashes", hashVectorsFile)
+	}
+	return vectors
+}
+
+// TestContentHash_Recorded checks ContentHash against the values computed by HiDrive and stored in hashVectorsFile.
+func TestContentHash_Recorded(t *testing.T) {
+	for _, v := range readHashVectors(t) {
+		t.Run(v.Name, func(t *testing.T) {
+			if got, err := ContentHash(v.reader()); err != nil || got != v.CHash {
+				t.Errorf("ContentHash() = %s, %v, HiDrive chash = %s", got, err, v.CHash)
+			}
+		})
+	}
+}
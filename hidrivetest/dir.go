@@ -0,0 +1,257 @@
+package hidrivetest
+
+import (
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// defaultLimit is the implicit limit of directory members returned by HiDrive.
+const defaultLimit = 5000
+
+// handleDir serves `/dir` endpoint.
+func (s *Server) handleDir(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	switch r.Method {
+	case http.MethodGet:
+		s.dirGet(w, r)
+	case http.MethodPost:
+		s.dirCreate(w, r)
+	case http.MethodDelete:
+		s.dirDelete(w, r)
+	default:
+		methodNotAllowed(w)
+	}
+}
+
+func (s *Server) dirGet(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	n, herr := s.lookupAt(q.Get("snapshot"), q.Get("pid"), q.Get("path"))
+	if herr != nil {
+		writeError(w, herr)
+		return
+	}
+	if !n.dir {
+		writeError(w, badRequest("not a directory"))
+		return
+	}
+
+	members, herr := filterMembers(n.sortedChildren(), q.Get("members"))
+	if herr != nil {
+		writeError(w, herr)
+		return
+	}
+	if herr := sortMembers(members, q.Get("sort")); herr != nil {
+		writeError(w, herr)
+		return
+	}
+	total := len(members)
+
+	offset, limit, herr := parseLimit(q.Get("limit"))
+	if herr != nil {
+		writeError(w, herr)
+		return
+	}
+	if offset > len(members) {
+		offset = len(members)
+	}
+	members = members[offset:]
+	if s.MaxListLimit > 0 && limit > s.MaxListLimit {
+		limit = s.MaxListLimit
+	}
+	if len(members) > limit {
+		members = members[:limit]
+	}
+
+	if q.Get("members") == "none" {
+		members = nil
+	}
+	obj := s.object(n, members, total)
+	writeJSON(w, http.StatusOK, filterFields(obj, fieldsParam(r)))
+}
+
+func (s *Server) dirCreate(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	parent, name, herr := s.lookupParent(q.Get("pid"), q.Get("path"))
+	if herr != nil {
+		writeError(w, herr)
+		return
+	}
+
+	mtime, herr := parseTime(q.Get("mtime"))
+	if herr != nil {
+		writeError(w, herr)
+		return
+	}
+	if mtime.IsZero() {
+		mtime = time.Now()
+	}
+
+	if _, exists := parent.children[name]; exists {
+		if q.Get("on_exist") != "autoname" {
+			writeError(w, conflict())
+			return
+		}
+		name = autoname(parent, name)
+	}
+	if herr := setParentMTime(parent, q.Get("parent_mtime")); herr != nil {
+		writeError(w, herr)
+		return
+	}
+
+	n := s.newNode(parent, name, true, mtime)
+	writeJSON(w, http.StatusCreated, s.object(n, nil, 0))
+}
+
+func (s *Server) dirDelete(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	n, herr := s.lookup(q.Get("pid"), q.Get("path"))
+	if herr != nil {
+		writeError(w, herr)
+		return
+	}
+	if !n.dir {
+		writeError(w, badRequest("not a directory"))
+		return
+	}
+	if n.parent == nil {
+		writeError(w, &httpError{status: http.StatusForbidden, msg: "root directory can not be deleted"})
+		return
+	}
+	if len(n.children) > 0 && q.Get("recursive") != "true" {
+		writeError(w, conflict())
+		return
+	}
+	if herr := setParentMTime(n.parent, q.Get("parent_mtime")); herr != nil {
+		writeError(w, herr)
+		return
+	}
+
+	delete(n.parent.children, n.name)
+	s.forget(n)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handleDirCopy serves `/dir/copy` endpoint.
+func (s *Server) handleDirCopy(w http.ResponseWriter, r *http.Request) {
+	s.transfer(w, r, false, true)
+}
+
+// handleDirMove serves `/dir/move` endpoint.
+func (s *Server) handleDirMove(w http.ResponseWriter, r *http.Request) {
+	s.transfer(w, r, true, true)
+}
+
+// handleDirRename serves `/dir/rename` endpoint.
+func (s *Server) handleDirRename(w http.ResponseWriter, r *http.Request) {
+	s.rename(w, r, true)
+}
+
+// filterMembers applies `members` parameter to the list of directory members.
+func filterMembers(members []*node, param string) ([]*node, *httpError) {
+	if param == "" || param == "all" || param == "none" {
+		return members, nil
+	}
+
+	types := map[string]bool{}
+	for _, t := range strings.Split(param, ",") {
+		switch t {
+		case "dir", "file", "symlink":
+			types[t] = true
+		default:
+			return nil, badRequest("invalid members value " + t)
+		}
+	}
+
+	out := make([]*node, 0, len(members))
+	for _, m := range members {
+		if types[m.objectType()] {
+			out = append(out, m)
+		}
+	}
+	return out, nil
+}
+
+// sortMembers applies `sort` parameter to the list of directory members.
+func sortMembers(members []*node, param string) *httpError {
+	if param == "" || param == "none" {
+		return nil
+	}
+
+	var less []func(a, b *node) int
+	for _, crit := range strings.Split(param, ",") {
+		desc := strings.HasPrefix(crit, "-")
+		var cmp func(a, b *node) int
+		switch strings.TrimPrefix(crit, "-") {
+		case "name":
+			cmp = func(a, b *node) int { return strings.Compare(strings.ToLower(a.name), strings.ToLower(b.name)) }
+		case "mtime":
+			cmp = func(a, b *node) int { return compareInt(a.mtime.UnixNano(), b.mtime.UnixNano()) }
+		case "size":
+			cmp = func(a, b *node) int { return compareInt(a.size(), b.size()) }
+		case "type":
+			cmp = func(a, b *node) int { return strings.Compare(a.objectType(), b.objectType()) }
+		case "category":
+			cmp = func(a, b *node) int { return strings.Compare(a.mimeType(), b.mimeType()) }
+		default:
+			return badRequest("invalid sort value " + crit)
+		}
+		if desc {
+			asc := cmp
+			cmp = func(a, b *node) int { return -asc(a, b) }
+		}
+		less = append(less, cmp)
+	}
+
+	sort.SliceStable(members, func(i, j int) bool {
+		for _, cmp := range less {
+			if c := cmp(members[i], members[j]); c != 0 {
+				return c < 0
+			}
+		}
+		return false
+	})
+	return nil
+}
+
+// parseLimit parses `limit` parameter in "<offset>,<limit>" or "<limit>" format.
+func parseLimit(param string) (int, int, *httpError) {
+	if param == "" {
+		return 0, defaultLimit, nil
+	}
+
+	offsetStr, limitStr := "0", param
+	if parts := strings.SplitN(param, ",", 2); len(parts) == 2 {
+		offsetStr, limitStr = parts[0], parts[1]
+	}
+
+	offset, err := strconv.Atoi(offsetStr)
+	if err != nil || offset < 0 {
+		return 0, 0, badRequest("invalid limit value " + param)
+	}
+	if limitStr == "none" || limitStr == "0" {
+		return offset, defaultLimit, nil
+	}
+	limit, err := strconv.Atoi(limitStr)
+	if err != nil || limit < 0 {
+		return 0, 0, badRequest("invalid limit value " + param)
+	}
+	if limit > defaultLimit {
+		limit = defaultLimit
+	}
+	return offset, limit, nil
+}
+
+func compareInt(a, b int64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,462 @@
+package hidrivetest
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	hidrive "github.com/Burmuley/go-hidrive"
+)
+
+// handleFile serves `/file` endpoint.
+func (s *Server) handleFile(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		s.fileGet(w, r)
+	case http.MethodPost:
+		s.fileUpload(w, r, false)
+	case http.MethodPut:
+		s.fileUpload(w, r, true)
+	case http.MethodPatch:
+		s.filePatch(w, r)
+	case http.MethodDelete:
+		s.fileDelete(w, r)
+	default:
+		methodNotAllowed(w)
+	}
+}
+
+func (s *Server) fileGet(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+
+	s.mu.Lock()
+	n, herr := s.lookupAt(q.Get("snapshot"), q.Get("pid"), q.Get("path"))
+	if herr == nil && n.dir {
+		herr = badRequest("not a file")
+	}
+	if herr != nil {
+		s.mu.Unlock()
+		writeError(w, herr)
+		return
+	}
+	content, name, mtime, mimeType := n.content, n.name, n.mtime, n.mimeType()
+	s.mu.Unlock()
+
+	w.Header().Set("Content-Type", mimeType)
+	w.Header().Set("ETag", fmt.Sprintf(`"%x-%x"`, mtime.Unix(), len(content)))
+	http.ServeContent(w, r, name, mtime, bytes.NewReader(content))
+}
+
+func (s *Server) fileUpload(w http.ResponseWriter, r *http.Request, overwrite bool) {
+	q := r.URL.Query()
+
+	body, herr := s.readBody(r)
+	if herr != nil {
+		writeError(w, herr)
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	name := q.Get("name")
+	if name == "" {
+		writeError(w, badRequest("name is required"))
+		return
+	}
+	if _, err := splitPath(name, false); err != nil || len(name) > 255 {
+		writeError(w, &httpError{status: http.StatusUnprocessableEntity, msg: fmt.Sprintf("invalid name %q", name)})
+		return
+	}
+
+	parent, herr := s.lookup(q.Get("dir_id"), q.Get("dir"))
+	if herr == nil && !parent.dir {
+		herr = notFound()
+	}
+	if herr != nil {
+		writeError(w, herr)
+		return
+	}
+
+	mtime, herr := parseTime(q.Get("mtime"))
+	if herr != nil {
+		writeError(w, herr)
+		return
+	}
+	if mtime.IsZero() {
+		mtime = time.Now()
+	}
+
+	existing, exists := parent.children[name]
+	var replaced int64
+	switch {
+	case exists && existing.dir:
+		writeError(w, conflict())
+		return
+	case exists && overwrite:
+		replaced = int64(len(existing.content))
+	case exists && q.Get("on_exist") == "autoname":
+		name = autoname(parent, name)
+		exists = false
+	case exists:
+		writeError(w, conflict())
+		return
+	}
+
+	if herr := s.checkQuota(parent, int64(len(body))-replaced); herr != nil {
+		writeError(w, herr)
+		return
+	}
+	if herr := setParentMTime(parent, q.Get("parent_mtime")); herr != nil {
+		writeError(w, herr)
+		return
+	}
+
+	n := existing
+	if !exists {
+		n = s.newNode(parent, name, false, mtime)
+	}
+	n.content = body
+	n.mtime = mtime
+
+	status := http.StatusCreated
+	if overwrite {
+		status = http.StatusOK
+	}
+	writeJSON(w, status, s.object(n, nil, 0))
+}
+
+func (s *Server) filePatch(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+
+	body, herr := s.readBody(r)
+	if herr != nil {
+		writeError(w, herr)
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	n, herr := s.lookup(q.Get("pid"), q.Get("path"))
+	if herr == nil && n.dir {
+		herr = badRequest("not a file")
+	}
+	if herr != nil {
+		writeError(w, herr)
+		return
+	}
+
+	offset, err := strconv.ParseInt(q.Get("offset"), 10, 64)
+	if err != nil || offset < 0 {
+		writeError(w, badRequest(fmt.Sprintf("invalid offset %q", q.Get("offset"))))
+		return
+	}
+	if offset > int64(len(n.content)) {
+		writeError(w, &httpError{status: http.StatusRequestedRangeNotSatisfiable})
+		return
+	}
+
+	end := offset + int64(len(body))
+	if grow := end - int64(len(n.content)); grow > 0 {
+		if herr := s.checkQuota(n.parent, grow); herr != nil {
+			writeError(w, herr)
+			return
+		}
+		n.content = append(n.content, make([]byte, grow)...)
+	}
+	copy(n.content[offset:end], body)
+	n.mtime = time.Now()
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) fileDelete(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	q := r.URL.Query()
+	n, herr := s.lookup(q.Get("pid"), q.Get("path"))
+	if herr == nil && n.dir {
+		herr = badRequest("not a file")
+	}
+	if herr != nil {
+		writeError(w, herr)
+		return
+	}
+	if herr := setParentMTime(n.parent, q.Get("parent_mtime")); herr != nil {
+		writeError(w, herr)
+		return
+	}
+
+	delete(n.parent.children, n.name)
+	s.forget(n)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handleFileCopy serves `/file/copy` endpoint.
+func (s *Server) handleFileCopy(w http.ResponseWriter, r *http.Request) {
+	s.transfer(w, r, false, false)
+}
+
+// handleFileMove serves `/file/move` endpoint.
+func (s *Server) handleFileMove(w http.ResponseWriter, r *http.Request) {
+	s.transfer(w, r, true, false)
+}
+
+// transfer implements copy and move operations for files and directories (`dir` is true).
+func (s *Server) transfer(w http.ResponseWriter, r *http.Request, move, dir bool) {
+	if r.Method != http.MethodPost {
+		methodNotAllowed(w)
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	q := r.URL.Query()
+	if move && q.Get("snapshot") != "" {
+		writeError(w, badRequest("can not move object out of a snapshot"))
+		return
+	}
+	src, herr := s.lookupAt(q.Get("snapshot"), q.Get("src_id"), q.Get("src"))
+	if herr == nil {
+		herr = checkType(src, dir)
+	}
+	if herr != nil {
+		writeError(w, herr)
+		return
+	}
+	if q.Get("dst") == "" {
+		writeError(w, badRequest("dst is required"))
+		return
+	}
+	dstParent, name, herr := s.lookupParent(q.Get("dst_id"), q.Get("dst"))
+	if herr != nil {
+		writeError(w, herr)
+		return
+	}
+
+	srcParent := src.parent
+	n, herr := s.place(src, dstParent, name, q.Get("on_exist"), move)
+	if herr != nil {
+		writeError(w, herr)
+		return
+	}
+
+	if !move && q.Get("preserve_mtime") != "true" {
+		n.mtime = time.Now()
+	}
+	if move {
+		if herr := setParentMTime(srcParent, q.Get("src_parent_mtime")); herr != nil {
+			writeError(w, herr)
+			return
+		}
+	}
+	if herr := setParentMTime(dstParent, q.Get("dst_parent_mtime")); herr != nil {
+		writeError(w, herr)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, s.object(n, nil, 0))
+}
+
+// checkType returns an error if the node is not a directory (`dir` is true) or a file or is the root directory.
+func checkType(n *node, dir bool) *httpError {
+	switch {
+	case n.dir && !dir:
+		return badRequest("not a file")
+	case !n.dir && dir:
+		return badRequest("not a directory")
+	case n.parent == nil:
+		return badRequest("root directory can not be modified")
+	}
+	return nil
+}
+
+// handleFileRename serves `/file/rename` endpoint.
+func (s *Server) handleFileRename(w http.ResponseWriter, r *http.Request) {
+	s.rename(w, r, false)
+}
+
+// rename implements rename operation for files and directories (`dir` is true).
+func (s *Server) rename(w http.ResponseWriter, r *http.Request, dir bool) {
+	if r.Method != http.MethodPost {
+		methodNotAllowed(w)
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	q := r.URL.Query()
+	n, herr := s.lookup(q.Get("pid"), q.Get("path"))
+	if herr == nil {
+		herr = checkType(n, dir)
+	}
+	if herr != nil {
+		writeError(w, herr)
+		return
+	}
+	name := q.Get("name")
+	if _, err := splitPath(name, false); err != nil || name == "" {
+		writeError(w, &httpError{status: http.StatusUnprocessableEntity, msg: fmt.Sprintf("invalid name %q", name)})
+		return
+	}
+
+	n, herr = s.place(n, n.parent, name, q.Get("on_exist"), true)
+	if herr != nil {
+		writeError(w, herr)
+		return
+	}
+	if herr := setParentMTime(n.parent, q.Get("parent_mtime")); herr != nil {
+		writeError(w, herr)
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, s.object(n, nil, 0))
+}
+
+// handleFileHash serves `/file/hash` endpoint, `level` defaults to 0 and all checksums are returned without `ranges`.
+func (s *Server) handleFileHash(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		methodNotAllowed(w)
+		return
+	}
+
+	q := r.URL.Query()
+	s.mu.Lock()
+	n, herr := s.lookupAt(q.Get("snapshot"), q.Get("pid"), q.Get("path"))
+	if herr == nil && n.dir {
+		herr = badRequest("not a file")
+	}
+	if herr != nil {
+		s.mu.Unlock()
+		writeError(w, herr)
+		return
+	}
+	content, chash := n.content, n.contentHash()
+	s.mu.Unlock()
+
+	level := 0
+	if v := q.Get("level"); v != "" {
+		var err error
+		if level, err = strconv.Atoi(v); err != nil || level < 0 {
+			writeError(w, badRequest(fmt.Sprintf("invalid level %q", v)))
+			return
+		}
+	}
+	sums, err := hidrive.LevelHashes(bytes.NewReader(content), level)
+	if err != nil {
+		writeError(w, &httpError{status: http.StatusInternalServerError, msg: err.Error()})
+		return
+	}
+
+	list := [][]string{sums}
+	if v := q.Get("ranges"); v != "" {
+		list = nil
+		for _, rng := range strings.Split(v, ",") {
+			start, end, err := parseHashRange(rng)
+			if err != nil {
+				writeError(w, badRequest(err.Error()))
+				return
+			}
+			if start >= int64(len(sums)) {
+				writeError(w, &httpError{status: http.StatusRequestedRangeNotSatisfiable})
+				return
+			}
+			list = append(list, sums[start:min(end+1, int64(len(sums)))])
+		}
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{"level": level, "chash": chash, "list": list})
+}
+
+// parseHashRange parses "start-end" range of checksum indices.
+func parseHashRange(rng string) (int64, int64, error) {
+	start, end, ok := strings.Cut(rng, "-")
+	if !ok {
+		return 0, 0, fmt.Errorf("invalid range %q", rng)
+	}
+	s, err1 := strconv.ParseInt(start, 10, 64)
+	e, err2 := strconv.ParseInt(end, 10, 64)
+	if err1 != nil || err2 != nil || s < 0 || e < s {
+		return 0, 0, fmt.Errorf("invalid range %q", rng)
+	}
+	return s, e, nil
+}
+
+/*
+place puts `src` (or its copy if `move` is false) into `dstParent` under `name` respecting `on_exist` value:
+"autoname" picks another name, "overwrite" replaces existing object of the same type, otherwise 409 is returned.
+*/
+func (s *Server) place(src, dstParent *node, name, onExist string, move bool) (*node, *httpError) {
+	if src.isAncestorOf(dstParent) {
+		return nil, badRequest("can not copy or move object into itself")
+	}
+
+	if existing, exists := dstParent.children[name]; exists {
+		if existing == src {
+			return src, nil
+		}
+		switch onExist {
+		case "autoname":
+			name = autoname(dstParent, name)
+		case "overwrite":
+			if existing.dir != src.dir || existing.isAncestorOf(src) {
+				return nil, conflict()
+			}
+			delete(dstParent.children, name)
+			s.forget(existing)
+		default:
+			return nil, conflict()
+		}
+	}
+
+	if !move {
+		if herr := s.checkQuota(dstParent, src.size()); herr != nil {
+			return nil, herr
+		}
+		return s.clone(src, dstParent, name), nil
+	}
+
+	delete(src.parent.children, src.name)
+	src.parent = dstParent
+	src.name = name
+	dstParent.children[name] = src
+	s.reassignIDs(src)
+	return src, nil
+}
+
+// clone creates a deep copy of the node inside the `parent` directory.
+func (s *Server) clone(src, parent *node, name string) *node {
+	n := s.newNode(parent, name, src.dir, src.mtime)
+	n.content = append([]byte(nil), src.content...)
+	for _, c := range src.children {
+		s.clone(c, n, c.name)
+	}
+	return n
+}
+
+// readBody reads upload request body respecting `MaxUploadSize` limit.
+func (s *Server) readBody(r *http.Request) ([]byte, *httpError) {
+	s.mu.Lock()
+	limit := s.MaxUploadSize
+	s.mu.Unlock()
+
+	rdr := io.Reader(r.Body)
+	if limit > 0 {
+		rdr = io.LimitReader(r.Body, limit+1)
+	}
+	body, err := io.ReadAll(rdr)
+	if err != nil {
+		return nil, badRequest(err.Error())
+	}
+	if limit > 0 && int64(len(body)) > limit {
+		return nil, &httpError{status: http.StatusRequestEntityTooLarge}
+	}
+	return body, nil
+}
@@ -0,0 +1,39 @@
+package hidrivetest
+
+import (
+	"net/http"
+)
+
+// handleMeta serves `/meta` endpoint.
+func (s *Server) handleMeta(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	q := r.URL.Query()
+	if q.Get("snapshot") != "" && r.Method != http.MethodGet {
+		writeError(w, badRequest("snapshots are read-only"))
+		return
+	}
+	n, herr := s.lookupAt(q.Get("snapshot"), q.Get("pid"), q.Get("path"))
+	if herr != nil {
+		writeError(w, herr)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		writeJSON(w, http.StatusOK, filterFields(s.object(n, nil, 0), fieldsParam(r)))
+	case http.MethodPatch:
+		mtime, herr := parseTime(q.Get("mtime"))
+		if herr != nil {
+			writeError(w, herr)
+			return
+		}
+		if !mtime.IsZero() {
+			n.mtime = mtime
+		}
+		writeJSON(w, http.StatusOK, s.object(n, nil, 0))
+	default:
+		methodNotAllowed(w)
+	}
+}
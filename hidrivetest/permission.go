@@ -0,0 +1,159 @@
+package hidrivetest
+
+import (
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+)
+
+// rights represents access rights of a user or group, stored per directory in `node.acl`.
+type rights struct {
+	readable bool
+	writable bool
+}
+
+// AddUser creates a regular (non-administrator) user of the account with its home directory "/users/<alias>".
+func (s *Server) AddUser(alias string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.users[alias]; ok {
+		return fmt.Errorf("user %q already exists", alias)
+	}
+	if _, ok := s.groups[alias]; ok {
+		return fmt.Errorf("group %q already exists", alias)
+	}
+	_, err := s.addUser(alias, false)
+	return err
+}
+
+// AddGroup creates a group of users, the group can be used as `account` of `/permission` endpoint.
+func (s *Server) AddGroup(name string, members ...string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.groups[name]; ok {
+		return fmt.Errorf("group %q already exists", name)
+	}
+	if _, ok := s.users[name]; ok {
+		return fmt.Errorf("user %q already exists", name)
+	}
+	for _, m := range members {
+		if _, ok := s.users[m]; !ok {
+			return fmt.Errorf("user %q does not exist", m)
+		}
+	}
+	s.groups[name] = slices.Clone(members)
+	return nil
+}
+
+/*
+rightsOf resolves the effective rights of the account to the node: rights set on the nearest directory win,
+a user gets the union of the rights of its groups unless rights are set for the user itself.
+Without any rights set administrators have full access, users have full access to their home directory only.
+*/
+func (s *Server) rightsOf(account string, n *node) rights {
+	for d := n; d != nil; d = d.parent {
+		if r, ok := d.acl[account]; ok {
+			return r
+		}
+		var (
+			union rights
+			found bool
+		)
+		for group, members := range s.groups {
+			if r, ok := d.acl[group]; ok && slices.Contains(members, account) {
+				union.readable = union.readable || r.readable
+				union.writable = union.writable || r.writable
+				found = true
+			}
+		}
+		if found {
+			return union
+		}
+	}
+
+	if u, ok := s.users[account]; ok && (u.admin || u.home.isAncestorOf(n)) {
+		return rights{readable: true, writable: true}
+	}
+	return rights{}
+}
+
+// accountParam returns the `account` parameter or the default user, 404 is returned for unknown accounts.
+func (s *Server) accountParam(r *http.Request) (string, *httpError) {
+	account := r.URL.Query().Get("account")
+	if account == "" {
+		return DefaultUser, nil
+	}
+	if _, ok := s.users[account]; ok {
+		return account, nil
+	}
+	if _, ok := s.groups[account]; ok {
+		return account, nil
+	}
+	return "", notFound()
+}
+
+// handlePermission serves `/permission` endpoint.
+func (s *Server) handlePermission(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodPut {
+		methodNotAllowed(w)
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	q := r.URL.Query()
+	n, herr := s.lookup(q.Get("pid"), q.Get("path"))
+	if herr != nil {
+		writeError(w, herr)
+		return
+	}
+	account, herr := s.accountParam(r)
+	if herr != nil {
+		writeError(w, herr)
+		return
+	}
+
+	if r.Method == http.MethodPut {
+		if herr := s.requireAdmin(); herr != nil {
+			writeError(w, herr)
+			return
+		}
+		if !n.dir {
+			writeError(w, badRequest("rights can only be set on directories"))
+			return
+		}
+		cur := s.rightsOf(account, n)
+		for name, dst := range map[string]*bool{"readable": &cur.readable, "writable": &cur.writable} {
+			if v := q.Get(name); v != "" {
+				b, err := strconv.ParseBool(v)
+				if err != nil {
+					writeError(w, badRequest("invalid "+name+" "+v))
+					return
+				}
+				*dst = b
+			}
+		}
+		if cur.writable && !cur.readable {
+			writeError(w, badRequest("write access requires read access"))
+			return
+		}
+		if n.acl == nil {
+			n.acl = map[string]rights{}
+		}
+		n.acl[account] = cur
+	}
+
+	rt := s.rightsOf(account, n)
+	obj := map[string]any{
+		"account":  account,
+		"id":       n.id,
+		"path":     encodePath(n.path()),
+		"readable": rt.readable,
+		"writable": rt.writable,
+	}
+	writeJSON(w, http.StatusOK, filterFields(obj, fieldsParam(r)))
+}
@@ -0,0 +1,479 @@
+/*
+Package hidrivetest provides an in-memory fake of the HiDrive API for offline testing.
+
+[Server] starts an [httptest.Server] implementing the most commonly used HiDrive endpoints (`/dir`, `/dir/copy`,
+`/dir/move`, `/dir/rename`, `/file`, `/file/copy`, `/file/hash`, `/file/move`, `/file/rename`, `/file/thumbnail`,
+`/meta`, `/permission`, `/share`, `/share/invite`, `/sharelink`, `/snapshot`, `/snapshot/rename`, `/user` and
+`/user/me`) against an in-memory directory tree. Responses mimic the real API: objects are encoded the same way, the
+same status codes are returned on errors, `on_exist` parameter is respected and new public ids (pid) are generated for
+every created object. Content and name hashes (`chash`, `nhash`, checksums of `/file/hash`) are computed with
+[hidrive.NewHash], [hidrive.LevelHashes] and [hidrive.NameHash], directory `chash` and the meta hashes (`mhash`,
+`mohash`) change with any change in the subtree like HiDrive ones, but their values differ from the real API. GIF,
+JPEG and PNG files are reported as images with their dimensions and can be scaled down with `/file/thumbnail`.
+Snapshots keep a frozen copy of the whole tree which can be read with `snapshot` parameter. Access rights set with
+`/permission` are only stored and reported, they are not enforced.
+
+Example:
+
+	srv := hidrivetest.NewServer()
+	defer srv.Close()
+
+	_ = srv.AddFile("/public/hello.txt", []byte("hello"), time.Now())
+
+	fileApi := hidrive.NewFile(srv.Client(), srv.Endpoint())
+	rdr, err := fileApi.Get(ctx, hidrive.NewParameters().SetPath("/public/hello.txt").Values)
+
+[TestWritableFS] provides a conformance test suite for [hidrive.WritableFS] implementations.
+*/
+package hidrivetest
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	hidrive "github.com/Burmuley/go-hidrive"
+)
+
+// APIPrefix is the path prefix of all endpoints served, it mimics the version part of the real HiDrive API URL.
+const APIPrefix = "/2.1"
+
+/*
+Server - in-memory fake HiDrive API server.
+
+The tree initially contains root directory "/", "/public" directory and "/users/test" - the home directory
+of [DefaultUser], the owner and administrator of the account all requests are authenticated as.
+Use [Server.AddDir] and [Server.AddFile] to populate the tree and [Server.ReadFile] to inspect it,
+[Server.AddSnapshot] takes a snapshot as HiDrive does on schedule,
+[Server.AddUser] and [Server.AddGroup] create further users and groups of the account.
+
+Property `MaxUploadSize` limits the size of a request body for file uploads (413 is returned if exceeded),
+`Quota` limits the total size of all files stored (507 is returned if exceeded) and `MaxListLimit` limits the number
+of members returned by a single `/dir` request, so pages may be shorter than requested; zero values mean no limit.
+Quotas of users set with `/user` endpoint are enforced the same way for their home directories.
+*/
+type Server struct {
+	*httptest.Server
+	MaxUploadSize int64
+	Quota         int64
+	MaxListLimit  int
+
+	mu         sync.Mutex
+	root       *node
+	nodes      map[string]*node
+	lastID     int64
+	lastSnapID int64
+	shares     map[string]*shareEntry
+	sharelinks map[string]*shareEntry
+	snapshots  []*snapshotEntry
+	users      map[string]*userEntry
+	groups     map[string][]string
+	faults     []*fault
+}
+
+// fault is an injected error returned for matching requests.
+type fault struct {
+	method   string
+	endpoint string
+	status   int
+	times    int
+}
+
+/*
+NewServer - create and start new instance of [Server].
+
+The caller should call Close when finished, to shut it down.
+*/
+func NewServer() *Server {
+	s := &Server{
+		nodes:      map[string]*node{},
+		shares:     map[string]*shareEntry{},
+		sharelinks: map[string]*shareEntry{},
+		users:      map[string]*userEntry{},
+		groups:     map[string][]string{},
+	}
+
+	now := time.Now()
+	s.root = s.newNode(nil, "", true, now)
+	s.newNode(s.root, "public", true, now)
+	me, _ := s.addUser(DefaultUser, true)
+	me.owner = true
+
+	mux := http.NewServeMux()
+	mux.HandleFunc(APIPrefix+"/dir", s.handleDir)
+	mux.HandleFunc(APIPrefix+"/dir/copy", s.handleDirCopy)
+	mux.HandleFunc(APIPrefix+"/dir/move", s.handleDirMove)
+	mux.HandleFunc(APIPrefix+"/dir/rename", s.handleDirRename)
+	mux.HandleFunc(APIPrefix+"/file", s.handleFile)
+	mux.HandleFunc(APIPrefix+"/file/copy", s.handleFileCopy)
+	mux.HandleFunc(APIPrefix+"/file/hash", s.handleFileHash)
+	mux.HandleFunc(APIPrefix+"/file/move", s.handleFileMove)
+	mux.HandleFunc(APIPrefix+"/file/rename", s.handleFileRename)
+	mux.HandleFunc(APIPrefix+"/file/thumbnail", s.handleFileThumbnail)
+	mux.HandleFunc(APIPrefix+"/meta", s.handleMeta)
+	mux.HandleFunc(APIPrefix+"/permission", s.handlePermission)
+	mux.HandleFunc(APIPrefix+"/share", s.handleShare)
+	mux.HandleFunc(APIPrefix+"/share/invite", s.handleShareInvite)
+	mux.HandleFunc(APIPrefix+"/sharelink", s.handleSharelink)
+	mux.HandleFunc(APIPrefix+"/snapshot", s.handleSnapshot)
+	mux.HandleFunc(APIPrefix+"/snapshot/rename", s.handleSnapshotRename)
+	mux.HandleFunc(APIPrefix+"/user", s.handleUser)
+	mux.HandleFunc(APIPrefix+"/user/me", s.handleUserMe)
+
+	s.Server = httptest.NewServer(s.withFaults(mux))
+	return s
+}
+
+/*
+NewClient - start new [Server] with the given files and return it together with [hidrive.Client] using it,
+the server is closed when the test finishes.
+
+Keys of `files` are absolute paths and values are file contents, keys ending with "/" create empty directories.
+All created objects, including missing parents, get the modification time `mtime`. Options `opts` are applied
+after the ones pointing the client to the server, e.g. [hidrive.WithHTTPClient] can wrap the transport of
+[Server.Client].
+*/
+func NewClient(t testing.TB, files map[string]string, mtime time.Time, opts ...hidrive.ClientOption) (*Server, *hidrive.Client) {
+	t.Helper()
+	s := NewServer()
+	t.Cleanup(s.Close)
+
+	for p, content := range files {
+		var err error
+		if strings.HasSuffix(p, "/") {
+			s.mu.Lock()
+			_, err = s.mkdirAll(strings.TrimSuffix(p, "/"), mtime)
+			s.mu.Unlock()
+		} else {
+			err = s.AddFile(p, []byte(content), mtime)
+		}
+		if err != nil {
+			t.Fatalf("hidrivetest: adding %s: %v", p, err)
+		}
+	}
+
+	opts = append([]hidrive.ClientOption{hidrive.WithHTTPClient(s.Client()), hidrive.WithEndpoint(s.Endpoint())}, opts...)
+	return s, hidrive.NewClient(opts...)
+}
+
+// Endpoint returns API endpoint URL to be used with go_hidrive constructors.
+func (s *Server) Endpoint() string {
+	return s.URL + APIPrefix
+}
+
+/*
+InjectError - make the server respond with `status` to the next `times` requests matching `method` and `endpoint`
+(e.g. "GET" and "file"). Empty `method` or `endpoint` matches any value.
+
+Useful to test retries and error handling.
+*/
+func (s *Server) InjectError(method, endpoint string, status, times int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.faults = append(s.faults, &fault{method: method, endpoint: endpoint, status: status, times: times})
+}
+
+// AddDir creates directory with the given absolute path and all missing parents.
+func (s *Server) AddDir(p string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, err := s.mkdirAll(p, time.Now())
+	return err
+}
+
+// AddFile creates or overwrites a file with the given absolute path and creates all missing parents.
+func (s *Server) AddFile(p string, content []byte, mtime time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	elems, err := splitPath(p, true)
+	if err != nil || len(elems) == 0 {
+		return fmt.Errorf("invalid file path %q", p)
+	}
+	parent, err := s.mkdirAll("/"+strings.Join(elems[:len(elems)-1], "/"), mtime)
+	if err != nil {
+		return err
+	}
+
+	name := elems[len(elems)-1]
+	n, ok := parent.children[name]
+	if ok && n.dir {
+		return fmt.Errorf("%q is a directory", p)
+	}
+	if !ok {
+		n = s.newNode(parent, name, false, mtime)
+	}
+	n.content = append([]byte(nil), content...)
+	n.mtime = mtime
+	return nil
+}
+
+// ReadFile returns contents of the file with the given absolute path.
+func (s *Server) ReadFile(p string) ([]byte, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	n, herr := s.lookup("", p)
+	if herr != nil {
+		return nil, herr
+	}
+	if n.dir {
+		return nil, fmt.Errorf("%q is a directory", p)
+	}
+	return append([]byte(nil), n.content...), nil
+}
+
+// ModTime returns modification time of the object with the given absolute path.
+func (s *Server) ModTime(p string) (time.Time, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	n, herr := s.lookup("", p)
+	if herr != nil {
+		return time.Time{}, herr
+	}
+	return n.mtime, nil
+}
+
+// Exists reports whether an object with the given absolute path exists.
+func (s *Server) Exists(p string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	_, herr := s.lookup("", p)
+	return herr == nil
+}
+
+// withFaults wraps the handler to return injected errors.
+func (s *Server) withFaults(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		endpoint := strings.TrimPrefix(r.URL.Path, APIPrefix+"/")
+
+		s.mu.Lock()
+		status := 0
+		for i, f := range s.faults {
+			if (f.method == "" || f.method == r.Method) && (f.endpoint == "" || f.endpoint == endpoint) {
+				status = f.status
+				if f.times--; f.times <= 0 {
+					s.faults = append(s.faults[:i], s.faults[i+1:]...)
+				}
+				break
+			}
+		}
+		s.mu.Unlock()
+
+		if status != 0 {
+			writeError(w, &httpError{status: status})
+			return
+		}
+		h.ServeHTTP(w, r)
+	})
+}
+
+// newNode creates a new node with freshly generated pid and attaches it to the parent.
+func (s *Server) newNode(parent *node, name string, dir bool, mtime time.Time) *node {
+	n := &node{
+		id:     s.newID(),
+		name:   name,
+		dir:    dir,
+		parent: parent,
+		mtime:  mtime,
+		ctime:  time.Now(),
+	}
+	if dir {
+		n.children = map[string]*node{}
+	}
+	if parent != nil {
+		parent.children[name] = n
+	}
+	s.nodes[n.id] = n
+	return n
+}
+
+// newID generates a new public id in HiDrive format, e.g. "b1489258310.123".
+func (s *Server) newID() string {
+	s.lastID++
+	return fmt.Sprintf("b1489258310.%d", s.lastID)
+}
+
+// reassignIDs gives the node and its subtree new public ids, as HiDrive ids are not persistent upon rename/move.
+func (s *Server) reassignIDs(n *node) {
+	delete(s.nodes, n.id)
+	n.id = s.newID()
+	s.nodes[n.id] = n
+	for _, c := range n.children {
+		s.reassignIDs(c)
+	}
+}
+
+// forget removes the node and its subtree from pid index.
+func (s *Server) forget(n *node) {
+	delete(s.nodes, n.id)
+	for _, c := range n.children {
+		s.forget(c)
+	}
+}
+
+// mkdirAll creates directory and all missing parents.
+func (s *Server) mkdirAll(p string, mtime time.Time) (*node, error) {
+	elems, err := splitPath(p, true)
+	if err != nil {
+		return nil, err
+	}
+
+	cur := s.root
+	for _, e := range elems {
+		next, ok := cur.children[e]
+		if !ok {
+			next = s.newNode(cur, e, true, mtime)
+		}
+		if !next.dir {
+			return nil, fmt.Errorf("%q is not a directory", next.path())
+		}
+		cur = next
+	}
+	return cur, nil
+}
+
+// lookup resolves object by pid and/or path as HiDrive does: if both are given, path is relative to pid.
+func (s *Server) lookup(pid, p string) (*node, *httpError) {
+	if pid == "" && p == "" {
+		return nil, badRequest("at least one of path and pid is required")
+	}
+
+	cur := s.root
+	if pid != "" {
+		var ok bool
+		if cur, ok = s.nodes[pid]; !ok {
+			return nil, notFound()
+		}
+	}
+
+	elems, err := splitPath(p, pid == "")
+	if err != nil {
+		return nil, badRequest(err.Error())
+	}
+	for _, e := range elems {
+		if !cur.dir {
+			return nil, notFound()
+		}
+		next, ok := cur.children[e]
+		if !ok {
+			return nil, notFound()
+		}
+		cur = next
+	}
+	return cur, nil
+}
+
+// lookupParent resolves parent directory of the object addressed by pid and/or path, returns it with the object name.
+func (s *Server) lookupParent(pid, p string) (*node, string, *httpError) {
+	elems, err := splitPath(p, pid == "")
+	if err != nil {
+		return nil, "", badRequest(err.Error())
+	}
+	if len(elems) == 0 {
+		return nil, "", badRequest("path must address an object inside a directory")
+	}
+
+	parentPath := strings.Join(elems[:len(elems)-1], "/")
+	if pid == "" {
+		parentPath = "/" + parentPath
+	}
+	var parent *node
+	if parentPath == "" {
+		var ok bool
+		if parent, ok = s.nodes[pid]; !ok {
+			return nil, "", notFound()
+		}
+	} else {
+		var herr *httpError
+		if parent, herr = s.lookup(pid, parentPath); herr != nil {
+			return nil, "", herr
+		}
+	}
+	if !parent.dir {
+		return nil, "", notFound()
+	}
+	return parent, elems[len(elems)-1], nil
+}
+
+// usedSpace returns total size of all files stored.
+func (s *Server) usedSpace() int64 {
+	return s.root.size()
+}
+
+// httpError is an error rendered as HiDrive JSON error response.
+type httpError struct {
+	status int
+	msg    string
+}
+
+func (e *httpError) Error() string {
+	if e.msg != "" {
+		return e.msg
+	}
+	return http.StatusText(e.status)
+}
+
+func badRequest(msg string) *httpError { return &httpError{status: http.StatusBadRequest, msg: msg} }
+func notFound() *httpError             { return &httpError{status: http.StatusNotFound} }
+func conflict() *httpError             { return &httpError{status: http.StatusConflict} }
+
+// writeError writes HiDrive JSON error response.
+func writeError(w http.ResponseWriter, e *httpError) {
+	writeJSON(w, e.status, map[string]any{
+		"code": e.status,
+		"msg":  e.Error(),
+	})
+}
+
+// writeJSON writes JSON-encoded value with the given status code.
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+// methodNotAllowed writes 405 response.
+func methodNotAllowed(w http.ResponseWriter) {
+	writeError(w, &httpError{status: http.StatusMethodNotAllowed})
+}
+
+// parseTime parses unix timestamp parameter, returns zero time if the value is empty.
+func parseTime(value string) (time.Time, *httpError) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	secs, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return time.Time{}, badRequest(fmt.Sprintf("invalid timestamp %q", value))
+	}
+	return time.Unix(secs, 0), nil
+}
+
+// setParentMTime applies the optional `parent_mtime` like parameter to the parent directory.
+func setParentMTime(parent *node, value string) *httpError {
+	t, herr := parseTime(value)
+	if herr != nil {
+		return herr
+	}
+	if !t.IsZero() {
+		parent.mtime = t
+	}
+	return nil
+}
+
+// fieldsParam splits `fields` parameter value.
+func fieldsParam(r *http.Request) []string {
+	if f := r.URL.Query().Get("fields"); f != "" {
+		return strings.Split(f, ",")
+	}
+	return nil
+}
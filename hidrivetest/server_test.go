@@ -0,0 +1,353 @@
+package hidrivetest_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"testing"
+	"time"
+
+	hidrive "github.com/Burmuley/go-hidrive"
+	"github.com/Burmuley/go-hidrive/hidrivetest"
+)
+
+type closingBuffer struct {
+	*bytes.Buffer
+}
+
+func (c *closingBuffer) Close() error {
+	return nil
+}
+
+func newBody(s string) io.ReadCloser {
+	return &closingBuffer{bytes.NewBufferString(s)}
+}
+
+func TestServer_Dir(t *testing.T) {
+	srv := hidrivetest.NewServer()
+	defer srv.Close()
+	dirApi := hidrive.NewDir(srv.Client(), srv.Endpoint())
+	ctx := context.Background()
+
+	tests := []struct {
+		name    string
+		call    func() error
+		wantErr error
+	}{
+		{
+			name: "create directory",
+			call: func() error {
+				_, err := dirApi.Create(ctx, hidrive.NewParameters().SetPath("/public/docs").Values)
+				return err
+			},
+		},
+		{
+			name: "create existing directory",
+			call: func() error {
+				_, err := dirApi.Create(ctx, hidrive.NewParameters().SetPath("/public/docs").Values)
+				return err
+			},
+			wantErr: hidrive.ErrConflict,
+		},
+		{
+			name: "create directory with missing parent",
+			call: func() error {
+				_, err := dirApi.Create(ctx, hidrive.NewParameters().SetPath("/public/a/b").Values)
+				return err
+			},
+			wantErr: hidrive.ErrNotFound,
+		},
+		{
+			name: "create path",
+			call: func() error {
+				_, err := dirApi.CreatePath(ctx, hidrive.NewParameters().SetPath("/public/a/b/c").Values)
+				return err
+			},
+		},
+		{
+			name: "get non-existent directory",
+			call: func() error {
+				_, err := dirApi.Get(ctx, hidrive.NewParameters().SetPath("/public/missing").Values)
+				return err
+			},
+			wantErr: hidrive.ErrNotFound,
+		},
+		{
+			name: "delete non-empty directory",
+			call: func() error {
+				return dirApi.Delete(ctx, hidrive.NewParameters().SetPath("/public/a").Values)
+			},
+			wantErr: hidrive.ErrConflict,
+		},
+		{
+			name: "delete empty directory",
+			call: func() error {
+				return dirApi.Delete(ctx, hidrive.NewParameters().SetPath("/public/a/b/c").Values)
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.call()
+			if (tt.wantErr == nil && err != nil) || (tt.wantErr != nil && !errors.Is(err, tt.wantErr)) {
+				t.Errorf("error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestServer_DirMembers(t *testing.T) {
+	srv := hidrivetest.NewServer()
+	defer srv.Close()
+	dirApi := hidrive.NewDir(srv.Client(), srv.Endpoint())
+	ctx := context.Background()
+
+	mtime := time.Unix(1600000000, 0)
+	for _, p := range []string{"/public/d/c.txt", "/public/d/a b.txt", "/public/d/b.txt"} {
+		if err := srv.AddFile(p, []byte(p), mtime); err != nil {
+			t.Fatalf("AddFile() error = %v", err)
+		}
+	}
+	if err := srv.AddDir("/public/d/sub"); err != nil {
+		t.Fatalf("AddDir() error = %v", err)
+	}
+
+	tests := []struct {
+		name      string
+		params    *hidrive.Parameters
+		wantNames []string
+		wantTotal int64
+	}{
+		{
+			name:      "all members",
+			params:    hidrive.NewParameters().SetPath("/public/d"),
+			wantNames: []string{"a b.txt", "b.txt", "c.txt", "sub"},
+			wantTotal: 4,
+		},
+		{
+			name:      "only files",
+			params:    hidrive.NewParameters().SetPath("/public/d").SetMembers([]string{"file"}),
+			wantNames: []string{"a b.txt", "b.txt", "c.txt"},
+			wantTotal: 3,
+		},
+		{
+			name:      "limit with offset",
+			params:    hidrive.NewParameters().SetPath("/public/d").SetLimit(2, 1),
+			wantNames: []string{"b.txt", "c.txt"},
+			wantTotal: 4,
+		},
+		{
+			name:      "sorted descending",
+			params:    hidrive.NewParameters().SetPath("/public/d").SetSortBy("-name"),
+			wantNames: []string{"sub", "c.txt", "b.txt", "a b.txt"},
+			wantTotal: 4,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			obj, err := dirApi.Get(ctx, tt.params.Values)
+			if err != nil {
+				t.Fatalf("Get() error = %v", err)
+			}
+			var names []string
+			for _, m := range obj.Members {
+				names = append(names, m.Name)
+			}
+			if len(names) != len(tt.wantNames) {
+				t.Fatalf("Get() members = %v, want %v", names, tt.wantNames)
+			}
+			for i := range names {
+				if names[i] != tt.wantNames[i] {
+					t.Errorf("Get() members = %v, want %v", names, tt.wantNames)
+					break
+				}
+			}
+			if obj.MemberCount != tt.wantTotal {
+				t.Errorf("Get() nmembers = %d, want %d", obj.MemberCount, tt.wantTotal)
+			}
+		})
+	}
+}
+
+func TestServer_File(t *testing.T) {
+	srv := hidrivetest.NewServer()
+	defer srv.Close()
+	fileApi := hidrive.NewFile(srv.Client(), srv.Endpoint())
+	ctx := context.Background()
+	mtime := time.Unix(1600000000, 0)
+
+	uploaded, err := fileApi.Upload(ctx, hidrive.NewParameters().SetFilePath("/public/f.txt").SetMTime(mtime).Values, newBody("hello"))
+	if err != nil {
+		t.Fatalf("Upload() error = %v", err)
+	}
+	if uploaded.Name != "f.txt" || uploaded.Size != 5 || time.Time(uploaded.MTime) != mtime || uploaded.ID == "" {
+		t.Errorf("Upload() = %+v", uploaded)
+	}
+
+	if _, err := fileApi.Upload(ctx, hidrive.NewParameters().SetFilePath("/public/f.txt").Values, newBody("again")); !errors.Is(err, hidrive.ErrConflict) {
+		t.Errorf("Upload() existing file error = %v, want %v", err, hidrive.ErrConflict)
+	}
+
+	renamed, err := fileApi.Upload(ctx, hidrive.NewParameters().SetFilePath("/public/f.txt").SetOnExist("autoname").Values, newBody("again"))
+	if err != nil || renamed.Name != "f (1).txt" {
+		t.Errorf("Upload() autoname = %v, %v", renamed, err)
+	}
+
+	if _, err := fileApi.Update(ctx, hidrive.NewParameters().SetFilePath("/public/f.txt").Values, newBody("updated")); err != nil {
+		t.Errorf("Update() error = %v", err)
+	}
+
+	rdr, err := fileApi.Get(ctx, hidrive.NewParameters().SetPid(uploaded.ID).Values)
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	if contents, _ := io.ReadAll(rdr); string(contents) != "updated" {
+		t.Errorf("Get() contents = %q, want %q", contents, "updated")
+	}
+	rdr.Close()
+
+	copied, err := fileApi.Copy(ctx, hidrive.NewParameters().SetSrc("/public/f.txt").SetDst("/public/copy.txt").SetPreserveMTime(true).Values)
+	if err != nil || copied.Size != 7 {
+		t.Errorf("Copy() = %v, %v", copied, err)
+	}
+
+	if _, err := fileApi.Move(ctx, hidrive.NewParameters().SetSrc("/public/copy.txt").SetDst("/public/f (1).txt").Values); !errors.Is(err, hidrive.ErrConflict) {
+		t.Errorf("Move() to existing file error = %v, want %v", err, hidrive.ErrConflict)
+	}
+
+	moved, err := fileApi.Move(ctx, hidrive.NewParameters().SetSrc("/public/copy.txt").SetDst("/public/f (1).txt").SetOnExist("overwrite").Values)
+	if err != nil || moved.Size != 7 || srv.Exists("/public/copy.txt") {
+		t.Errorf("Move() overwrite = %v, %v", moved, err)
+	}
+
+	if _, err := fileApi.Rename(ctx, hidrive.NewParameters().SetPath("/public/f (1).txt").SetName("g.txt").Values); err != nil {
+		t.Errorf("Rename() error = %v", err)
+	}
+	if contents, err := srv.ReadFile("/public/g.txt"); err != nil || string(contents) != "updated" {
+		t.Errorf("ReadFile() = %q, %v", contents, err)
+	}
+
+	if err := fileApi.Delete(ctx, hidrive.NewParameters().SetPath("/public/g.txt").Values); err != nil {
+		t.Errorf("Delete() error = %v", err)
+	}
+	if err := fileApi.Delete(ctx, hidrive.NewParameters().SetPath("/public/g.txt").Values); !errors.Is(err, hidrive.ErrNotFound) {
+		t.Errorf("Delete() non-existent file error = %v, want %v", err, hidrive.ErrNotFound)
+	}
+}
+
+func TestServer_Limits(t *testing.T) {
+	srv := hidrivetest.NewServer()
+	defer srv.Close()
+	fileApi := hidrive.NewFile(srv.Client(), srv.Endpoint())
+	ctx := context.Background()
+
+	srv.MaxUploadSize = 4
+	if _, err := fileApi.Upload(ctx, hidrive.NewParameters().SetFilePath("/public/big").Values, newBody("12345")); !errors.Is(err, hidrive.ErrTooLarge) {
+		t.Errorf("Upload() error = %v, want %v", err, hidrive.ErrTooLarge)
+	}
+
+	srv.MaxUploadSize = 0
+	srv.Quota = 4
+	if _, err := fileApi.Upload(ctx, hidrive.NewParameters().SetFilePath("/public/big").Values, newBody("12345")); !errors.Is(err, hidrive.ErrInsufficientStorage) {
+		t.Errorf("Upload() error = %v, want %v", err, hidrive.ErrInsufficientStorage)
+	}
+
+	srv.Quota = 0
+	srv.InjectError(http.MethodGet, "meta", http.StatusServiceUnavailable, 2)
+	metaApi := hidrive.NewMeta(srv.Client(), srv.Endpoint())
+	metaApi.RetryPolicy = hidrive.NewRetryPolicy()
+	metaApi.RetryPolicy.MinBackoff = time.Millisecond
+	if _, err := metaApi.Get(ctx, hidrive.NewParameters().SetPath("/public").Values); err != nil {
+		t.Errorf("Get() with retries error = %v", err)
+	}
+}
+
+func TestServer_Meta(t *testing.T) {
+	srv := hidrivetest.NewServer()
+	defer srv.Close()
+	metaApi := hidrive.NewMeta(srv.Client(), srv.Endpoint())
+	ctx := context.Background()
+
+	if err := srv.AddFile("/public/m.txt", []byte("meta"), time.Unix(1600000000, 0)); err != nil {
+		t.Fatalf("AddFile() error = %v", err)
+	}
+
+	obj, err := metaApi.Get(ctx, hidrive.NewParameters().SetPath("/public/m.txt").SetFields([]string{"size", "type"}).Values)
+	if err != nil || obj.Size != 4 || obj.Type != "file" || obj.Name != "" {
+		t.Errorf("Get() = %+v, %v", obj, err)
+	}
+
+	mtime := time.Unix(1700000000, 0)
+	if _, err := metaApi.Update(ctx, hidrive.NewParameters().SetPath("/public/m.txt").SetMTime(mtime).Values); err != nil {
+		t.Errorf("Update() error = %v", err)
+	}
+	if got, _ := srv.ModTime("/public/m.txt"); !got.Equal(mtime) {
+		t.Errorf("ModTime() = %v, want %v", got, mtime)
+	}
+}
+
+func TestServer_Share(t *testing.T) {
+	srv := hidrivetest.NewServer()
+	defer srv.Close()
+	shareApi := hidrive.NewShare(srv.Client(), srv.Endpoint())
+	sharelinkApi := hidrive.NewSharelink(srv.Client(), srv.Endpoint())
+	ctx := context.Background()
+
+	if err := srv.AddFile("/public/shared/file.txt", []byte("shared"), time.Now()); err != nil {
+		t.Fatalf("AddFile() error = %v", err)
+	}
+
+	share, err := shareApi.Create(ctx, hidrive.NewParameters().SetPath("/public/shared").SetPassword("secret").SetTTL(3600).Values)
+	if err != nil || share.ID == "" || !share.HasPassword || share.Status != "valid" {
+		t.Fatalf("Create() = %+v, %v", share, err)
+	}
+
+	if _, err := shareApi.Create(ctx, hidrive.NewParameters().SetPath("/public/missing").Values); !errors.Is(err, hidrive.ErrNotFound) {
+		t.Errorf("Create() error = %v, want %v", err, hidrive.ErrNotFound)
+	}
+
+	if got, err := shareApi.Get(ctx, hidrive.NewParameters().SetId(share.ID).Values); err != nil || got.Path != "/public/shared" {
+		t.Errorf("Get() = %+v, %v", got, err)
+	}
+
+	if got, err := shareApi.Update(ctx, hidrive.NewParameters().SetId(share.ID).SetMaxCount(10).Values); err != nil || got.MaxCount != 10 {
+		t.Errorf("Update() = %+v, %v", got, err)
+	}
+
+	invite, err := shareApi.Invite(ctx, hidrive.NewParameters().SetId(share.ID).SetRecipient("bob@example.com").Values)
+	if err != nil || len(invite.Done) != 1 {
+		t.Errorf("Invite() = %+v, %v", invite, err)
+	}
+
+	if err := shareApi.Delete(ctx, hidrive.NewParameters().SetId(share.ID).Values); err != nil {
+		t.Errorf("Delete() error = %v", err)
+	}
+
+	link, err := sharelinkApi.Create(ctx, hidrive.NewParameters().SetPath("/public/shared/file.txt").Values)
+	if err != nil || link.ShareType != "file" {
+		t.Fatalf("Sharelink Create() = %+v, %v", link, err)
+	}
+	if err := sharelinkApi.Delete(ctx, hidrive.NewParameters().SetId(link.ID).Values); err != nil {
+		t.Errorf("Sharelink Delete() error = %v", err)
+	}
+}
+
+func TestNewClient(t *testing.T) {
+	mtime := time.Unix(1600000000, 0)
+	srv, client := hidrivetest.NewClient(t, map[string]string{"/public/a/b.txt": "b", "/public/empty/": ""}, mtime,
+		hidrive.WithUserAgent("test"))
+
+	obj, err := client.Meta.Get(context.Background(), hidrive.NewParameters().SetPath("/public/a/b.txt").Values)
+	if err != nil || obj.Size != 1 || !time.Time(obj.MTime).Equal(mtime) {
+		t.Errorf("Meta.Get() = %+v, %v", obj, err)
+	}
+	dir, err := client.Meta.Get(context.Background(), hidrive.NewParameters().SetPath("/public/empty").Values)
+	if err != nil || dir.Type != "dir" {
+		t.Errorf("Meta.Get() = %+v, %v", dir, err)
+	}
+	if client.Meta.UserAgent != "test" || !srv.Exists("/public/a") {
+		t.Errorf("NewClient() options or files not applied")
+	}
+}
@@ -0,0 +1,301 @@
+package hidrivetest
+
+import (
+	"fmt"
+	"net/http"
+	"net/mail"
+	"strconv"
+	"time"
+)
+
+// defaultTTL is the share time-to-live used when `ttl` parameter is omitted (tariff maximum).
+const defaultTTL = 30 * 24 * 60 * 60
+
+// shareEntry represents a share (directory) or a sharelink (file).
+type shareEntry struct {
+	id           string
+	target       *node
+	shareType    string
+	password     string
+	encrypted    bool
+	writable     bool
+	created      time.Time
+	lastModified time.Time
+	validUntil   time.Time
+	maxCount     int
+	count        int
+}
+
+// handleShare serves `/share` endpoint.
+func (s *Server) handleShare(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	switch r.Method {
+	case http.MethodGet:
+		s.shareGet(w, r, s.shares)
+	case http.MethodPost:
+		s.shareCreate(w, r, s.shares, "sharedir")
+	case http.MethodPut:
+		s.shareUpdate(w, r, s.shares)
+	case http.MethodDelete:
+		s.shareDelete(w, r, s.shares)
+	default:
+		methodNotAllowed(w)
+	}
+}
+
+// handleSharelink serves `/sharelink` endpoint.
+func (s *Server) handleSharelink(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	switch r.Method {
+	case http.MethodGet:
+		s.shareGet(w, r, s.sharelinks)
+	case http.MethodPost:
+		if t := r.URL.Query().Get("type"); t != "file" {
+			writeError(w, badRequest("invalid type "+t))
+			return
+		}
+		s.shareCreate(w, r, s.sharelinks, "file")
+	case http.MethodPut:
+		s.shareUpdate(w, r, s.sharelinks)
+	case http.MethodDelete:
+		s.shareDelete(w, r, s.sharelinks)
+	default:
+		methodNotAllowed(w)
+	}
+}
+
+// handleShareInvite serves `/share/invite` endpoint.
+func (s *Server) handleShareInvite(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		methodNotAllowed(w)
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, herr := s.findShare(r, s.shares); herr != nil {
+		writeError(w, herr)
+		return
+	}
+
+	recipients := r.URL.Query()["recipient"]
+	if len(recipients) == 0 {
+		writeError(w, badRequest("recipient is required"))
+		return
+	}
+
+	done := []map[string]any{}
+	failed := []map[string]any{}
+	for _, rcpt := range recipients {
+		if _, err := mail.ParseAddress(rcpt); err != nil {
+			failed = append(failed, map[string]any{"to": rcpt, "code": http.StatusBadRequest, "msg": err.Error()})
+			continue
+		}
+		done = append(done, map[string]any{"to": rcpt, "code": http.StatusOK})
+	}
+
+	status := http.StatusOK
+	switch {
+	case len(done) == 0:
+		status = http.StatusBadRequest
+	case len(failed) > 0:
+		status = http.StatusMultiStatus
+	}
+	writeJSON(w, status, map[string]any{"done": done, "failed": failed})
+}
+
+func (s *Server) shareGet(w http.ResponseWriter, r *http.Request, registry map[string]*shareEntry) {
+	q := r.URL.Query()
+	if q.Get("id") == "" && q.Get("path") == "" && q.Get("pid") == "" {
+		list := make([]map[string]any, 0, len(registry))
+		for _, e := range registry {
+			list = append(list, filterFields(s.shareObject(e), fieldsParam(r)))
+		}
+		writeJSON(w, http.StatusOK, list)
+		return
+	}
+
+	e, herr := s.findShare(r, registry)
+	if herr != nil {
+		writeError(w, herr)
+		return
+	}
+	writeJSON(w, http.StatusOK, filterFields(s.shareObject(e), fieldsParam(r)))
+}
+
+func (s *Server) shareCreate(w http.ResponseWriter, r *http.Request, registry map[string]*shareEntry, shareType string) {
+	q := r.URL.Query()
+	n, herr := s.lookup(q.Get("pid"), q.Get("path"))
+	if herr != nil {
+		writeError(w, herr)
+		return
+	}
+	if n.dir != (shareType == "sharedir") {
+		writeError(w, badRequest("invalid share target type "+n.objectType()))
+		return
+	}
+
+	now := time.Now()
+	e := &shareEntry{
+		id:           s.newShareID(),
+		target:       n,
+		shareType:    shareType,
+		created:      now,
+		lastModified: now,
+		validUntil:   now.Add(defaultTTL * time.Second),
+		maxCount:     -1,
+	}
+	if herr := applyShareParams(e, r); herr != nil {
+		writeError(w, herr)
+		return
+	}
+	if q.Get("salt") != "" {
+		if q.Get("share_access_key") == "" || q.Get("pw_sharekey") == "" || q.Get("password") != "" {
+			writeError(w, badRequest("encrypted shares require salt, share_access_key and pw_sharekey"))
+			return
+		}
+		e.encrypted = true
+	}
+
+	registry[e.id] = e
+	writeJSON(w, http.StatusCreated, s.shareObject(e))
+}
+
+func (s *Server) shareUpdate(w http.ResponseWriter, r *http.Request, registry map[string]*shareEntry) {
+	e, ok := registry[r.URL.Query().Get("id")]
+	if !ok {
+		writeError(w, notFound())
+		return
+	}
+	if herr := applyShareParams(e, r); herr != nil {
+		writeError(w, herr)
+		return
+	}
+	e.lastModified = time.Now()
+	writeJSON(w, http.StatusOK, s.shareObject(e))
+}
+
+func (s *Server) shareDelete(w http.ResponseWriter, r *http.Request, registry map[string]*shareEntry) {
+	id := r.URL.Query().Get("id")
+	if _, ok := registry[id]; !ok {
+		writeError(w, notFound())
+		return
+	}
+	delete(registry, id)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// newShareID generates a new share id.
+func (s *Server) newShareID() string {
+	s.lastID++
+	return fmt.Sprintf("%010x", 0x5c0000000+s.lastID)
+}
+
+// findShare looks up the share by `id`, `path` or `pid` parameters.
+func (s *Server) findShare(r *http.Request, registry map[string]*shareEntry) (*shareEntry, *httpError) {
+	q := r.URL.Query()
+	if id := q.Get("id"); id != "" {
+		if e, ok := registry[id]; ok {
+			return e, nil
+		}
+		return nil, notFound()
+	}
+
+	n, herr := s.lookup(q.Get("pid"), q.Get("path"))
+	if herr != nil {
+		return nil, herr
+	}
+	for _, e := range registry {
+		if e.target == n {
+			return e, nil
+		}
+	}
+	return nil, notFound()
+}
+
+// applyShareParams updates share properties from the request parameters.
+func applyShareParams(e *shareEntry, r *http.Request) *httpError {
+	q := r.URL.Query()
+	if v := q.Get("ttl"); v != "" {
+		ttl, err := strconv.ParseUint(v, 10, 32)
+		if err != nil {
+			return badRequest("invalid ttl " + v)
+		}
+		e.validUntil = time.Now().Add(time.Duration(ttl) * time.Second)
+	}
+	if v := q.Get("maxcount"); v != "" {
+		maxCount, err := strconv.Atoi(v)
+		if err != nil || (maxCount >= 0 && maxCount < e.count) {
+			return badRequest("invalid maxcount " + v)
+		}
+		e.maxCount = maxCount
+	}
+	if v := q.Get("writable"); v != "" {
+		writable, err := strconv.ParseBool(v)
+		if err != nil {
+			return badRequest("invalid writable " + v)
+		}
+		e.writable = writable
+	}
+	if q.Has("password") {
+		e.password = q.Get("password")
+	}
+	return nil
+}
+
+// shareObject renders the share as HiDrive JSON share object.
+func (s *Server) shareObject(e *shareEntry) map[string]any {
+	ttl := int(time.Until(e.validUntil).Seconds())
+	status := "valid"
+	if ttl <= 0 {
+		status = "expired"
+	}
+	remaining := -1
+	if e.maxCount >= 0 {
+		remaining = e.maxCount - e.count
+	}
+
+	obj := map[string]any{
+		"id":            e.id,
+		"path":          encodePath(e.target.path()),
+		"pid":           e.target.id,
+		"name":          e.target.name,
+		"status":        status,
+		"file_type":     e.target.objectType(),
+		"share_type":    e.shareType,
+		"count":         e.count,
+		"maxcount":      e.maxCount,
+		"remaining":     remaining,
+		"created":       e.created.Unix(),
+		"last_modified": e.lastModified.Unix(),
+		"valid_until":   e.validUntil.Unix(),
+		"ttl":           ttl,
+		"has_password":  e.password != "",
+		"is_encrypted":  e.encrypted,
+		"readable":      true,
+		"writable":      e.writable,
+		"size":          e.target.size(),
+		"uri":           fmt.Sprintf("%s/share/%s", s.URL, e.id),
+		"viewmode":      "a",
+	}
+	if e.password != "" {
+		obj["password"] = e.password
+	}
+	return obj
+}
+
+// sharesOf returns share objects for all shares of the node.
+func (s *Server) sharesOf(n *node) []map[string]any {
+	var out []map[string]any
+	for _, e := range s.shares {
+		if e.target == n {
+			out = append(out, s.shareObject(e))
+		}
+	}
+	return out
+}
@@ -0,0 +1,209 @@
+package hidrivetest
+
+import (
+	"fmt"
+	"maps"
+	"net/http"
+	"sort"
+	"time"
+)
+
+// snapshotEntry represents a snapshot, a frozen copy of the whole tree.
+type snapshotEntry struct {
+	id      string
+	name    string
+	typ     string
+	created time.Time
+	root    *node
+}
+
+// AddSnapshot takes a snapshot of the current tree as the automatic snapshots of HiDrive do.
+func (s *Server) AddSnapshot(name string, created time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.snapshotByName(name); ok {
+		return fmt.Errorf("snapshot %q already exists", name)
+	}
+	s.takeSnapshot(name, "automatic", created)
+	return nil
+}
+
+// takeSnapshot stores a frozen copy of the tree, nodes keep their pids but are not reachable outside the snapshot.
+func (s *Server) takeSnapshot(name, typ string, created time.Time) *snapshotEntry {
+	s.lastSnapID++
+	snap := &snapshotEntry{
+		id:      fmt.Sprintf("snapshot.%d", s.lastSnapID),
+		name:    name,
+		typ:     typ,
+		created: created,
+		root:    freeze(s.root, nil),
+	}
+	s.snapshots = append(s.snapshots, snap)
+	return snap
+}
+
+// freeze creates a deep copy of the node keeping its pid.
+func freeze(n, parent *node) *node {
+	c := *n
+	c.parent = parent
+	c.content = append([]byte(nil), n.content...)
+	c.acl = maps.Clone(n.acl)
+	if n.dir {
+		c.children = map[string]*node{}
+		for name, child := range n.children {
+			c.children[name] = freeze(child, &c)
+		}
+	}
+	return &c
+}
+
+func (s *Server) snapshotByName(name string) (*snapshotEntry, bool) {
+	for _, snap := range s.snapshots {
+		if snap.name == name {
+			return snap, true
+		}
+	}
+	return nil, false
+}
+
+func (s *Server) snapshotIndex(id string) (int, *httpError) {
+	if id == "" {
+		return 0, badRequest("id is required")
+	}
+	for i, snap := range s.snapshots {
+		if snap.id == id {
+			return i, nil
+		}
+	}
+	return 0, notFound()
+}
+
+// lookupAt resolves object by pid and/or path in the snapshot with the given name or in the live tree
+// if `snapshot` is empty.
+func (s *Server) lookupAt(snapshot, pid, p string) (*node, *httpError) {
+	if snapshot == "" {
+		return s.lookup(pid, p)
+	}
+	snap, ok := s.snapshotByName(snapshot)
+	if !ok {
+		return nil, notFound()
+	}
+	if pid == "" && p == "" {
+		return nil, badRequest("at least one of path and pid is required")
+	}
+
+	cur := snap.root
+	if pid != "" {
+		if cur = findNode(snap.root, pid); cur == nil {
+			return nil, notFound()
+		}
+	}
+	elems, err := splitPath(p, pid == "")
+	if err != nil {
+		return nil, badRequest(err.Error())
+	}
+	for _, e := range elems {
+		next, ok := cur.children[e]
+		if !cur.dir || !ok {
+			return nil, notFound()
+		}
+		cur = next
+	}
+	return cur, nil
+}
+
+// findNode returns the node with the given pid from the subtree.
+func findNode(n *node, id string) *node {
+	if n.id == id {
+		return n
+	}
+	for _, c := range n.children {
+		if found := findNode(c, id); found != nil {
+			return found
+		}
+	}
+	return nil
+}
+
+// object renders the snapshot as HiDrive JSON object.
+func (snap *snapshotEntry) object() map[string]any {
+	return map[string]any{
+		"id":      snap.id,
+		"name":    snap.name,
+		"type":    snap.typ,
+		"created": snap.created.Unix(),
+		"size":    snap.root.size(),
+	}
+}
+
+// handleSnapshot serves `/snapshot` endpoint.
+func (s *Server) handleSnapshot(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	q := r.URL.Query()
+	switch r.Method {
+	case http.MethodGet:
+		snaps := append([]*snapshotEntry(nil), s.snapshots...)
+		sort.SliceStable(snaps, func(i, j int) bool {
+			return snaps[i].created.After(snaps[j].created)
+		})
+		list := make([]map[string]any, 0, len(snaps))
+		for _, snap := range snaps {
+			list = append(list, filterFields(snap.object(), fieldsParam(r)))
+		}
+		writeJSON(w, http.StatusOK, list)
+	case http.MethodPost:
+		name := q.Get("name")
+		if name == "" {
+			writeError(w, badRequest("name is required"))
+			return
+		}
+		if _, ok := s.snapshotByName(name); ok {
+			writeError(w, conflict())
+			return
+		}
+		writeJSON(w, http.StatusCreated, s.takeSnapshot(name, "manual", time.Now()).object())
+	case http.MethodDelete:
+		i, herr := s.snapshotIndex(q.Get("id"))
+		if herr != nil {
+			writeError(w, herr)
+			return
+		}
+		s.snapshots = append(s.snapshots[:i], s.snapshots[i+1:]...)
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		methodNotAllowed(w)
+	}
+}
+
+// handleSnapshotRename serves `/snapshot/rename` endpoint.
+func (s *Server) handleSnapshotRename(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		methodNotAllowed(w)
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	q := r.URL.Query()
+	i, herr := s.snapshotIndex(q.Get("id"))
+	if herr != nil {
+		writeError(w, herr)
+		return
+	}
+	name := q.Get("name")
+	if name == "" {
+		writeError(w, badRequest("name is required"))
+		return
+	}
+	if other, ok := s.snapshotByName(name); ok && other != s.snapshots[i] {
+		writeError(w, conflict())
+		return
+	}
+
+	s.snapshots[i].name = name
+	writeJSON(w, http.StatusOK, s.snapshots[i].object())
+}
@@ -0,0 +1,137 @@
+package hidrivetest
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	_ "image/gif" // registers GIF format for image.Decode
+	"image/jpeg"
+	"image/png"
+	"net/http"
+	"strconv"
+)
+
+// imageConfig returns dimensions of the file if it is a GIF, JPEG or PNG image.
+func (n *node) imageConfig() (image.Config, bool) {
+	if n.dir {
+		return image.Config{}, false
+	}
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(n.content))
+	return cfg, err == nil
+}
+
+// handleFileThumbnail serves `/file/thumbnail` endpoint, images are scaled with nearest neighbour sampling
+// and encoded in their original format (GIF thumbnails are encoded as PNG).
+func (s *Server) handleFileThumbnail(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		methodNotAllowed(w)
+		return
+	}
+
+	q := r.URL.Query()
+	s.mu.Lock()
+	n, herr := s.lookupAt(q.Get("snapshot"), q.Get("pid"), q.Get("path"))
+	if herr == nil && n.dir {
+		herr = badRequest("not a file")
+	}
+	if herr != nil {
+		s.mu.Unlock()
+		writeError(w, herr)
+		return
+	}
+	content := n.content
+	s.mu.Unlock()
+
+	width, herr := dimensionParam(q.Get("width"))
+	if herr != nil {
+		writeError(w, herr)
+		return
+	}
+	height, herr := dimensionParam(q.Get("height"))
+	if herr != nil {
+		writeError(w, herr)
+		return
+	}
+	mode := q.Get("mode")
+	if mode != "" && mode != "fit" && mode != "crop" {
+		writeError(w, badRequest(fmt.Sprintf("invalid mode %q", mode)))
+		return
+	}
+
+	img, format, err := image.Decode(bytes.NewReader(content))
+	if err != nil {
+		writeError(w, &httpError{status: http.StatusUnsupportedMediaType, msg: "not an image"})
+		return
+	}
+	thumb := scaleImage(img, width, height, mode == "crop")
+
+	var buf bytes.Buffer
+	contentType := "image/png"
+	if format == "jpeg" {
+		contentType = "image/jpeg"
+		err = jpeg.Encode(&buf, thumb, nil)
+	} else {
+		err = png.Encode(&buf, thumb)
+	}
+	if err != nil {
+		writeError(w, &httpError{status: http.StatusInternalServerError, msg: err.Error()})
+		return
+	}
+
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Length", strconv.Itoa(buf.Len()))
+	w.WriteHeader(http.StatusOK)
+	w.Write(buf.Bytes())
+}
+
+// dimensionParam parses width or height parameter, 0 means not given.
+func dimensionParam(value string) (int, *httpError) {
+	if value == "" {
+		return 0, nil
+	}
+	v, err := strconv.Atoi(value)
+	if err != nil || v <= 0 {
+		return 0, badRequest(fmt.Sprintf("invalid dimension %q", value))
+	}
+	return v, nil
+}
+
+// scaleImage scales the image down to fit into (or with `crop` to cover and be cropped to) width x height,
+// zero dimensions are derived from the aspect ratio. Images are never scaled up.
+func scaleImage(img image.Image, width, height int, crop bool) image.Image {
+	b := img.Bounds()
+	srcW, srcH := b.Dx(), b.Dy()
+	if width == 0 && height == 0 || srcW == 0 || srcH == 0 {
+		return img
+	}
+	if width == 0 {
+		width = srcW
+	}
+	if height == 0 {
+		height = srcH
+	}
+
+	scale := min(float64(width)/float64(srcW), float64(height)/float64(srcH))
+	if crop {
+		scale = max(float64(width)/float64(srcW), float64(height)/float64(srcH))
+	}
+	scale = min(scale, 1)
+	dstW, dstH := max(int(float64(srcW)*scale), 1), max(int(float64(srcH)*scale), 1)
+
+	// the crop window is centered in the scaled image
+	offX, offY := 0, 0
+	if crop {
+		offX, offY = max(dstW-width, 0)/2, max(dstH-height, 0)/2
+		dstW, dstH = min(dstW, width), min(dstH, height)
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, dstW, dstH))
+	for y := 0; y < dstH; y++ {
+		for x := 0; x < dstW; x++ {
+			sx := b.Min.X + int(float64(x+offX)/scale)
+			sy := b.Min.Y + int(float64(y+offY)/scale)
+			dst.Set(x, y, img.At(min(sx, b.Max.X-1), min(sy, b.Max.Y-1)))
+		}
+	}
+	return dst
+}
@@ -0,0 +1,283 @@
+package hidrivetest
+
+import (
+	"crypto/sha1"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"mime"
+	"net/url"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	hidrive "github.com/Burmuley/go-hidrive"
+)
+
+// node represents a single filesystem object (directory or file) of the in-memory tree.
+type node struct {
+	id       string
+	name     string
+	dir      bool
+	parent   *node
+	children map[string]*node
+	content  []byte
+	mtime    time.Time
+	ctime    time.Time
+	acl      map[string]rights
+}
+
+// path returns absolute path of the node.
+func (n *node) path() string {
+	if n.parent == nil {
+		return "/"
+	}
+	return path.Join(n.parent.path(), n.name)
+}
+
+// size returns size of a file or recursive size of a directory.
+func (n *node) size() int64 {
+	if !n.dir {
+		return int64(len(n.content))
+	}
+	var total int64
+	for _, c := range n.children {
+		total += c.size()
+	}
+	return total
+}
+
+// sortedChildren returns directory members sorted by name.
+func (n *node) sortedChildren() []*node {
+	out := make([]*node, 0, len(n.children))
+	for _, c := range n.children {
+		out = append(out, c)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		return out[i].name < out[j].name
+	})
+	return out
+}
+
+// isAncestorOf reports whether the node is the same as or a parent of `other`.
+func (n *node) isAncestorOf(other *node) bool {
+	for o := other; o != nil; o = o.parent {
+		if o == n {
+			return true
+		}
+	}
+	return false
+}
+
+// objectType returns HiDrive object type string.
+func (n *node) objectType() string {
+	if n.dir {
+		return "dir"
+	}
+	return "file"
+}
+
+// mimeType guesses MIME type of the file from its extension.
+func (n *node) mimeType() string {
+	if t := mime.TypeByExtension(path.Ext(n.name)); t != "" {
+		return t
+	}
+	return "application/octet-stream"
+}
+
+// encodePath returns URL-encoded path the same way HiDrive returns it.
+func encodePath(p string) string {
+	elems := strings.Split(p, "/")
+	for i, e := range elems {
+		elems[i] = url.PathEscape(e)
+	}
+	return strings.Join(elems, "/")
+}
+
+// splitPath validates the path and splits it into elements, absolute paths are only allowed if `abs` is true.
+func splitPath(p string, abs bool) ([]string, error) {
+	if p == "" {
+		return nil, nil
+	}
+	if strings.HasPrefix(p, "/") != abs {
+		return nil, fmt.Errorf("invalid path %q", p)
+	}
+	if p == "/" {
+		return nil, nil
+	}
+	if strings.HasSuffix(p, "/") {
+		return nil, fmt.Errorf("invalid path %q", p)
+	}
+
+	elems := strings.Split(strings.TrimPrefix(p, "/"), "/")
+	for _, e := range elems {
+		if e == "" || e == "." || e == ".." {
+			return nil, fmt.Errorf("invalid path %q", p)
+		}
+	}
+	return elems, nil
+}
+
+// autoname returns a name not yet used in the directory, following HiDrive "name (N).ext" pattern.
+func autoname(dir *node, name string) string {
+	ext := path.Ext(name)
+	base := strings.TrimSuffix(name, ext)
+	for i := 1; ; i++ {
+		candidate := fmt.Sprintf("%s (%d)%s", base, i, ext)
+		if _, ok := dir.children[candidate]; !ok {
+			return candidate
+		}
+	}
+}
+
+// object renders the node as HiDrive JSON object, `members` is nil when members should not be included.
+func (s *Server) object(n *node, members []*node, total int) map[string]any {
+	obj := map[string]any{
+		"id":         n.id,
+		"name":       url.PathEscape(n.name),
+		"path":       encodePath(n.path()),
+		"type":       n.objectType(),
+		"mtime":      n.mtime.Unix(),
+		"ctime":      n.ctime.Unix(),
+		"size":       n.size(),
+		"readable":   true,
+		"writable":   true,
+		"shareable":  true,
+		"teamfolder": false,
+		"nhash":      hidrive.NameHash(n.name),
+	}
+	chash, mohash, mhash := n.hashes()
+	obj["chash"] = hex.EncodeToString(chash)
+	obj["mohash"] = hex.EncodeToString(mohash)
+	obj["mhash"] = hex.EncodeToString(mhash)
+	if shares := s.sharesOf(n); len(shares) > 0 {
+		obj["rshare"] = shares
+	}
+	if n.parent != nil {
+		obj["parent_id"] = n.parent.id
+	}
+
+	if n.dir {
+		obj["nmembers"] = len(n.children)
+		hasDirs := false
+		for _, c := range n.children {
+			hasDirs = hasDirs || c.dir
+		}
+		obj["has_dirs"] = hasDirs
+	} else {
+		obj["mime_type"] = n.mimeType()
+		if cfg, ok := n.imageConfig(); ok {
+			obj["category"] = "image"
+			obj["image"] = map[string]any{"width": cfg.Width, "height": cfg.Height, "exif": map[string]any{}}
+		}
+	}
+
+	if members != nil {
+		list := make([]map[string]any, 0, len(members))
+		for _, m := range members {
+			list = append(list, s.object(m, nil, 0))
+		}
+		obj["members"] = list
+		obj["nmembers"] = total
+	}
+
+	return obj
+}
+
+// contentHash returns HiDrive content hash of the file.
+func (n *node) contentHash() string {
+	return hex.EncodeToString(n.contentSum())
+}
+
+func (n *node) contentSum() []byte {
+	h := hidrive.NewHash()
+	h.Write(n.content)
+	return h.Sum(nil)
+}
+
+/*
+hashes returns the content hash (`chash`), the meta only hash (`mohash`) and the meta hash (`mhash`) of the node.
+
+The hashes have the same structure as HiDrive ones, the values are not byte-compatible with the real API:
+`mohash` covers the name, size and mtime, `mhash` covers `mohash` and `chash`. The `chash` of a directory is the sum
+of `mhash` of its members, so any change within the subtree changes the hashes of all directories above it.
+*/
+func (n *node) hashes() (chash, mohash, mhash []byte) {
+	nhash, _ := hex.DecodeString(hidrive.NameHash(n.name))
+	meta := sha1.New()
+	meta.Write(nhash)
+	binary.Write(meta, binary.BigEndian, n.size())
+	binary.Write(meta, binary.BigEndian, n.mtime.Unix())
+
+	if n.dir {
+		var sum, moSum [sha1.Size]byte
+		for _, c := range n.children {
+			_, cmo, cm := c.hashes()
+			add160(&sum, cm)
+			add160(&moSum, cmo)
+		}
+		chash = sum[:]
+		meta.Write(moSum[:])
+	} else {
+		chash = n.contentSum()
+	}
+	mohash = meta.Sum(nil)
+
+	h := sha1.New()
+	h.Write(mohash)
+	h.Write(chash)
+	return chash, mohash, h.Sum(nil)
+}
+
+// add160 adds b to a as 160-bit big-endian unsigned integers, ignoring the overflow.
+func add160(a *[sha1.Size]byte, b []byte) {
+	carry := 0
+	for i := sha1.Size - 1; i >= 0; i-- {
+		v := int(a[i]) + int(b[i]) + carry
+		a[i] = byte(v)
+		carry = v >> 8
+	}
+}
+
+// filterFields removes all values not listed in `fields` from the object, empty list keeps everything.
+func filterFields(obj map[string]any, fields []string) map[string]any {
+	if len(fields) == 0 {
+		return obj
+	}
+
+	top := map[string]bool{}
+	sub := map[string]bool{}
+	for _, f := range fields {
+		if strings.HasPrefix(f, "members.") {
+			top["members"] = true
+			sub[strings.TrimPrefix(f, "members.")] = true
+			continue
+		}
+		top[strings.SplitN(f, ".", 2)[0]] = true
+	}
+
+	out := map[string]any{}
+	for k, v := range obj {
+		if !top[k] {
+			continue
+		}
+		if members, ok := v.([]map[string]any); ok && k == "members" && len(sub) > 0 {
+			filtered := make([]map[string]any, 0, len(members))
+			for _, m := range members {
+				filtered = append(filtered, filterFields(m, keys(sub)))
+			}
+			v = filtered
+		}
+		out[k] = v
+	}
+	return out
+}
+
+func keys(m map[string]bool) []string {
+	out := make([]string, 0, len(m))
+	for k := range m {
+		out = append(out, k)
+	}
+	return out
+}
@@ -0,0 +1,246 @@
+package hidrivetest
+
+import (
+	"net/http"
+	"net/url"
+	"path"
+	"slices"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// DefaultUser is the name (alias) of the user the requests are authenticated as, its home directory is
+// "/users/<DefaultUser>".
+const DefaultUser = "test"
+
+// userEntry represents a HiDrive user of the account.
+type userEntry struct {
+	alias      string
+	descriptor string
+	email      string
+	language   string
+	password   string
+	admin      bool
+	owner      bool
+	quota      int64
+	home       *node
+}
+
+// addUser creates a user with its home directory.
+func (s *Server) addUser(alias string, admin bool) (*userEntry, error) {
+	home, err := s.mkdirAll(path.Join("/users", alias), s.root.mtime)
+	if err != nil {
+		return nil, err
+	}
+	u := &userEntry{
+		alias:      alias,
+		descriptor: alias,
+		email:      alias + "@example.com",
+		language:   "en",
+		admin:      admin,
+		home:       home,
+	}
+	s.users[alias] = u
+	return u, nil
+}
+
+// userObject renders the user as HiDrive JSON object.
+func (s *Server) userObject(u *userEntry) map[string]any {
+	return map[string]any{
+		"account":        "hidrivetest",
+		"alias":          u.alias,
+		"descriptor":     u.descriptor,
+		"email":          u.email,
+		"email_pending":  "",
+		"email_verified": true,
+		"encrypted":      false,
+		"home":           u.home.path(),
+		"home_id":        u.home.id,
+		"is_admin":       u.admin,
+		"is_owner":       u.owner,
+		"language":       u.language,
+		"protocols":      map[string]bool{"webdav": true, "rsync": false, "ftp": false, "scp": false, "git": false, "cifs": false},
+		"quota":          s.quotaOf(u),
+	}
+}
+
+// quotaOf returns the quota of the user if set, the quota of the account otherwise.
+func (s *Server) quotaOf(u *userEntry) map[string]int64 {
+	if u.quota > 0 {
+		return map[string]int64{"total": u.quota, "used": u.home.size()}
+	}
+	return map[string]int64{"total": s.Quota, "used": s.usedSpace()}
+}
+
+/*
+checkQuota returns 507 if `grow` bytes more stored in the directory would exceed the quota of the account
+or the quota of a user whose home directory contains it.
+*/
+func (s *Server) checkQuota(dir *node, grow int64) *httpError {
+	if s.Quota > 0 && s.usedSpace()+grow > s.Quota {
+		return &httpError{status: http.StatusInsufficientStorage}
+	}
+	for _, u := range s.users {
+		if u.quota > 0 && u.home.isAncestorOf(dir) && u.home.size()+grow > u.quota {
+			return &httpError{status: http.StatusInsufficientStorage}
+		}
+	}
+	return nil
+}
+
+// handleUserMe serves `/user/me` endpoint.
+func (s *Server) handleUserMe(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		methodNotAllowed(w)
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	writeJSON(w, http.StatusOK, filterFields(s.userObject(s.users[DefaultUser]), fieldsParam(r)))
+}
+
+// requireAdmin returns 403 unless [DefaultUser] has administrative rights.
+func (s *Server) requireAdmin() *httpError {
+	if !s.users[DefaultUser].admin {
+		return &httpError{status: http.StatusForbidden, msg: "administrative rights required"}
+	}
+	return nil
+}
+
+// handleUser serves `/user` endpoint, only administrators are allowed to manage users.
+func (s *Server) handleUser(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if herr := s.requireAdmin(); herr != nil {
+		writeError(w, herr)
+		return
+	}
+
+	// the password is sent in a form-encoded body
+	if err := r.ParseForm(); err != nil {
+		writeError(w, badRequest(err.Error()))
+		return
+	}
+	q := r.Form
+	alias := q.Get("alias")
+	switch r.Method {
+	case http.MethodGet:
+		aliases := make([]string, 0, len(s.users))
+		for a := range s.users {
+			if alias == "" || a == alias {
+				aliases = append(aliases, a)
+			}
+		}
+		if len(aliases) == 0 {
+			writeError(w, notFound())
+			return
+		}
+		sort.Strings(aliases)
+		out := make([]map[string]any, 0, len(aliases))
+		for _, a := range aliases {
+			out = append(out, filterFields(s.userObject(s.users[a]), fieldsParam(r)))
+		}
+		writeJSON(w, http.StatusOK, out)
+	case http.MethodPost:
+		if alias == "" || q.Get("password") == "" {
+			writeError(w, badRequest("alias and password are required"))
+			return
+		}
+		if _, ok := s.users[alias]; ok {
+			writeError(w, conflict())
+			return
+		}
+		if _, ok := s.groups[alias]; ok {
+			writeError(w, conflict())
+			return
+		}
+		if !q.Has("home") {
+			q.Set("home", path.Join("/users", alias))
+		}
+		u := &userEntry{alias: alias, descriptor: alias, language: "en"}
+		if herr := s.updateUser(u, q); herr != nil {
+			writeError(w, herr)
+			return
+		}
+		s.users[alias] = u
+		writeJSON(w, http.StatusCreated, s.userObject(u))
+	case http.MethodPut:
+		u, ok := s.users[alias]
+		if !ok {
+			writeError(w, notFound())
+			return
+		}
+		updated := *u
+		if herr := s.updateUser(&updated, q); herr != nil {
+			writeError(w, herr)
+			return
+		}
+		*u = updated
+		writeJSON(w, http.StatusOK, s.userObject(u))
+	case http.MethodDelete:
+		u, ok := s.users[alias]
+		if !ok {
+			writeError(w, notFound())
+			return
+		}
+		if u.owner {
+			writeError(w, &httpError{status: http.StatusForbidden, msg: "the owner cannot be deleted"})
+			return
+		}
+		delete(s.users, alias)
+		for name, members := range s.groups {
+			s.groups[name] = slices.DeleteFunc(members, func(m string) bool { return m == alias })
+		}
+		for _, n := range s.nodes {
+			delete(n.acl, alias)
+		}
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		methodNotAllowed(w)
+	}
+}
+
+// updateUser applies the user properties given in the query to the user.
+func (s *Server) updateUser(u *userEntry, q url.Values) *httpError {
+	get := func(name string) (string, bool) {
+		return q.Get(name), q.Has(name)
+	}
+
+	if v, ok := get("password"); ok {
+		if len(v) < 8 {
+			return badRequest("password must be at least 8 characters long")
+		}
+		u.password = v
+	}
+	if v, ok := get("descriptor"); ok {
+		u.descriptor = v
+	}
+	if v, ok := get("email"); ok {
+		u.email = v
+	}
+	if v, ok := get("is_admin"); ok {
+		admin, err := strconv.ParseBool(v)
+		if err != nil {
+			return badRequest("invalid is_admin " + v)
+		}
+		u.admin = admin
+	}
+	if v, ok := get("quota"); ok {
+		quota, err := strconv.ParseInt(v, 10, 64)
+		if err != nil || quota < 0 {
+			return badRequest("invalid quota " + v)
+		}
+		u.quota = quota
+	}
+	if v, ok := get("home"); ok {
+		home, err := s.mkdirAll(v, time.Now())
+		if err != nil {
+			return badRequest(err.Error())
+		}
+		u.home = home
+	}
+	return nil
+}
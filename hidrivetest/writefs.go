@@ -0,0 +1,292 @@
+package hidrivetest
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"reflect"
+	"testing"
+	"time"
+
+	hidrive "github.com/Burmuley/go-hidrive"
+)
+
+/*
+TestWritableFS runs the conformance test suite for [hidrive.WritableFS] implementations, it checks that
+the implementation behaves the same way as the local filesystem accessed with the os package.
+
+Every subtest calls `newFS` to get an empty filesystem to operate on:
+
+	func TestMyFS(t *testing.T) {
+		hidrivetest.TestWritableFS(t, func(t *testing.T) hidrive.WritableFS {
+			return newMyFS(t.TempDir())
+		})
+	}
+*/
+func TestWritableFS(t *testing.T, newFS func(t *testing.T) hidrive.WritableFS) {
+	tests := []struct {
+		name string
+		test func(t *testing.T, fsys hidrive.WritableFS)
+	}{
+		{"OpenFile", testOpenFile},
+		{"Mkdir", testMkdir},
+		{"MkdirAll", testMkdirAll},
+		{"Rename", testRename},
+		{"RenameDir", testRenameDir},
+		{"Remove", testRemove},
+		{"RemoveAll", testRemoveAll},
+		{"Chtimes", testChtimes},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.test(t, newFS(t))
+		})
+	}
+}
+
+func writeFile(t *testing.T, fsys hidrive.WritableFS, name string, flag int, data string) {
+	t.Helper()
+	f, err := fsys.OpenFile(name, flag, 0o644)
+	if err != nil {
+		t.Fatalf("OpenFile(%q) error = %v", name, err)
+	}
+	if _, err := f.Write([]byte(data)); err != nil {
+		f.Close()
+		t.Fatalf("Write(%q) error = %v", name, err)
+	}
+	if err := f.Close(); err != nil {
+		t.Fatalf("Close(%q) error = %v", name, err)
+	}
+}
+
+func checkFile(t *testing.T, fsys hidrive.WritableFS, name, want string) {
+	t.Helper()
+	f, err := fsys.Open(name)
+	if err != nil {
+		t.Fatalf("Open(%q) error = %v", name, err)
+	}
+	defer f.Close()
+
+	got, err := io.ReadAll(f)
+	if err != nil || !bytes.Equal(got, []byte(want)) {
+		t.Errorf("content of %q = %q, %v, want %q", name, got, err, want)
+	}
+	if info, err := fsys.Stat(name); err != nil || info.Size() != int64(len(want)) || info.IsDir() {
+		t.Errorf("Stat(%q) = %v, %v, want file of size %d", name, info, err, len(want))
+	}
+}
+
+func checkNames(t *testing.T, fsys hidrive.WritableFS, dir string, want ...string) {
+	t.Helper()
+	entries, err := fs.ReadDir(fsys, dir)
+	if err != nil {
+		t.Fatalf("ReadDir(%q) error = %v", dir, err)
+	}
+	got := []string{}
+	for _, e := range entries {
+		got = append(got, e.Name())
+	}
+	if want == nil {
+		want = []string{}
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ReadDir(%q) = %v, want %v", dir, got, want)
+	}
+}
+
+func checkErr(t *testing.T, op string, err, want error) {
+	t.Helper()
+	if !errors.Is(err, want) {
+		t.Errorf("%s error = %v, want %v", op, err, want)
+	}
+}
+
+func testOpenFile(t *testing.T, fsys hidrive.WritableFS) {
+	const create = os.O_WRONLY | os.O_CREATE | os.O_TRUNC
+
+	writeFile(t, fsys, "a.txt", create, "hello world")
+	checkFile(t, fsys, "a.txt", "hello world")
+
+	writeFile(t, fsys, "a.txt", os.O_WRONLY, "HELLO")
+	checkFile(t, fsys, "a.txt", "HELLO world")
+
+	writeFile(t, fsys, "a.txt", os.O_WRONLY|os.O_APPEND, "!")
+	checkFile(t, fsys, "a.txt", "HELLO world!")
+
+	writeFile(t, fsys, "a.txt", create, "bye")
+	checkFile(t, fsys, "a.txt", "bye")
+
+	writeFile(t, fsys, "empty.txt", create, "")
+	checkFile(t, fsys, "empty.txt", "")
+	checkNames(t, fsys, ".", "a.txt", "empty.txt")
+
+	_, err := fsys.OpenFile("a.txt", create|os.O_EXCL, 0o644)
+	checkErr(t, "OpenFile(O_EXCL) of existing file", err, fs.ErrExist)
+	_, err = fsys.OpenFile("missing.txt", os.O_WRONLY, 0o644)
+	checkErr(t, "OpenFile() of missing file", err, fs.ErrNotExist)
+	_, err = fsys.OpenFile("missing/a.txt", create, 0o644)
+	checkErr(t, "OpenFile() in missing directory", err, fs.ErrNotExist)
+
+	f, err := fsys.OpenFile("a.txt", os.O_RDONLY, 0)
+	if err != nil {
+		t.Fatalf("OpenFile(O_RDONLY) error = %v", err)
+	}
+	if _, err := f.Write([]byte("x")); err == nil {
+		t.Errorf("Write() to file opened for reading error = nil")
+	}
+	f.Close()
+	checkFile(t, fsys, "a.txt", "bye")
+}
+
+func testMkdir(t *testing.T, fsys hidrive.WritableFS) {
+	if err := fsys.Mkdir("dir", 0o755); err != nil {
+		t.Fatalf("Mkdir() error = %v", err)
+	}
+	if info, err := fsys.Stat("dir"); err != nil || !info.IsDir() {
+		t.Errorf("Stat() = %v, %v, want directory", info, err)
+	}
+	checkErr(t, "Mkdir() of existing directory", fsys.Mkdir("dir", 0o755), fs.ErrExist)
+	checkErr(t, "Mkdir() in missing directory", fsys.Mkdir("missing/dir", 0o755), fs.ErrNotExist)
+	checkNames(t, fsys, "dir")
+}
+
+func testMkdirAll(t *testing.T, fsys hidrive.WritableFS) {
+	if err := fsys.MkdirAll("a/b/c", 0o755); err != nil {
+		t.Fatalf("MkdirAll() error = %v", err)
+	}
+	if err := fsys.MkdirAll("a/b/c", 0o755); err != nil {
+		t.Errorf("MkdirAll() of existing directory error = %v", err)
+	}
+	if err := fsys.MkdirAll("a/d", 0o755); err != nil {
+		t.Errorf("MkdirAll() error = %v", err)
+	}
+	checkNames(t, fsys, "a", "b", "d")
+	checkNames(t, fsys, "a/b", "c")
+
+	writeFile(t, fsys, "a/file", os.O_WRONLY|os.O_CREATE, "x")
+	if err := fsys.MkdirAll("a/file", 0o755); err == nil {
+		t.Errorf("MkdirAll() over a file error = nil")
+	}
+}
+
+func testRename(t *testing.T, fsys hidrive.WritableFS) {
+	const create = os.O_WRONLY | os.O_CREATE | os.O_TRUNC
+	if err := fsys.Mkdir("dir", 0o755); err != nil {
+		t.Fatalf("Mkdir() error = %v", err)
+	}
+	writeFile(t, fsys, "a.txt", create, "a")
+	writeFile(t, fsys, "b.txt", create, "b")
+
+	if err := fsys.Rename("a.txt", "c.txt"); err != nil {
+		t.Fatalf("Rename() error = %v", err)
+	}
+	checkFile(t, fsys, "c.txt", "a")
+
+	if err := fsys.Rename("c.txt", "dir/d.txt"); err != nil {
+		t.Fatalf("Rename() to another directory error = %v", err)
+	}
+	checkFile(t, fsys, "dir/d.txt", "a")
+
+	if err := fsys.Rename("b.txt", "dir/d.txt"); err != nil {
+		t.Fatalf("Rename() over existing file error = %v", err)
+	}
+	checkFile(t, fsys, "dir/d.txt", "b")
+	checkNames(t, fsys, ".", "dir")
+	checkNames(t, fsys, "dir", "d.txt")
+
+	checkErr(t, "Rename() of missing file", fsys.Rename("missing.txt", "e.txt"), fs.ErrNotExist)
+	if err := fsys.Rename("dir/d.txt", "dir"); err == nil {
+		t.Errorf("Rename() over a directory error = nil")
+	}
+}
+
+func testRenameDir(t *testing.T, fsys hidrive.WritableFS) {
+	const create = os.O_WRONLY | os.O_CREATE | os.O_TRUNC
+	if err := fsys.MkdirAll("src/sub", 0o755); err != nil {
+		t.Fatalf("MkdirAll() error = %v", err)
+	}
+	if err := fsys.Mkdir("other", 0o755); err != nil {
+		t.Fatalf("Mkdir() error = %v", err)
+	}
+	writeFile(t, fsys, "src/a.txt", create, "a")
+	writeFile(t, fsys, "src/sub/b.txt", create, "b")
+	writeFile(t, fsys, "other/c.txt", create, "c")
+
+	if err := fsys.Rename("src", "renamed"); err != nil {
+		t.Fatalf("Rename() error = %v", err)
+	}
+	checkNames(t, fsys, ".", "other", "renamed")
+	checkFile(t, fsys, "renamed/sub/b.txt", "b")
+
+	if err := fsys.Rename("renamed", "other/moved"); err != nil {
+		t.Fatalf("Rename() to another directory error = %v", err)
+	}
+	checkNames(t, fsys, ".", "other")
+	checkNames(t, fsys, "other", "c.txt", "moved")
+	checkFile(t, fsys, "other/moved/a.txt", "a")
+	checkFile(t, fsys, "other/moved/sub/b.txt", "b")
+
+	if err := fsys.Rename("other/moved", "other/moved/sub/inside"); err == nil {
+		t.Errorf("Rename() into itself error = nil")
+	}
+	if err := fsys.Rename("other/moved/sub", "other"); err == nil {
+		t.Errorf("Rename() over a non-empty directory error = nil")
+	}
+	checkFile(t, fsys, "other/moved/sub/b.txt", "b")
+}
+
+func testRemove(t *testing.T, fsys hidrive.WritableFS) {
+	if err := fsys.MkdirAll("dir/sub", 0o755); err != nil {
+		t.Fatalf("MkdirAll() error = %v", err)
+	}
+	writeFile(t, fsys, "a.txt", os.O_WRONLY|os.O_CREATE, "a")
+
+	if err := fsys.Remove("a.txt"); err != nil {
+		t.Errorf("Remove() of file error = %v", err)
+	}
+	checkErr(t, "Remove() of non-empty directory", fsys.Remove("dir"), fs.ErrExist)
+	if err := fsys.Remove("dir/sub"); err != nil {
+		t.Errorf("Remove() of empty directory error = %v", err)
+	}
+	checkErr(t, "Remove() of missing file", fsys.Remove("a.txt"), fs.ErrNotExist)
+	checkNames(t, fsys, ".", "dir")
+}
+
+func testRemoveAll(t *testing.T, fsys hidrive.WritableFS) {
+	if err := fsys.MkdirAll("dir/sub", 0o755); err != nil {
+		t.Fatalf("MkdirAll() error = %v", err)
+	}
+	writeFile(t, fsys, "dir/sub/a.txt", os.O_WRONLY|os.O_CREATE, "a")
+	writeFile(t, fsys, "b.txt", os.O_WRONLY|os.O_CREATE, "b")
+
+	if err := fsys.RemoveAll("dir"); err != nil {
+		t.Errorf("RemoveAll() of directory error = %v", err)
+	}
+	if err := fsys.RemoveAll("b.txt"); err != nil {
+		t.Errorf("RemoveAll() of file error = %v", err)
+	}
+	if err := fsys.RemoveAll("missing"); err != nil {
+		t.Errorf("RemoveAll() of missing path error = %v", err)
+	}
+	checkNames(t, fsys, ".")
+}
+
+func testChtimes(t *testing.T, fsys hidrive.WritableFS) {
+	writeFile(t, fsys, "a.txt", os.O_WRONLY|os.O_CREATE, "a")
+	if err := fsys.Mkdir("dir", 0o755); err != nil {
+		t.Fatalf("Mkdir() error = %v", err)
+	}
+
+	mtime := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	for _, name := range []string{"a.txt", "dir"} {
+		if err := fsys.Chtimes(name, mtime, mtime); err != nil {
+			t.Fatalf("Chtimes(%q) error = %v", name, err)
+		}
+		if info, err := fsys.Stat(name); err != nil || !info.ModTime().Equal(mtime) {
+			t.Errorf("Stat(%q) = %v, %v, want mtime %v", name, info, err, mtime)
+		}
+	}
+	checkErr(t, "Chtimes() of missing file", fsys.Chtimes("missing", mtime, mtime), fs.ErrNotExist)
+}
@@ -0,0 +1,144 @@
+package go_hidrive
+
+import (
+	"context"
+	"iter"
+	"net/url"
+	"strings"
+)
+
+const DefaultListPageSize = 1000 // Default number of directory members requested at once by [Dir.List]
+
+/*
+DirIterator - iterates over members of a directory fetching them page by page, see [Dir.List].
+
+Typical usage:
+
+	it := dirApi.List(ctx, hidrive.NewParameters().SetPath("/public").Values, 0)
+	for it.Next() {
+		fmt.Println(it.Object().Name)
+	}
+	if err := it.Err(); err != nil {
+		return err
+	}
+*/
+type DirIterator struct {
+	ctx      context.Context
+	api      Dir
+	params   url.Values
+	pageSize uint
+
+	offset uint
+	page   []*Object
+	obj    *Object
+	dir    *Object // the directory as returned with the last page
+	done   bool
+	err    error
+}
+
+/*
+List - return [DirIterator] over all members of the directory, which transparently issues as many [Dir.Get]
+requests as needed using `limit` parameter with the given page size (0 means [DefaultListPageSize]).
+
+Iteration stops when all members are returned, on the first error or when `ctx` is cancelled
+(the context error is then returned by [DirIterator.Err]).
+The end of the listing is detected by the number of members (`nmembers`) reported by HiDrive, as a page may be
+shorter than requested. Only if the number is missing in the response, a short page is taken for the last one.
+The `limit` parameter, if present in `params`, is overridden.
+
+Supported parameters are the same as for [Dir.Get], the `fields` parameter should list the member fields needed
+(e.g. "members.name"), "nmembers" is added to them if missing. The `members` parameter can be used to filter
+the members by type.
+*/
+func (d Dir) List(ctx context.Context, params url.Values, pageSize uint) *DirIterator {
+	if pageSize == 0 {
+		pageSize = DefaultListPageSize
+	}
+
+	p := url.Values{}
+	for k, v := range params {
+		p[k] = v
+	}
+	if fields := p.Get("fields"); fields != "" && !isItemInSlice(strings.Split(fields, ","), "nmembers") {
+		p.Set("fields", fields+",nmembers")
+	}
+	return &DirIterator{ctx: ctx, api: d, params: p, pageSize: pageSize}
+}
+
+// Next advances the iterator to the next member, it returns false when there are no more members or an error occurred.
+func (it *DirIterator) Next() bool {
+	if it.err != nil {
+		return false
+	}
+	if it.err = it.ctx.Err(); it.err != nil {
+		return false
+	}
+
+	if len(it.page) == 0 {
+		if it.done {
+			return false
+		}
+		if it.err = it.fetch(); it.err != nil || len(it.page) == 0 {
+			return false
+		}
+	}
+
+	it.obj, it.page = it.page[0], it.page[1:]
+	return true
+}
+
+// Object returns the current member.
+func (it *DirIterator) Object() *Object {
+	return it.obj
+}
+
+// Err returns the error which stopped the iteration, if any.
+func (it *DirIterator) Err() error {
+	return it.err
+}
+
+// fetch requests the next page of members.
+func (it *DirIterator) fetch() error {
+	params := (&Parameters{it.params}).SetLimit(it.pageSize, it.offset).Values
+
+	obj, err := it.api.Get(it.ctx, params)
+	if err != nil {
+		return err
+	}
+
+	it.dir = obj
+	it.page = obj.Members
+	it.offset += uint(len(obj.Members))
+	if obj.MemberCount >= 0 {
+		it.done = len(obj.Members) == 0 || int64(it.offset) >= obj.MemberCount
+	} else {
+		it.done = uint(len(obj.Members)) < it.pageSize
+	}
+	return nil
+}
+
+/*
+All - return an iterator over all members of the directory to be used with `range`, see [Dir.List] for details.
+
+	for obj, err := range dirApi.All(ctx, hidrive.NewParameters().SetPath("/public").Values, 0) {
+		if err != nil {
+			return err
+		}
+		fmt.Println(obj.Name)
+	}
+
+On error the iterator yields a nil [Object] together with the error and stops.
+*/
+func (d Dir) All(ctx context.Context, params url.Values, pageSize uint) iter.Seq2[*Object, error] {
+	return func(yield func(*Object, error) bool) {
+		it := d.List(ctx, params, pageSize)
+		for it.Next() {
+			if !yield(it.Object(), nil) {
+				return
+			}
+		}
+		if err := it.Err(); err != nil {
+			yield(nil, err)
+		}
+	}
+}
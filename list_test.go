@@ -0,0 +1,189 @@
+package go_hidrive_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	hidrive "github.com/Burmuley/go-hidrive"
+	"github.com/Burmuley/go-hidrive/hidrivetest"
+)
+
+func TestDir_List(t *testing.T) {
+	tests := []struct {
+		name         string
+		files        int
+		pageSize     uint
+		maxListLimit int
+		fields       []string
+		path         string
+		wantRequests int64
+		wantErr      error
+	}{
+		{
+			name:         "empty directory",
+			files:        0,
+			pageSize:     10,
+			wantRequests: 1,
+		},
+		{
+			name:         "single page",
+			files:        7,
+			pageSize:     10,
+			wantRequests: 1,
+		},
+		{
+			name:         "multiple of page size",
+			files:        20,
+			pageSize:     10,
+			wantRequests: 2,
+		},
+		{
+			name:         "last page is shorter",
+			files:        25,
+			pageSize:     10,
+			wantRequests: 3,
+		},
+		{
+			name:         "pages shorter than requested",
+			files:        25,
+			pageSize:     10,
+			maxListLimit: 4,
+			wantRequests: 7,
+		},
+		{
+			name:         "nmembers is requested if missing in fields",
+			files:        25,
+			pageSize:     10,
+			maxListLimit: 4,
+			fields:       []string{"members.name"},
+			wantRequests: 7,
+		},
+		{
+			name:         "default page size",
+			files:        25,
+			wantRequests: 1,
+		},
+		{
+			name:         "directory does not exist",
+			path:         "/public/missing",
+			pageSize:     10,
+			wantRequests: 1,
+			wantErr:      hidrive.ErrNotFound,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := hidrivetest.NewServer()
+			defer srv.Close()
+			srv.MaxListLimit = tt.maxListLimit
+			if err := srv.AddDir("/public/dir"); err != nil {
+				t.Fatalf("AddDir() error = %v", err)
+			}
+			for i := 0; i < tt.files; i++ {
+				if err := srv.AddFile(fmt.Sprintf("/public/dir/file%02d.txt", i), nil, time.Now()); err != nil {
+					t.Fatalf("AddFile() error = %v", err)
+				}
+			}
+			transport := &countingTransport{base: srv.Client().Transport}
+			dirApi := hidrive.NewDir(&http.Client{Transport: transport}, srv.Endpoint())
+			path := tt.path
+			if path == "" {
+				path = "/public/dir"
+			}
+			fields := tt.fields
+			if fields == nil {
+				fields = []string{"nmembers", "members.name"}
+			}
+			params := hidrive.NewParameters().SetPath(path).SetSortBy("name").SetFields(fields)
+
+			var names []string
+			it := dirApi.List(context.Background(), params.Values, tt.pageSize)
+			for it.Next() {
+				names = append(names, it.Object().Name)
+			}
+			if !errors.Is(it.Err(), tt.wantErr) {
+				t.Fatalf("List() error = %v, want %v", it.Err(), tt.wantErr)
+			}
+			if transport.count != tt.wantRequests {
+				t.Errorf("List() requests = %d, want %d", transport.count, tt.wantRequests)
+			}
+			if tt.wantErr != nil {
+				return
+			}
+			if len(names) != tt.files {
+				t.Fatalf("List() members = %d, want %d", len(names), tt.files)
+			}
+			for i, name := range names {
+				if want := fmt.Sprintf("file%02d.txt", i); name != want {
+					t.Errorf("List() member %d = %q, want %q", i, name, want)
+				}
+			}
+		})
+	}
+}
+
+func TestDir_All(t *testing.T) {
+	srv := hidrivetest.NewServer()
+	defer srv.Close()
+	for i := 0; i < 30; i++ {
+		if err := srv.AddFile(fmt.Sprintf("/public/dir/file%02d.txt", i), nil, time.Now()); err != nil {
+			t.Fatalf("AddFile() error = %v", err)
+		}
+	}
+	dirApi := hidrive.NewDir(srv.Client(), srv.Endpoint())
+	params := hidrive.NewParameters().SetPath("/public/dir").Values
+
+	t.Run("all members", func(t *testing.T) {
+		count := 0
+		for obj, err := range dirApi.All(context.Background(), params, 7) {
+			if err != nil {
+				t.Fatalf("All() error = %v", err)
+			}
+			if obj == nil {
+				t.Fatalf("All() yielded nil object")
+			}
+			count++
+		}
+		if count != 30 {
+			t.Errorf("All() members = %d, want 30", count)
+		}
+	})
+
+	t.Run("break stops iteration", func(t *testing.T) {
+		count := 0
+		for range dirApi.All(context.Background(), params, 7) {
+			if count++; count == 10 {
+				break
+			}
+		}
+		if count != 10 {
+			t.Errorf("All() members = %d, want 10", count)
+		}
+	})
+
+	t.Run("context cancellation", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		count := 0
+		var lastErr error
+		for obj, err := range dirApi.All(ctx, params, 7) {
+			if err != nil {
+				lastErr = err
+				continue
+			}
+			if obj != nil {
+				if count++; count == 5 {
+					cancel()
+				}
+			}
+		}
+		if count != 5 || !errors.Is(lastErr, context.Canceled) {
+			t.Errorf("All() members = %d, error = %v, want 5, %v", count, lastErr, context.Canceled)
+		}
+	})
+}
@@ -43,6 +43,7 @@ Supported parameters:
   - path ([Parameters.SetPath])
   - pid ([Parameters.SetPid])
   - fields ([Parameters.SetFields])
+  - snapshot ([Parameters.SetSnapshot])
 */
 func (m Meta) Get(ctx context.Context, params url.Values) (*Object, error) {
 	var (
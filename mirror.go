@@ -0,0 +1,404 @@
+package go_hidrive
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"maps"
+	"math/rand/v2"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ErrInvalidName - the name of a remote object can not be used as a local file name.
+var ErrInvalidName = errors.New("invalid name")
+
+// mirrorFields - object fields requested by [Client.Mirror] for every remote object.
+var mirrorFields = []string{"id", "name", "type", "size", "mtime", "chash", "mhash"}
+
+/*
+SyncState - the state of previous runs of [Client.Mirror] or [Client.TwoWaySync].
+
+[Client.Mirror] records directories together with their remote meta hash (`mhash`) in `Dirs`. The meta hash of
+a HiDrive directory changes whenever anything within its subtree changes, so a directory whose meta hash equals
+the recorded one has not changed since it was mirrored and is skipped without being listed.
+
+[Client.TwoWaySync] records the last synced version of every file and directory in `Paths`, see [SyncRecord].
+
+The state can be serialized to JSON (see [SyncState.Save] and [LoadSyncState]) to be reused by the next run.
+The state belongs to a single pair of remote and local directories, it is reset when used with different ones.
+*/
+type SyncState struct {
+	Remote string                `json:"remote"`          // remote root directory
+	Local  string                `json:"local"`           // local root directory
+	Dirs   map[string]string     `json:"dirs"`            // meta hashes of mirrored directories by relative path
+	Paths  map[string]SyncRecord `json:"paths,omitempty"` // last synced versions by relative path
+}
+
+/*
+SyncRecord - the version of a file or directory recorded by [Client.TwoWaySync] after it was last synced.
+
+Properties `Size`, `MTime` (in seconds since Unix epoch) and `CHash` are the same on both sides after the sync,
+`PID` is the ID of the remote object. Only `Dir` is set for directories.
+*/
+type SyncRecord struct {
+	Dir   bool   `json:"dir,omitempty"`
+	Size  int64  `json:"size,omitempty"`
+	MTime int64  `json:"mtime,omitempty"`
+	CHash string `json:"chash,omitempty"`
+	PID   string `json:"pid,omitempty"`
+}
+
+// NewSyncState - create new empty instance of [SyncState].
+func NewSyncState() *SyncState {
+	return &SyncState{Dirs: map[string]string{}, Paths: map[string]SyncRecord{}}
+}
+
+// LoadSyncState reads [SyncState] previously stored with [SyncState.Save] from the file.
+func LoadSyncState(name string) (*SyncState, error) {
+	state := NewSyncState()
+	if err := loadJSON(name, "sync state", state); err != nil {
+		return nil, err
+	}
+	return state, nil
+}
+
+// Save writes the state as JSON to the file, the file is replaced atomically.
+func (s *SyncState) Save(name string) error {
+	return saveJSON(name, s)
+}
+
+// reset clears the state if it was recorded for different directories.
+func (s *SyncState) reset(remote, local string) {
+	if s.Remote != remote || s.Local != local {
+		s.Remote, s.Local, s.Dirs, s.Paths = remote, local, nil, nil
+	}
+	if s.Dirs == nil {
+		s.Dirs = map[string]string{}
+	}
+	if s.Paths == nil {
+		s.Paths = map[string]SyncRecord{}
+	}
+}
+
+/*
+Mirror - make the local directory `localDir` a copy of the HiDrive directory `remotePath`, the reverse of [Client.Sync].
+
+Remote directories are listed with [Dir.List] and compared with the local tree: missing directories are created,
+new and changed files are downloaded with [File.Get] and local modification times are set from [Object.MTime].
+Files are downloaded into a temporary file in the target directory which is then renamed over the target, so an
+interrupted mirror never leaves partially written files behind. Local objects of a different type than the remote
+ones are replaced, with [SyncOptions.Delete] local files and directories removed remotely are pruned. Remote names
+which can not be used locally ("..", names containing a path separator) stop the mirror with [ErrInvalidName]
+before anything is written.
+
+With [SyncOptions.State] directories whose remote meta hash has not changed since the previous run are skipped
+without being listed, so a mirror of a large mostly unchanged tree only costs a few requests. This assumes the local
+copy is not modified between the runs, use a new state to check the whole tree.
+
+The mirror stops at the first error, the returned report then lists the changes made so far.
+*/
+func (c *Client) Mirror(ctx context.Context, remotePath, localDir string, opts *SyncOptions) (*SyncReport, error) {
+	m := &mirror{ctx: ctx, api: c.api, remote: path.Clean("/" + remotePath), local: localDir, report: &SyncReport{}}
+	if opts != nil {
+		m.opts = *opts
+	}
+	if m.opts.State != nil {
+		m.opts.State.reset(m.remote, m.local)
+	}
+
+	root, err := Meta{c.api}.Get(ctx, NewParameters().SetPath(m.remote).SetFields(mirrorFields).Values)
+	if err != nil {
+		return m.report, err
+	}
+	if root.Type != "dir" {
+		return m.report, fmt.Errorf("%s: not a directory", m.remote)
+	}
+
+	info, err := os.Lstat(localDir)
+	switch {
+	case err == nil && !info.IsDir():
+		return m.report, fmt.Errorf("%s: not a directory", localDir)
+	case err != nil && !os.IsNotExist(err):
+		return m.report, err
+	case err != nil:
+		info = nil
+	}
+	return m.report, m.mirrorDir(".", root, info)
+}
+
+// mirror holds the state of a single [Client.Mirror] call.
+type mirror struct {
+	ctx    context.Context
+	api    Api
+	opts   SyncOptions
+	remote string
+	local  string
+	report *SyncReport
+}
+
+// localPath returns the local path of the relative path.
+func (m *mirror) localPath(rel string) string {
+	return filepath.Join(m.local, filepath.FromSlash(rel))
+}
+
+// mirrorDir mirrors the remote directory, `info` describes the local directory, nil if it does not exist.
+func (m *mirror) mirrorDir(rel string, obj *Object, info fs.FileInfo) error {
+	if err := m.ctx.Err(); err != nil {
+		return err
+	}
+
+	state := m.opts.State
+	if info != nil && state != nil && obj.MetaHash != "" && state.Dirs[rel] == obj.MetaHash {
+		m.report.Skipped++
+		return nil
+	}
+
+	members, err := m.list(obj)
+	if err != nil {
+		return err
+	}
+	for _, member := range members {
+		if err := checkLocalName(member.Name); err != nil {
+			return fmt.Errorf("%s: %w", path.Join(m.remote, rel), err)
+		}
+	}
+
+	created := info == nil
+	if created {
+		m.report.add(rel, SyncMkdir, true, true, 0)
+		if !m.opts.DryRun {
+			if err := os.MkdirAll(m.localPath(rel), 0o755); err != nil {
+				return err
+			}
+		}
+	}
+
+	local := map[string]fs.FileInfo{}
+	if !created {
+		entries, err := os.ReadDir(m.localPath(rel))
+		if err != nil {
+			return err
+		}
+		for _, e := range entries {
+			if local[e.Name()], err = e.Info(); err != nil {
+				return err
+			}
+		}
+	}
+
+	remote, dirs := map[string]bool{}, map[string]bool{}
+	for _, member := range members {
+		remote[member.Name] = true
+		dirs[member.Name] = member.Type == "dir"
+		childRel := path.Join(rel, member.Name)
+		childInfo := local[member.Name]
+
+		if childInfo != nil && childInfo.IsDir() != (member.Type == "dir") {
+			if err := m.delete(childRel, childInfo); err != nil {
+				return err
+			}
+			childInfo = nil
+		}
+		if member.Type == "dir" {
+			err = m.mirrorDir(childRel, member, childInfo)
+		} else {
+			err = m.mirrorFile(childRel, member, childInfo)
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	if m.opts.Delete {
+		for _, name := range slices.Sorted(maps.Keys(local)) {
+			if !remote[name] {
+				if err := m.delete(path.Join(rel, name), local[name]); err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	if m.opts.DryRun {
+		if !created && info.ModTime().Unix() != time.Time(obj.MTime).Unix() {
+			m.report.add(rel, SyncSetMTime, true, true, 0)
+		}
+		return nil
+	}
+
+	// the local mtime has changed if anything has been created or removed in the directory
+	cur, err := os.Stat(m.localPath(rel))
+	if err != nil {
+		return err
+	}
+	if mtime := time.Time(obj.MTime); cur.ModTime().Unix() != mtime.Unix() {
+		if !created && info.ModTime().Unix() != mtime.Unix() {
+			m.report.add(rel, SyncSetMTime, true, true, 0)
+		}
+		if err := os.Chtimes(m.localPath(rel), mtime, mtime); err != nil {
+			return err
+		}
+	}
+
+	if state != nil {
+		m.pruneState(rel, dirs)
+		if obj.MetaHash != "" {
+			state.Dirs[rel] = obj.MetaHash
+		}
+	}
+	return nil
+}
+
+// pruneState removes the recorded meta hashes of subdirectories of `rel` which are no longer remote directories.
+func (m *mirror) pruneState(rel string, dirs map[string]bool) {
+	for key := range m.opts.State.Dirs {
+		sub, ok := strings.CutPrefix(key, rel+"/")
+		if rel == "." {
+			sub, ok = key, key != "."
+		}
+		if !ok {
+			continue
+		}
+		if name, _, _ := strings.Cut(sub, "/"); !dirs[name] {
+			delete(m.opts.State.Dirs, key)
+		}
+	}
+}
+
+// checkLocalName returns an error wrapping [ErrInvalidName] if the name can not be used as a local file name.
+func checkLocalName(name string) error {
+	if name == "" || name == "." || name == ".." || strings.ContainsAny(name, "/\x00") ||
+		strings.ContainsRune(name, filepath.Separator) {
+		return fmt.Errorf("%w %q", ErrInvalidName, name)
+	}
+	return nil
+}
+
+// list returns all members of the remote directory.
+func (m *mirror) list(obj *Object) ([]*Object, error) {
+	fields := []string{"nmembers"}
+	for _, f := range mirrorFields {
+		fields = append(fields, "members."+f)
+	}
+
+	var members []*Object
+	for member, err := range (Dir{m.api}).All(m.ctx, NewParameters().SetPid(obj.ID).SetFields(fields).Values, 0) {
+		if err != nil {
+			return nil, err
+		}
+		members = append(members, member)
+	}
+
+	sort.Slice(members, func(i, j int) bool { return members[i].Name < members[j].Name })
+	return members, nil
+}
+
+// mirrorFile downloads the remote file if it is new or changed, `info` describes the local file, nil if missing.
+func (m *mirror) mirrorFile(rel string, obj *Object, info fs.FileInfo) error {
+	mtime := time.Time(obj.MTime)
+	action := SyncDownload
+	if info != nil {
+		var err error
+		if action, err = compareFile(m.localPath(rel), info, obj, m.opts.Compare); err != nil {
+			return err
+		}
+	}
+
+	switch action {
+	case "":
+		m.report.Unchanged++
+		return nil
+	case SyncSetMTime:
+		m.report.add(rel, SyncSetMTime, false, true, obj.Size)
+		if m.opts.DryRun {
+			return nil
+		}
+		return os.Chtimes(m.localPath(rel), mtime, mtime)
+	}
+
+	m.report.add(rel, action, false, true, obj.Size)
+	if m.opts.DryRun {
+		return nil
+	}
+	return m.download(rel, obj)
+}
+
+// download writes the remote file into a temporary file and renames it over the local file.
+func (m *mirror) download(rel string, obj *Object) (err error) {
+	target := m.localPath(rel)
+	tmp, err := createTemp(target)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			tmp.Close()
+			os.Remove(tmp.Name())
+		}
+	}()
+
+	// an existing file keeps its permissions, a new one gets the default permissions of new files
+	if info, statErr := os.Stat(target); statErr == nil {
+		if err = tmp.Chmod(info.Mode().Perm()); err != nil {
+			return err
+		}
+	}
+
+	rdr, err := File{m.api}.Get(m.ctx, NewParameters().SetPid(obj.ID).Values)
+	if err != nil {
+		return err
+	}
+	defer rdr.Close()
+
+	n, err := io.Copy(tmp, rdr)
+	if err != nil {
+		return err
+	}
+	if n != obj.Size {
+		return fmt.Errorf("%s: %w: downloaded %d bytes, file size is %d", rel, ErrSizeMismatch, n, obj.Size)
+	}
+	if err = tmp.Close(); err != nil {
+		return err
+	}
+
+	mtime := time.Time(obj.MTime)
+	if err = os.Chtimes(tmp.Name(), mtime, mtime); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), target)
+}
+
+/*
+createTemp creates a new temporary file in the directory of `target`. Unlike os.CreateTemp which always uses 0600,
+the file is created with 0666 permissions, so it gets the default permissions of new files (minus the umask).
+*/
+func createTemp(target string) (*os.File, error) {
+	for {
+		name := filepath.Join(filepath.Dir(target), fmt.Sprintf(".%s.%d.tmp", filepath.Base(target), rand.Uint32()))
+		f, err := os.OpenFile(name, os.O_RDWR|os.O_CREATE|os.O_EXCL, 0o666)
+		if !errors.Is(err, fs.ErrExist) {
+			return f, err
+		}
+	}
+}
+
+// delete removes the local file or directory (recursively).
+func (m *mirror) delete(rel string, info fs.FileInfo) error {
+	var size int64
+	if !info.IsDir() {
+		size = info.Size()
+	}
+	m.report.add(rel, SyncDelete, info.IsDir(), true, size)
+	if m.opts.DryRun {
+		return nil
+	}
+	return os.RemoveAll(m.localPath(rel))
+}
@@ -0,0 +1,301 @@
+package go_hidrive_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"slices"
+	"testing"
+	"time"
+
+	hidrive "github.com/Burmuley/go-hidrive"
+	"github.com/Burmuley/go-hidrive/hidrivetest"
+)
+
+func TestClient_Mirror(t *testing.T) {
+	files := map[string]string{
+		"/public/team/a.txt":     "a",
+		"/public/team/b/c.txt":   "c",
+		"/public/team/b/d/e.txt": "e",
+		"/public/team/empty/":    "",
+	}
+	setup := func(t *testing.T) (*hidrive.Client, *hidrivetest.Server, *countingTransport, string) {
+		transport := &countingTransport{}
+		srv, client := hidrivetest.NewClient(t, files, syncMTime, hidrive.WithHTTPClient(&http.Client{Transport: transport}))
+		transport.base = srv.Client().Transport
+		return client, srv, transport, filepath.Join(t.TempDir(), "mirror")
+	}
+	readLocal := func(t *testing.T, name string) string {
+		t.Helper()
+		data, err := os.ReadFile(name)
+		if err != nil {
+			t.Errorf("ReadFile() error = %v", err)
+		}
+		return string(data)
+	}
+	ctx := context.Background()
+
+	t.Run("initial and incremental", func(t *testing.T) {
+		client, srv, transport, local := setup(t)
+		state := hidrive.NewSyncState()
+		report, err := client.Mirror(ctx, "/public/team", local, &hidrive.SyncOptions{State: state})
+		if err != nil {
+			t.Fatalf("Mirror() error = %v", err)
+		}
+		want := []string{"mkdir .", "download a.txt", "mkdir b", "download b/c.txt", "mkdir b/d", "download b/d/e.txt", "mkdir empty"}
+		if got := syncActions(report); !reflect.DeepEqual(got, want) {
+			t.Errorf("Mirror() actions = %q, want %q", got, want)
+		}
+		if report.Bytes != 3 {
+			t.Errorf("Mirror() bytes = %d, want 3", report.Bytes)
+		}
+		for _, rel := range []string{"a.txt", "b/c.txt", "b/d/e.txt"} {
+			name := filepath.Join(local, filepath.FromSlash(rel))
+			if got := readLocal(t, name); got != files["/public/team/"+rel] {
+				t.Errorf("%s = %q", rel, got)
+			}
+		}
+		for _, rel := range []string{".", "a.txt", "b", "b/d/e.txt", "empty"} {
+			want, _ := srv.ModTime(path.Join("/public/team", rel))
+			if info, err := os.Stat(filepath.Join(local, filepath.FromSlash(rel))); err != nil || info.ModTime().Unix() != want.Unix() {
+				t.Errorf("mtime of %s = %v, %v, want %v", rel, info.ModTime(), err, want)
+			}
+		}
+
+		// nothing changed: only the root is queried
+		transport.count = 0
+		report, err = client.Mirror(ctx, "/public/team", local, &hidrive.SyncOptions{State: state})
+		if err != nil {
+			t.Fatalf("Mirror() error = %v", err)
+		}
+		if len(report.Entries) != 0 || report.Skipped != 1 || transport.count != 1 {
+			t.Errorf("Mirror() actions = %q, skipped = %d, requests = %d", syncActions(report), report.Skipped, transport.count)
+		}
+
+		// a changed file: only directories on its path are listed again
+		later := syncMTime.Add(time.Hour)
+		if err := srv.AddFile("/public/team/b/c.txt", []byte("C"), later); err != nil {
+			t.Fatalf("AddFile() error = %v", err)
+		}
+		saved := filepath.Join(t.TempDir(), "state.json")
+		if err := state.Save(saved); err != nil {
+			t.Fatalf("Save() error = %v", err)
+		}
+		if state, err = hidrive.LoadSyncState(saved); err != nil {
+			t.Fatalf("LoadSyncState() error = %v", err)
+		}
+		report, err = client.Mirror(ctx, "/public/team", local, &hidrive.SyncOptions{State: state})
+		if err != nil {
+			t.Fatalf("Mirror() error = %v", err)
+		}
+		if got := syncActions(report); !reflect.DeepEqual(got, []string{"update b/c.txt"}) || report.Skipped != 2 {
+			t.Errorf("Mirror() actions = %q, skipped = %d", got, report.Skipped)
+		}
+		if got := readLocal(t, filepath.Join(local, "b", "c.txt")); got != "C" {
+			t.Errorf("c.txt = %q, want %q", got, "C")
+		}
+
+		// the state is reset for other directories
+		other := filepath.Join(t.TempDir(), "other")
+		if report, err = client.Mirror(ctx, "/public/team/b", other, &hidrive.SyncOptions{State: state}); err != nil || report.Skipped != 0 {
+			t.Errorf("Mirror() to other directory skipped = %d, error = %v", report.Skipped, err)
+		}
+	})
+
+	t.Run("prune and replace", func(t *testing.T) {
+		client, srv, _, local := setup(t)
+		for rel, content := range map[string]string{"a.txt/x.txt": "a is a directory", "b/extra.txt": "extra", "empty": "empty is a file"} {
+			name := filepath.Join(local, filepath.FromSlash(rel))
+			if err := os.MkdirAll(filepath.Dir(name), 0o755); err != nil {
+				t.Fatal(err)
+			}
+			if err := os.WriteFile(name, []byte(content), 0o644); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if err := os.WriteFile(filepath.Join(local, "b", "c.txt"), []byte("c"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Chtimes(filepath.Join(local, "b", "c.txt"), syncMTime.Add(time.Hour), syncMTime.Add(time.Hour)); err != nil {
+			t.Fatal(err)
+		}
+
+		report, err := client.Mirror(ctx, "/public/team", local, &hidrive.SyncOptions{DryRun: true, Delete: true, Compare: hidrive.SyncCompareHash})
+		if err != nil {
+			t.Fatalf("Mirror() error = %v", err)
+		}
+		want := []string{
+			"delete a.txt", "download a.txt", "mtime b/c.txt", "mkdir b/d", "download b/d/e.txt", "delete b/extra.txt",
+			"mtime b", "delete empty", "mkdir empty", "mtime .",
+		}
+		if got := syncActions(report); !reflect.DeepEqual(got, want) {
+			t.Errorf("Mirror() dry run actions = %q, want %q", got, want)
+		}
+		if got := readLocal(t, filepath.Join(local, "b", "extra.txt")); got != "extra" {
+			t.Errorf("dry run modified the local tree")
+		}
+
+		if _, err := client.Mirror(ctx, "/public/team", local, &hidrive.SyncOptions{Delete: true, Compare: hidrive.SyncCompareHash}); err != nil {
+			t.Fatalf("Mirror() error = %v", err)
+		}
+		if got := readLocal(t, filepath.Join(local, "a.txt")); got != "a" {
+			t.Errorf("a.txt = %q, want %q", got, "a")
+		}
+		if info, err := os.Stat(filepath.Join(local, "empty")); err != nil || !info.IsDir() {
+			t.Errorf("empty is not a directory: %v", err)
+		}
+		if _, err := os.Stat(filepath.Join(local, "b", "extra.txt")); !errors.Is(err, os.ErrNotExist) {
+			t.Errorf("extra.txt has not been pruned: %v", err)
+		}
+		if info, _ := os.Stat(filepath.Join(local, "b", "c.txt")); !info.ModTime().Equal(syncMTime) {
+			t.Errorf("mtime of c.txt = %v, want %v", info.ModTime(), syncMTime)
+		}
+		if want, _ := srv.ModTime("/public/team/b"); mustStat(t, filepath.Join(local, "b")).ModTime().Unix() != want.Unix() {
+			t.Errorf("mtime of b = %v, want %v", mustStat(t, filepath.Join(local, "b")).ModTime(), want)
+		}
+	})
+
+	t.Run("file modes", func(t *testing.T) {
+		if runtime.GOOS == "windows" {
+			t.Skip("file modes are not supported on Windows")
+		}
+		client, srv, _, local := setup(t)
+		if _, err := client.Mirror(ctx, "/public/team", local, nil); err != nil {
+			t.Fatalf("Mirror() error = %v", err)
+		}
+		// new files get the same permissions as files created by os.WriteFile with 0666
+		probe := filepath.Join(t.TempDir(), "probe")
+		if err := os.WriteFile(probe, nil, 0o666); err != nil {
+			t.Fatal(err)
+		}
+		if got, want := mustStat(t, filepath.Join(local, "a.txt")).Mode().Perm(), mustStat(t, probe).Mode().Perm(); got != want {
+			t.Errorf("mode of new a.txt = %v, want %v", got, want)
+		}
+
+		// updated files keep their permissions
+		if err := os.Chmod(filepath.Join(local, "a.txt"), 0o640); err != nil {
+			t.Fatal(err)
+		}
+		if err := srv.AddFile("/public/team/a.txt", []byte("new content"), syncMTime.Add(time.Hour)); err != nil {
+			t.Fatalf("AddFile() error = %v", err)
+		}
+		if _, err := client.Mirror(ctx, "/public/team", local, nil); err != nil {
+			t.Fatalf("Mirror() error = %v", err)
+		}
+		if got := readLocal(t, filepath.Join(local, "a.txt")); got != "new content" {
+			t.Errorf("a.txt = %q, want %q", got, "new content")
+		}
+		if got := mustStat(t, filepath.Join(local, "a.txt")).Mode().Perm(); got != 0o640 {
+			t.Errorf("mode of updated a.txt = %v, want %v", got, os.FileMode(0o640))
+		}
+	})
+
+	t.Run("invalid remote names", func(t *testing.T) {
+		for _, name := range []string{"..", "x/../..", "."} {
+			transport := &nameTransport{from: `"name":"b"`, to: fmt.Sprintf(`"name":%q`, name)}
+			srv, client := hidrivetest.NewClient(t, files, syncMTime, hidrive.WithHTTPClient(&http.Client{Transport: transport}))
+			transport.base = srv.Client().Transport
+			parent := t.TempDir()
+			local := filepath.Join(parent, "mirror")
+			if err := os.WriteFile(filepath.Join(parent, "keep.txt"), []byte("keep"), 0o644); err != nil {
+				t.Fatal(err)
+			}
+
+			_, err := client.Mirror(ctx, "/public/team", local, &hidrive.SyncOptions{Delete: true})
+			if !errors.Is(err, hidrive.ErrInvalidName) {
+				t.Errorf("Mirror() with remote name %q error = %v, want %v", name, err, hidrive.ErrInvalidName)
+			}
+			if entries, _ := os.ReadDir(parent); len(entries) != 1 || entries[0].Name() != "keep.txt" {
+				t.Errorf("Mirror() with remote name %q modified the local filesystem: %v", name, entries)
+			}
+		}
+	})
+
+	t.Run("state of removed directories", func(t *testing.T) {
+		client, _, _, local := setup(t)
+		state := hidrive.NewSyncState()
+		if _, err := client.Mirror(ctx, "/public/team", local, &hidrive.SyncOptions{State: state}); err != nil {
+			t.Fatalf("Mirror() error = %v", err)
+		}
+		if err := client.Dir.Delete(ctx, hidrive.NewParameters().SetPath("/public/team/b").SetRecursive(true).Values); err != nil {
+			t.Fatalf("Delete() error = %v", err)
+		}
+
+		if _, err := client.Mirror(ctx, "/public/team", local, &hidrive.SyncOptions{State: state, Delete: true}); err != nil {
+			t.Fatalf("Mirror() error = %v", err)
+		}
+		if got := slices.Sorted(maps.Keys(state.Dirs)); !reflect.DeepEqual(got, []string{".", "empty"}) {
+			t.Errorf("state directories = %q, want %q", got, []string{".", "empty"})
+		}
+	})
+
+	t.Run("failed download", func(t *testing.T) {
+		client, srv, _, local := setup(t)
+		if _, err := client.Mirror(ctx, "/public/team", local, nil); err != nil {
+			t.Fatalf("Mirror() error = %v", err)
+		}
+		if err := srv.AddFile("/public/team/a.txt", []byte("new content"), syncMTime.Add(time.Hour)); err != nil {
+			t.Fatalf("AddFile() error = %v", err)
+		}
+
+		srv.InjectError("GET", "file", http.StatusInternalServerError, 1)
+		if _, err := client.Mirror(ctx, "/public/team", local, nil); !errors.Is(err, hidrive.ErrInternal) {
+			t.Errorf("Mirror() error = %v, want %v", err, hidrive.ErrInternal)
+		}
+		entries, _ := os.ReadDir(local)
+		if len(entries) != 3 {
+			t.Errorf("entries = %v, want no temporary files left", entries)
+		}
+		if got := readLocal(t, filepath.Join(local, "a.txt")); got != "a" {
+			t.Errorf("a.txt = %q, want the previous content", got)
+		}
+
+		if _, err := client.Mirror(ctx, "/public/team/a.txt", local, nil); err == nil {
+			t.Errorf("Mirror() of a file error = nil")
+		}
+		if _, err := client.Mirror(ctx, "/public/missing", local, nil); !errors.Is(err, hidrive.ErrNotFound) {
+			t.Errorf("Mirror() of missing directory error = %v, want %v", err, hidrive.ErrNotFound)
+		}
+	})
+}
+
+func mustStat(t *testing.T, name string) os.FileInfo {
+	t.Helper()
+	info, err := os.Stat(name)
+	if err != nil {
+		t.Fatalf("Stat() error = %v", err)
+	}
+	return info
+}
+
+// nameTransport replaces `from` with `to` in all responses, e.g. to return a name HiDrive would not allow.
+type nameTransport struct {
+	base     http.RoundTripper
+	from, to string
+}
+
+func (t *nameTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	res, err := t.base.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	body, err := io.ReadAll(res.Body)
+	res.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	body = bytes.ReplaceAll(body, []byte(t.from), []byte(t.to))
+	res.Body = io.NopCloser(bytes.NewReader(body))
+	res.ContentLength = int64(len(body))
+	res.Header.Del("Content-Length")
+	return res, nil
+}
@@ -0,0 +1,122 @@
+package go_hidrive
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+/*
+OSFS - implementation of [WritableFS] over a directory of the local filesystem, it allows to swap HiDrive
+with a local disk (e.g. in tests or for local development).
+
+	var fsys hidrive.WritableFS = hidrive.NewOSFS("/var/lib/app/data")
+	if useHiDrive {
+		fsys = client.FS("/users/app/data")
+	}
+*/
+type OSFS struct {
+	dir  string
+	fsys fs.FS
+}
+
+// NewOSFS - create new instance of [OSFS] rooted at the given local directory.
+func NewOSFS(dir string) *OSFS {
+	return &OSFS{dir: dir, fsys: os.DirFS(dir)}
+}
+
+// localPath returns the path of the named file in the local filesystem.
+func (o *OSFS) localPath(op, name string) (string, error) {
+	if !fs.ValidPath(name) {
+		return "", &fs.PathError{Op: op, Path: name, Err: fs.ErrInvalid}
+	}
+	return filepath.Join(o.dir, filepath.FromSlash(name)), nil
+}
+
+// Open opens the named file for reading, implements fs.FS.
+func (o *OSFS) Open(name string) (fs.File, error) {
+	return o.fsys.Open(name)
+}
+
+// Stat returns fs.FileInfo describing the named file, implements fs.StatFS.
+func (o *OSFS) Stat(name string) (fs.FileInfo, error) {
+	return fs.Stat(o.fsys, name)
+}
+
+// ReadFile reads the named file, implements fs.ReadFileFS.
+func (o *OSFS) ReadFile(name string) ([]byte, error) {
+	return fs.ReadFile(o.fsys, name)
+}
+
+// ReadDir reads the named directory, implements fs.ReadDirFS.
+func (o *OSFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	return fs.ReadDir(o.fsys, name)
+}
+
+// OpenFile opens the named file, see os.OpenFile.
+func (o *OSFS) OpenFile(name string, flag int, perm fs.FileMode) (WritableFile, error) {
+	p, err := o.localPath("open", name)
+	if err != nil {
+		return nil, err
+	}
+	return os.OpenFile(p, flag, perm)
+}
+
+// Mkdir creates a new directory, see os.Mkdir.
+func (o *OSFS) Mkdir(name string, perm fs.FileMode) error {
+	p, err := o.localPath("mkdir", name)
+	if err != nil {
+		return err
+	}
+	return os.Mkdir(p, perm)
+}
+
+// MkdirAll creates a directory along with any missing parents, see os.MkdirAll.
+func (o *OSFS) MkdirAll(name string, perm fs.FileMode) error {
+	p, err := o.localPath("mkdir", name)
+	if err != nil {
+		return err
+	}
+	return os.MkdirAll(p, perm)
+}
+
+// Rename renames (moves) `oldname` to `newname`, see os.Rename.
+func (o *OSFS) Rename(oldname, newname string) error {
+	oldPath, err := o.localPath("rename", oldname)
+	if err != nil {
+		return err
+	}
+	newPath, err := o.localPath("rename", newname)
+	if err != nil {
+		return err
+	}
+	return os.Rename(oldPath, newPath)
+}
+
+// Remove removes the named file or empty directory, see os.Remove.
+func (o *OSFS) Remove(name string) error {
+	p, err := o.localPath("remove", name)
+	if err != nil {
+		return err
+	}
+	return os.Remove(p)
+}
+
+// RemoveAll removes the named file or directory with all its contents, see os.RemoveAll.
+func (o *OSFS) RemoveAll(name string) error {
+	p, err := o.localPath("removeall", name)
+	if err != nil {
+		return err
+	}
+	return os.RemoveAll(p)
+}
+
+// Chtimes changes the access and modification times of the named file, see os.Chtimes.
+func (o *OSFS) Chtimes(name string, atime, mtime time.Time) error {
+	p, err := o.localPath("chtimes", name)
+	if err != nil {
+		return err
+	}
+	return os.Chtimes(p, atime, mtime)
+}
@@ -29,6 +29,8 @@ Can be used in the following methods:
   - [Sharelink.Create]
   - [Meta.Get]
   - [Meta.Update]
+  - [Permission.Get]
+  - [Permission.Set]
 */
 func (p *Parameters) SetPath(path string) *Parameters {
 	p.Set("path", path)
@@ -52,6 +54,8 @@ Can be used in the following methods:
   - [Sharelink.Create]
   - [Meta.Get]
   - [Meta.Update]
+  - [Permission.Get]
+  - [Permission.Set]
 */
 func (p *Parameters) SetPid(pid string) *Parameters {
 	p.Set("pid", pid)
@@ -83,6 +87,7 @@ Both <limit> and <offset> need to be nonnegative integer values.
 
 The returned amount of entries may be less than requested.
 To get all directory entries it is always recommended to check the nmembers field and issue another request with an <offset> updated accordingly.
+[Dir.List] and [Dir.All] do this automatically.
 
 A value of none or 0 for <limit> signifies to return as many entries as is feasible. This also works when combined with an offset.
 
@@ -105,6 +110,9 @@ Can be used in the following methods:
   - [Share.Get]
   - [Sharelink.Get]
   - [Meta.Get]
+  - [Permission.Get]
+  - [User.Get]
+  - [User.Me]
 
 Valid values for [Dir.Get]:
   - category                - string    - object category (audio, image, etc.)
@@ -180,6 +188,29 @@ Valid values for [Share.Get]:
   - valid_until     - int       - UNIX timestamp
   - viewmode        - string    - single letter. influences the share folder display
   - writable        - bool
+
+Valid values for [Permission.Get]:
+  - account         - string    - the user or group the rights belong to
+  - id              - string    - path id (pid) of the object
+  - path            - string    - URL-Encoded path of the object
+  - readable        - bool      - read-permission of the account
+  - writable        - bool      - write-permission of the account
+
+Valid values for [User.Get] and [User.Me]:
+  - account         - string    - the account id of the user
+  - alias           - string    - the user name
+  - descriptor      - string    - the descriptor (display name) of the user
+  - email           - string    - the e-mail address of the user
+  - email_pending   - string    - a new e-mail address not confirmed yet
+  - email_verified  - bool      - has the e-mail address been verified
+  - encrypted       - bool      - is the home directory encrypted
+  - home            - string    - path of the home directory
+  - home_id         - string    - path id (pid) of the home directory
+  - is_admin        - bool      - does the user have administrative rights
+  - is_owner        - bool      - is the user the owner of the account
+  - language        - string    - preferred language (e.g. "en", "de")
+  - protocols       - object    - access protocols enabled for the user (e.g. "webdav", "rsync")
+  - quota           - object    - storage quota of the account ("total" and "used" bytes)
 */
 func (p *Parameters) SetFields(fields []string) *Parameters {
 	p.Set("fields", strings.Join(fields, ","))
@@ -230,7 +261,11 @@ Valid values are:
   - "autoname"  - find another name if the destination already exists
 
 Can be used in the following methods:
+  - [Dir.Copy]
+  - [Dir.Move]
+  - [Dir.Rename]
   - [File.Upload]
+  - [Snapshot.Restore]
 */
 func (p *Parameters) SetOnExist(onExists string) *Parameters {
 	p.Set("on_exist", onExists)
@@ -243,6 +278,7 @@ to be set after the operation.
 
 Can be used in the following methods:
   - [Dir.Create]
+  - [File.Update]
   - [File.Upload]
   - [Meta.Update]
 */
@@ -258,7 +294,9 @@ target's parent folder to be set after the operation.
 Can be used in the following methods:
   - [Dir.Create]
   - [Dir.Delete]
+  - [Dir.Rename]
   - [File.Delete]
+  - [File.Update]
   - [File.Upload]
 */
 func (p *Parameters) SetParentMTime(t time.Time) *Parameters {
@@ -274,7 +312,7 @@ Can be used in the following methods:
   - [Dir.Delete]
 */
 func (p *Parameters) SetRecursive(recursive bool) *Parameters {
-	p.Set("on_exist", fmt.Sprint(recursive))
+	p.Set("recursive", fmt.Sprint(recursive))
 	return p
 }
 
@@ -323,7 +361,10 @@ The name parameter is mandatory for binary uploads. It is forbidden for multipar
 to be specified as "filename" parameter within the content-disposition header.
 
 Can be used in the following methods:
+  - [Dir.Rename]
   - [File.Upload]
+  - [Snapshot.Create]
+  - [Snapshot.Rename]
 */
 func (p *Parameters) SetName(name string) *Parameters {
 	p.Set("name", name)
@@ -366,15 +407,19 @@ func (p *Parameters) SetMaxCount(count int) *Parameters {
 }
 
 /*
-SetPassword - adds "password" parameter to the request - optional protection for the share.
+SetPassword - adds "password" parameter to the request - optional protection for the share,
+or the password of the user for [User] methods.
 
 Consider this recommended, especially the closer the share is set to the root directory.
 This parameter must be omitted for encrypted shares which require salt, share_access_key, pw_sharekey.
+[User.Create] and [User.Update] send it in the request body instead of the URL.
 
 Can be used in the following methods:
   - [Share.Create]
   - [Sharelink.Create]
   - [Sharelink.Update]
+  - [User.Create]
+  - [User.Update]
 */
 func (p *Parameters) SetPassword(password string) *Parameters {
 	p.Set("password", password)
@@ -387,6 +432,7 @@ SetWritable - adds "writable" parameter to the request - This option can be set
 Note: This includes deletion and modification of existing content.
 
 Can be used in the following methods:
+  - [Permission.Set]
   - [Share.Create]
 */
 func (p *Parameters) SetWritable(writable bool) *Parameters {
@@ -451,7 +497,8 @@ func (p *Parameters) SetPwShareKey(key string) *Parameters {
 }
 
 /*
-SetId - adds "id" parameter to the request - a share id as returned by [Share.Get] or [Share.Create].
+SetId - adds "id" parameter to the request - a share id as returned by [Share.Get] or [Share.Create]
+or a snapshot id as returned by [Snapshot.Get] or [Snapshot.Create].
 
 Can be used in the following methods:
   - [Share.Create]
@@ -459,6 +506,8 @@ Can be used in the following methods:
   - [Sharelink.Create]
   - [Sharelink.Update]
   - [Sharelink.Delete]
+  - [Snapshot.Rename]
+  - [Snapshot.Delete]
 */
 func (p *Parameters) SetId(id string) *Parameters {
 	p.Set("id", id)
@@ -503,8 +552,11 @@ It is not allowed to use only "/" as a source, since it is the parent for all po
 Note: if used in combination with a src_id, this value is not allowed to start with "/" either.
 
 Can be used in the following methods:
+  - [Dir.Copy]
+  - [Dir.Move]
   - [File.Copy]
   - [File.Move]
+  - [Snapshot.Restore]
 */
 func (p *Parameters) SetSrc(src string) *Parameters {
 	p.Set("src", src)
@@ -522,8 +574,11 @@ So after this operation, the src_id may no longer be valid. However, the current
 information (as id) after a successful request.
 
 Can be used in the following methods:
+  - [Dir.Copy]
+  - [Dir.Move]
   - [File.Copy]
   - [File.Move]
+  - [Snapshot.Restore]
 */
 func (p *Parameters) SetSrcId(srcId string) *Parameters {
 	p.Set("src_id", srcId)
@@ -539,8 +594,11 @@ Example: /users/example/archive/something
 Note: if used in combination with a dst_id, this value is not allowed to start with "/".
 
 Can be used in the following methods:
+  - [Dir.Copy]
+  - [Dir.Move]
   - [File.Copy]
   - [File.Move]
+  - [Snapshot.Restore]
 */
 func (p *Parameters) SetDst(dst string) *Parameters {
 	p.Set("dst", dst)
@@ -554,8 +612,11 @@ of the dst.
 Example: b1489258310.123
 
 Can be used in the following methods:
+  - [Dir.Copy]
+  - [Dir.Move]
   - [File.Copy]
   - [File.Move]
+  - [Snapshot.Restore]
 */
 func (p *Parameters) SetDstId(dstId string) *Parameters {
 	p.Set("dst_id", dstId)
@@ -567,6 +628,7 @@ SetSrcParentMTime - adds "src_parent_mtime" parameter to the request - the modif
 parent folder to be set after the operation.
 
 Can be used in the following methods:
+  - [Dir.Move]
   - [File.Move]
 */
 func (p *Parameters) SetSrcParentMTime(t time.Time) *Parameters {
@@ -579,6 +641,8 @@ SetDstParentMTime - adds "dst_parent_mtime" parameter to the request - the modif
 parent folder to be set after the operation.
 
 Can be used in the following methods:
+  - [Dir.Copy]
+  - [Dir.Move]
   - [File.Copy]
   - [File.Move]
 */
@@ -592,9 +656,215 @@ SetPreserveMTime - adds "preserve_mtime" parameter to the request - the modifica
 copied from the source after the operation.
 
 Can be used in the following methods:
+  - [Dir.Copy]
   - [File.Copy]
+  - [Snapshot.Restore]
 */
 func (p *Parameters) SetPreserveMTime(pmTime bool) *Parameters {
 	p.Set("preserve_mtime", fmt.Sprint(pmTime))
 	return p
 }
+
+/*
+SetOffset - adds "offset" parameter to the request - the position in bytes in the target file where the uploaded
+content is written to.
+
+Can be used in the following methods:
+  - [File.Patch]
+*/
+func (p *Parameters) SetOffset(offset int64) *Parameters {
+	p.Set("offset", fmt.Sprint(offset))
+	return p
+}
+
+/*
+SetSnapshot - adds "snapshot" parameter to the request - the name of the snapshot to read the object from
+instead of the current state of the filesystem.
+
+Can be used in the following methods:
+  - [Dir.Copy]
+  - [Dir.Get]
+  - [File.Copy]
+  - [File.Download]
+  - [File.Get]
+  - [File.Hash]
+  - [File.Thumbnail]
+  - [Meta.Get]
+  - [Snapshot.Restore]
+*/
+func (p *Parameters) SetSnapshot(name string) *Parameters {
+	p.Set("snapshot", name)
+	return p
+}
+
+/*
+SetLevel - adds "level" parameter to the request - the level of the content hash tree to return checksums of,
+level 0 contains checksums of 4 KiB blocks, every next level aggregates 256 checksums of the previous one.
+
+Can be used in the following methods:
+  - [File.Hash]
+*/
+func (p *Parameters) SetLevel(level int) *Parameters {
+	p.Set("level", fmt.Sprint(level))
+	return p
+}
+
+/*
+SetRanges - adds "ranges" parameter to the request - the ranges of checksum indices of the requested level
+to be returned, e.g. HashRange{0, 9} for the first ten checksums.
+
+Can be used in the following methods:
+  - [File.Hash]
+*/
+func (p *Parameters) SetRanges(ranges ...HashRange) *Parameters {
+	values := make([]string, len(ranges))
+	for i, r := range ranges {
+		values[i] = r.String()
+	}
+	p.Set("ranges", strings.Join(values, ","))
+	return p
+}
+
+/*
+SetWidth - adds "width" parameter to the request - the maximum width of the thumbnail in pixels.
+
+Can be used in the following methods:
+  - [File.Thumbnail]
+*/
+func (p *Parameters) SetWidth(width uint) *Parameters {
+	p.Set("width", fmt.Sprint(width))
+	return p
+}
+
+/*
+SetHeight - adds "height" parameter to the request - the maximum height of the thumbnail in pixels.
+
+Can be used in the following methods:
+  - [File.Thumbnail]
+*/
+func (p *Parameters) SetHeight(height uint) *Parameters {
+	p.Set("height", fmt.Sprint(height))
+	return p
+}
+
+/*
+SetThumbnailMode - adds "mode" parameter to the request - the way the image is scaled to the requested size,
+[ThumbnailModeFit] by default.
+
+Can be used in the following methods:
+  - [File.Thumbnail]
+*/
+func (p *Parameters) SetThumbnailMode(mode ThumbnailMode) *Parameters {
+	p.Set("mode", string(mode))
+	return p
+}
+
+/*
+SetAccount - adds "account" parameter to the request - the user (alias) or the group the access rights belong to,
+the current user by default.
+
+Can be used in the following methods:
+  - [Permission.Get]
+  - [Permission.Set]
+*/
+func (p *Parameters) SetAccount(account string) *Parameters {
+	p.Set("account", account)
+	return p
+}
+
+/*
+SetReadable - adds "readable" parameter to the request - allows or denies read access to the directory.
+
+Can be used in the following methods:
+  - [Permission.Set]
+*/
+func (p *Parameters) SetReadable(readable bool) *Parameters {
+	p.Set("readable", fmt.Sprint(readable))
+	return p
+}
+
+/*
+SetRights - adds "readable" and "writable" parameters to the request according to the given [Rights].
+
+Can be used in the following methods:
+  - [Permission.Set]
+*/
+func (p *Parameters) SetRights(rights Rights) *Parameters {
+	p.SetReadable(rights >= RightsRead)
+	p.SetWritable(rights >= RightsReadWrite)
+	return p
+}
+
+/*
+SetAlias - adds "alias" parameter to the request - the user name (login) of the user.
+
+Can be used in the following methods:
+  - [User.Create]
+  - [User.Delete]
+  - [User.Get]
+  - [User.Update]
+*/
+func (p *Parameters) SetAlias(alias string) *Parameters {
+	p.Set("alias", alias)
+	return p
+}
+
+/*
+SetDescriptor - adds "descriptor" parameter to the request - the display name of the user.
+
+Can be used in the following methods:
+  - [User.Create]
+  - [User.Update]
+*/
+func (p *Parameters) SetDescriptor(descriptor string) *Parameters {
+	p.Set("descriptor", descriptor)
+	return p
+}
+
+/*
+SetEmail - adds "email" parameter to the request - the e-mail address of the user.
+
+Can be used in the following methods:
+  - [User.Create]
+  - [User.Update]
+*/
+func (p *Parameters) SetEmail(email string) *Parameters {
+	p.Set("email", email)
+	return p
+}
+
+/*
+SetHome - adds "home" parameter to the request - absolute path of the home directory of the user.
+
+Can be used in the following methods:
+  - [User.Create]
+  - [User.Update]
+*/
+func (p *Parameters) SetHome(home string) *Parameters {
+	p.Set("home", home)
+	return p
+}
+
+/*
+SetIsAdmin - adds "is_admin" parameter to the request - grants or revokes administrative rights of the user.
+
+Can be used in the following methods:
+  - [User.Create]
+  - [User.Update]
+*/
+func (p *Parameters) SetIsAdmin(admin bool) *Parameters {
+	p.Set("is_admin", fmt.Sprint(admin))
+	return p
+}
+
+/*
+SetQuota - adds "quota" parameter to the request - the storage quota of the user in bytes, 0 means no quota.
+
+Can be used in the following methods:
+  - [User.Create]
+  - [User.Update]
+*/
+func (p *Parameters) SetQuota(quota int64) *Parameters {
+	p.Set("quota", fmt.Sprint(quota))
+	return p
+}
@@ -0,0 +1,40 @@
+package go_hidrive_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	hidrive "github.com/Burmuley/go-hidrive"
+	"github.com/Burmuley/go-hidrive/hidrivetest"
+)
+
+func TestParameters_SetRecursive(t *testing.T) {
+	params := hidrive.NewParameters().SetRecursive(true)
+	if got := params.Get("recursive"); got != "true" {
+		t.Errorf("SetRecursive() recursive = %q, want %q", got, "true")
+	}
+	if params.Has("on_exist") {
+		t.Errorf("SetRecursive() set on_exist = %q", params.Get("on_exist"))
+	}
+
+	srv := hidrivetest.NewServer()
+	defer srv.Close()
+	if err := srv.AddFile("/public/dir/a.txt", []byte("a"), time.Now()); err != nil {
+		t.Fatalf("AddFile() error = %v", err)
+	}
+	dirApi := hidrive.NewDir(srv.Client(), srv.Endpoint())
+	ctx := context.Background()
+
+	// a non-empty directory is only deleted recursively
+	if err := dirApi.Delete(ctx, hidrive.NewParameters().SetPath("/public/dir").SetRecursive(false).Values); !errors.Is(err, hidrive.ErrConflict) {
+		t.Errorf("Delete() error = %v, want %v", err, hidrive.ErrConflict)
+	}
+	if err := dirApi.Delete(ctx, hidrive.NewParameters().SetPath("/public/dir").SetRecursive(true).Values); err != nil {
+		t.Errorf("Delete() error = %v", err)
+	}
+	if srv.Exists("/public/dir") {
+		t.Errorf("Delete() did not delete the directory")
+	}
+}
@@ -0,0 +1,135 @@
+package go_hidrive
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+)
+
+// Rights - access rights to a filesystem object, see [Parameters.SetRights].
+type Rights int
+
+const (
+	RightsNone      Rights = iota // No access
+	RightsRead                    // Read-only access
+	RightsReadWrite               // Read and write access
+)
+
+// String returns a human-readable representation of the rights.
+func (r Rights) String() string {
+	switch r {
+	case RightsRead:
+		return "read"
+	case RightsReadWrite:
+		return "read-write"
+	default:
+		return "none"
+	}
+}
+
+/*
+Permission - structure represents a set of methods for interacting with HiDrive `/permission` API endpoint.
+
+Permissions define which users (or groups) of the account can read or modify a directory, e.g. a team folder.
+Rights set on a directory apply to all its contents unless overridden deeper in the tree.
+*/
+type Permission struct {
+	Api
+}
+
+/*
+NewPermission - create new instance of Permission.
+
+Accepts http.Client and API endpoint as input parameters.
+If `endpoint` is empty string, then default `StratoHiDriveAPIV21` value is used.
+*/
+func NewPermission(client *http.Client, endpoint string) Permission {
+	api := NewApi(client, endpoint)
+	return Permission{api}
+}
+
+/*
+Get - get the access rights of a user or group to a filesystem object, the current user if `account` is not given.
+
+Both, the `pid` and `path` parameters identify a filesystem object, at least one of them is always mandatory.
+It is allowed to use both together, in which case `pid` addresses a parent directory and the value of `path` is then
+considered relative to that directory (<pid>/<path>).
+
+Status codes:
+  - 200 - OK
+  - 400 - Bad Request (e.g. invalid parameter)
+  - 401 - Unauthorized (no authentication)
+  - 403 - Forbidden (e.g. rights of other users requested by a non-admin user)
+  - 404 - Not Found (object or account does not exist)
+  - 500 - Internal Error
+
+Supported parameters:
+  - path ([Parameters.SetPath])
+  - pid ([Parameters.SetPid])
+  - account ([Parameters.SetAccount])
+  - fields ([Parameters.SetFields])
+
+Returns [PermissionObject] with the rights of the account.
+*/
+func (p Permission) Get(ctx context.Context, params url.Values) (*PermissionObject, error) {
+	var (
+		res *http.Response
+		err error
+	)
+
+	if res, err = p.doGET(ctx, "permission", params, []int{http.StatusOK}); err != nil {
+		return nil, err
+	}
+
+	obj := &PermissionObject{}
+	if err := p.unmarshalBody(res, obj); err != nil {
+		return nil, err
+	}
+
+	return obj, nil
+}
+
+/*
+Set - set the access rights of a user or group to a directory, requires administrative rights.
+
+Both, the `pid` and `path` parameters identify a filesystem object, at least one of them is always mandatory.
+It is allowed to use both together, in which case `pid` addresses a parent directory and the value of `path` is then
+considered relative to that directory (<pid>/<path>).
+
+The rights are given with `readable` and `writable` parameters, use [Parameters.SetRights] to set both at once.
+Write access requires read access.
+
+Status codes:
+  - 200 - OK
+  - 400 - Bad Request (e.g. invalid parameter)
+  - 401 - Unauthorized (no authentication)
+  - 403 - Forbidden (the user is not an administrator)
+  - 404 - Not Found (object or account does not exist)
+  - 500 - Internal Error
+
+Supported parameters:
+  - path ([Parameters.SetPath])
+  - pid ([Parameters.SetPid])
+  - account ([Parameters.SetAccount])
+  - readable ([Parameters.SetReadable])
+  - writable ([Parameters.SetWritable])
+
+Returns [PermissionObject] with the updated rights.
+*/
+func (p Permission) Set(ctx context.Context, params url.Values) (*PermissionObject, error) {
+	var (
+		res *http.Response
+		err error
+	)
+
+	if res, err = p.doPUT(ctx, "permission", params, []int{http.StatusOK}, nil); err != nil {
+		return nil, err
+	}
+
+	obj := &PermissionObject{}
+	if err := p.unmarshalBody(res, obj); err != nil {
+		return nil, err
+	}
+
+	return obj, nil
+}
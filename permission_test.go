@@ -0,0 +1,119 @@
+package go_hidrive_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	hidrive "github.com/Burmuley/go-hidrive"
+	"github.com/Burmuley/go-hidrive/hidrivetest"
+)
+
+func TestPermission(t *testing.T) {
+	srv := hidrivetest.NewServer()
+	defer srv.Close()
+	for _, alias := range []string{"alice", "bob"} {
+		if err := srv.AddUser(alias); err != nil {
+			t.Fatalf("AddUser() error = %v", err)
+		}
+	}
+	if err := srv.AddGroup("sales", "alice", "bob"); err != nil {
+		t.Fatalf("AddGroup() error = %v", err)
+	}
+	if err := srv.AddDir("/public/team/drafts"); err != nil {
+		t.Fatalf("AddDir() error = %v", err)
+	}
+	permApi := hidrive.NewPermission(srv.Client(), srv.Endpoint())
+	ctx := context.Background()
+
+	set := func(path, account string, rights hidrive.Rights) {
+		t.Helper()
+		obj, err := permApi.Set(ctx, hidrive.NewParameters().SetPath(path).SetAccount(account).SetRights(rights).Values)
+		if err != nil {
+			t.Fatalf("Set(%s, %s, %s) error = %v", path, account, rights, err)
+		}
+		if obj.Account != account || obj.Rights() != rights {
+			t.Errorf("Set(%s, %s, %s) = %+v", path, account, rights, obj)
+		}
+	}
+	set("/public/team", "sales", hidrive.RightsRead)
+	set("/public/team", "alice", hidrive.RightsReadWrite)
+	set("/public/team/drafts", "sales", hidrive.RightsNone)
+
+	tests := []struct {
+		name    string
+		params  *hidrive.Parameters
+		want    hidrive.Rights
+		wantErr error
+	}{
+		{
+			name:   "current user",
+			params: hidrive.NewParameters().SetPath("/public/team"),
+			want:   hidrive.RightsReadWrite,
+		},
+		{
+			name:   "group",
+			params: hidrive.NewParameters().SetPath("/public/team").SetAccount("sales"),
+			want:   hidrive.RightsRead,
+		},
+		{
+			name:   "inherited from group",
+			params: hidrive.NewParameters().SetPath("/public/team").SetAccount("bob"),
+			want:   hidrive.RightsRead,
+		},
+		{
+			name:   "user overrides group",
+			params: hidrive.NewParameters().SetPath("/public/team").SetAccount("alice"),
+			want:   hidrive.RightsReadWrite,
+		},
+		{
+			name:   "overridden deeper in the tree",
+			params: hidrive.NewParameters().SetPath("/public/team/drafts").SetAccount("bob"),
+			want:   hidrive.RightsNone,
+		},
+		{
+			name:   "home directory",
+			params: hidrive.NewParameters().SetPath("/users/bob").SetAccount("bob"),
+			want:   hidrive.RightsReadWrite,
+		},
+		{
+			name:   "no rights",
+			params: hidrive.NewParameters().SetPath("/users/alice").SetAccount("bob"),
+			want:   hidrive.RightsNone,
+		},
+		{
+			name:    "unknown account",
+			params:  hidrive.NewParameters().SetPath("/public/team").SetAccount("mallory"),
+			wantErr: hidrive.ErrNotFound,
+		},
+		{
+			name:    "missing object",
+			params:  hidrive.NewParameters().SetPath("/public/missing").SetAccount("bob"),
+			wantErr: hidrive.ErrNotFound,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			obj, err := permApi.Get(ctx, tt.params.Values)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Get() error = %v, want %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if got := obj.Rights(); got != tt.want {
+				t.Errorf("Get() rights = %s, want %s", got, tt.want)
+			}
+			if obj.ID == "" || obj.Path != tt.params.Get("path") {
+				t.Errorf("Get() = %+v", obj)
+			}
+		})
+	}
+
+	t.Run("write requires read", func(t *testing.T) {
+		params := hidrive.NewParameters().SetPath("/public/team").SetAccount("bob").SetReadable(false).SetWritable(true)
+		if _, err := permApi.Set(ctx, params.Values); !errors.Is(err, hidrive.ErrBadRequest) {
+			t.Errorf("Set() error = %v, want %v", err, hidrive.ErrBadRequest)
+		}
+	})
+}
@@ -0,0 +1,294 @@
+package go_hidrive
+
+import (
+	"container/list"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/url"
+	"sync"
+)
+
+const DefaultCacheBlocks = 16 // Default number of blocks cached by [RemoteFile] when caching is enabled
+
+/*
+RemoteFileOptions - options for [File.Open].
+
+Property `BlockSize` enables the block cache: data is requested from HiDrive in blocks of `BlockSize` bytes aligned
+to the block size and up to `CacheBlocks` most recently used blocks (defaults to [DefaultCacheBlocks]) are kept
+in memory. When `BlockSize` is 0, every read is served by a separate range request of exactly the size requested.
+
+Property `ReadAhead` defines the number of blocks following the requested one which are fetched within the same
+request on a cache miss, it is useful for sequential reads. It has no effect when the cache is disabled.
+*/
+type RemoteFileOptions struct {
+	BlockSize   int64
+	CacheBlocks int
+	ReadAhead   int
+}
+
+/*
+RemoteFile - read-only handle of a file stored on HiDrive which implements io.ReadSeekCloser and io.ReaderAt
+using range requests, so only the parts of the file actually read are transferred.
+
+It can be passed to consumers requiring random access, e.g. archive/zip.NewReader:
+
+	rf, err := fileApi.Open(ctx, hidrive.NewParameters().SetPath("/public/archive.zip").Values, nil)
+	if err != nil {
+		return err
+	}
+	defer rf.Close()
+	zr, err := zip.NewReader(rf, rf.Size())
+
+ReadAt is safe for concurrent use, Read and Seek share the file offset and must not be called concurrently.
+If the file is modified on HiDrive while being read, reads fail with an error wrapping [ErrSizeMismatch] when
+the change of size is detected.
+*/
+type RemoteFile struct {
+	ctx    context.Context
+	file   File
+	obj    *Object
+	params url.Values // parameters addressing the file in range requests
+	opts   RemoteFileOptions
+	offset int64
+
+	mu     sync.Mutex
+	closed bool
+	blocks map[int64]*list.Element // block index -> element of lru
+	lru    *list.List              // of *cacheBlock, most recently used first
+}
+
+// cacheBlock - single cached block of a remote file.
+type cacheBlock struct {
+	index int64
+	data  []byte
+}
+
+/*
+Open - open a file on HiDrive for reading with random access, see [RemoteFile].
+
+The file is looked up with [Meta.Get] and then addressed by its id, so it can still be read if renamed.
+Fields required for reading are added to `fields` parameter if it is given.
+The context is used for all requests made by the returned handle.
+
+Supported parameters:
+  - path ([Parameters.SetPath])
+  - pid ([Parameters.SetPid])
+
+Returns [RemoteFile] which must be closed by the caller.
+*/
+func (f File) Open(ctx context.Context, params url.Values, opts *RemoteFileOptions) (*RemoteFile, error) {
+	obj, err := f.stat(ctx, params)
+	if err != nil {
+		return nil, err
+	}
+	return f.openRemote(ctx, obj, NewParameters().SetPid(obj.ID).Values, opts), nil
+}
+
+// openRemote creates [RemoteFile] for the object addressed by `params` in range requests.
+func (f File) openRemote(ctx context.Context, obj *Object, params url.Values, opts *RemoteFileOptions) *RemoteFile {
+	rf := &RemoteFile{ctx: ctx, file: f, obj: obj, params: params}
+	if opts != nil {
+		rf.opts = *opts
+	}
+	if rf.opts.BlockSize > 0 {
+		if rf.opts.CacheBlocks <= 0 {
+			rf.opts.CacheBlocks = DefaultCacheBlocks
+		}
+		if rf.opts.ReadAhead < 0 {
+			rf.opts.ReadAhead = 0
+		}
+		rf.blocks = make(map[int64]*list.Element)
+		rf.lru = list.New()
+	}
+	return rf
+}
+
+// Size returns the size of the file at the time it was opened.
+func (rf *RemoteFile) Size() int64 {
+	return rf.obj.Size
+}
+
+// Stat returns [Object] with information about the file at the time it was opened.
+func (rf *RemoteFile) Stat() *Object {
+	return rf.obj
+}
+
+// Read reads up to len(p) bytes from the current offset and advances it.
+func (rf *RemoteFile) Read(p []byte) (int, error) {
+	n, err := rf.ReadAt(p, rf.offset)
+	rf.offset += int64(n)
+	if errors.Is(err, io.EOF) && n > 0 {
+		err = nil
+	}
+	return n, err
+}
+
+// Seek sets the offset for the next Read according to whence (io.SeekStart, io.SeekCurrent or io.SeekEnd).
+func (rf *RemoteFile) Seek(offset int64, whence int) (int64, error) {
+	if rf.isClosed() {
+		return 0, fs.ErrClosed
+	}
+
+	switch whence {
+	case io.SeekStart:
+	case io.SeekCurrent:
+		offset += rf.offset
+	case io.SeekEnd:
+		offset += rf.obj.Size
+	default:
+		return 0, fmt.Errorf("seek: invalid whence %d", whence)
+	}
+	if offset < 0 {
+		return 0, fmt.Errorf("seek: negative position %d", offset)
+	}
+	rf.offset = offset
+	return offset, nil
+}
+
+// ReadAt reads len(p) bytes starting at offset `off`, it returns io.EOF if fewer bytes are available.
+func (rf *RemoteFile) ReadAt(p []byte, off int64) (int, error) {
+	if rf.isClosed() {
+		return 0, fs.ErrClosed
+	}
+	if off < 0 {
+		return 0, fmt.Errorf("read at negative offset %d", off)
+	}
+	if off >= rf.obj.Size {
+		return 0, io.EOF
+	}
+
+	want := len(p)
+	if rest := rf.obj.Size - off; int64(want) > rest {
+		want = int(rest)
+	}
+
+	var (
+		n   int
+		err error
+	)
+	// caching is decided by the options which never change, the cache itself is guarded by mu
+	if rf.opts.BlockSize <= 0 {
+		n, err = rf.fetch(p[:want], off)
+	} else {
+		for n < want && err == nil {
+			var blk []byte
+			idx := (off + int64(n)) / rf.opts.BlockSize
+			if blk, err = rf.block(idx); err == nil {
+				n += copy(p[n:want], blk[off+int64(n)-idx*rf.opts.BlockSize:])
+			}
+		}
+	}
+	if err == nil && n < len(p) {
+		err = io.EOF
+	}
+	return n, err
+}
+
+// Close releases cached data, subsequent reads fail.
+func (rf *RemoteFile) Close() error {
+	rf.mu.Lock()
+	defer rf.mu.Unlock()
+
+	if rf.closed {
+		return fs.ErrClosed
+	}
+	rf.closed = true
+	rf.blocks, rf.lru = nil, nil
+	return nil
+}
+
+func (rf *RemoteFile) isClosed() bool {
+	rf.mu.Lock()
+	defer rf.mu.Unlock()
+	return rf.closed
+}
+
+// block returns the block with the given index from the cache or fetches it together with read-ahead blocks.
+func (rf *RemoteFile) block(idx int64) ([]byte, error) {
+	bs := rf.opts.BlockSize
+	lastIdx := (rf.obj.Size - 1) / bs
+
+	rf.mu.Lock()
+	if rf.closed {
+		rf.mu.Unlock()
+		return nil, fs.ErrClosed
+	}
+	if el, ok := rf.blocks[idx]; ok {
+		rf.lru.MoveToFront(el)
+		rf.mu.Unlock()
+		return el.Value.(*cacheBlock).data, nil
+	}
+	count := int64(1)
+	for ; count <= int64(rf.opts.ReadAhead) && idx+count <= lastIdx; count++ {
+		if _, ok := rf.blocks[idx+count]; ok {
+			break
+		}
+	}
+	rf.mu.Unlock()
+
+	start := idx * bs
+	end := (idx + count) * bs
+	if end > rf.obj.Size {
+		end = rf.obj.Size
+	}
+	buf := make([]byte, end-start)
+	if _, err := rf.fetch(buf, start); err != nil {
+		return nil, err
+	}
+
+	rf.mu.Lock()
+	defer rf.mu.Unlock()
+	if rf.closed {
+		return nil, fs.ErrClosed
+	}
+	for i := count - 1; i >= 0; i-- {
+		data := buf[i*bs:]
+		if int64(len(data)) > bs {
+			data = data[:bs:bs]
+		}
+		rf.put(idx+i, data)
+	}
+	if end-start > bs {
+		return buf[:bs:bs], nil
+	}
+	return buf, nil
+}
+
+// put adds the block to the cache evicting the least recently used blocks if needed, must be called with mu held.
+func (rf *RemoteFile) put(idx int64, data []byte) {
+	if el, ok := rf.blocks[idx]; ok {
+		el.Value.(*cacheBlock).data = data
+		rf.lru.MoveToFront(el)
+		return
+	}
+	rf.blocks[idx] = rf.lru.PushFront(&cacheBlock{index: idx, data: data})
+	for rf.lru.Len() > rf.opts.CacheBlocks {
+		oldest := rf.lru.Back()
+		rf.lru.Remove(oldest)
+		delete(rf.blocks, oldest.Value.(*cacheBlock).index)
+	}
+}
+
+// fetch reads exactly len(p) bytes at offset `off` with a single range request.
+func (rf *RemoteFile) fetch(p []byte, off int64) (int, error) {
+	if len(p) == 0 {
+		return 0, nil
+	}
+
+	res, err := rf.file.Download(rf.ctx, rf.params, &DownloadOptions{Offset: off, Length: int64(len(p))})
+	if err != nil {
+		return 0, err
+	}
+	defer res.Body.Close()
+
+	if res.ContentRange == nil {
+		return 0, fmt.Errorf("range request for %d-%d was ignored", off, off+int64(len(p))-1)
+	}
+	if res.ContentRange.Total != rf.obj.Size {
+		return 0, fmt.Errorf("%w: file size changed from %d to %d", ErrSizeMismatch, rf.obj.Size, res.ContentRange.Total)
+	}
+	return io.ReadFull(res.Body, p)
+}
@@ -0,0 +1,214 @@
+package go_hidrive_test
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"io/fs"
+	"net/http"
+	"testing"
+	"time"
+
+	hidrive "github.com/Burmuley/go-hidrive"
+	"github.com/Burmuley/go-hidrive/hidrivetest"
+)
+
+func buildZip(t *testing.T, files map[string][]byte) []byte {
+	t.Helper()
+	buf := &bytes.Buffer{}
+	zw := zip.NewWriter(buf)
+	for name, content := range files {
+		w, err := zw.Create(name)
+		if err != nil {
+			t.Fatalf("zip Create() error = %v", err)
+		}
+		if _, err := w.Write(content); err != nil {
+			t.Fatalf("zip Write() error = %v", err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatalf("zip Close() error = %v", err)
+	}
+	return buf.Bytes()
+}
+
+func TestFile_Open(t *testing.T) {
+	files := map[string][]byte{
+		"a.txt":     []byte("hello, world"),
+		"dir/b.bin": randomBytes(20000),
+		"c.bin":     randomBytes(5000),
+	}
+
+	tests := []struct {
+		name string
+		opts *hidrive.RemoteFileOptions
+	}{
+		{
+			name: "without cache",
+		},
+		{
+			name: "with cache",
+			opts: &hidrive.RemoteFileOptions{BlockSize: 1024, CacheBlocks: 4},
+		},
+		{
+			name: "with cache and read-ahead",
+			opts: &hidrive.RemoteFileOptions{BlockSize: 1024, CacheBlocks: 64, ReadAhead: 8},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := hidrivetest.NewServer()
+			defer srv.Close()
+			data := buildZip(t, files)
+			if err := srv.AddFile("/public/archive.zip", data, time.Now()); err != nil {
+				t.Fatalf("AddFile() error = %v", err)
+			}
+			fileApi := hidrive.NewFile(srv.Client(), srv.Endpoint())
+
+			rf, err := fileApi.Open(context.Background(), hidrive.NewParameters().SetPath("/public/archive.zip").Values, tt.opts)
+			if err != nil {
+				t.Fatalf("Open() error = %v", err)
+			}
+			defer rf.Close()
+
+			zr, err := zip.NewReader(rf, rf.Size())
+			if err != nil {
+				t.Fatalf("zip.NewReader() error = %v", err)
+			}
+			if len(zr.File) != len(files) {
+				t.Fatalf("zip.NewReader() files = %d, want %d", len(zr.File), len(files))
+			}
+			for _, zf := range zr.File {
+				r, err := zf.Open()
+				if err != nil {
+					t.Fatalf("Open(%s) error = %v", zf.Name, err)
+				}
+				got, err := io.ReadAll(r)
+				r.Close()
+				if err != nil || !bytes.Equal(got, files[zf.Name]) {
+					t.Errorf("ReadAll(%s) content differs, error = %v", zf.Name, err)
+				}
+			}
+
+			want := bytes.NewReader(data)
+			for _, seek := range []struct {
+				offset int64
+				whence int
+			}{{100, io.SeekStart}, {-10, io.SeekEnd}, {-3000, io.SeekCurrent}, {0, io.SeekStart}} {
+				pos, err := rf.Seek(seek.offset, seek.whence)
+				wantPos, _ := want.Seek(seek.offset, seek.whence)
+				if err != nil || pos != wantPos {
+					t.Fatalf("Seek(%d, %d) = %d, %v, want %d", seek.offset, seek.whence, pos, err, wantPos)
+				}
+				got, wantData := make([]byte, 2500), make([]byte, 2500)
+				n, _ := io.ReadFull(rf, got)
+				wn, _ := io.ReadFull(want, wantData)
+				if n != wn || !bytes.Equal(got[:n], wantData[:wn]) {
+					t.Errorf("Read() after Seek(%d, %d) = %d bytes, want %d", seek.offset, seek.whence, n, wn)
+				}
+			}
+
+			if n, err := rf.ReadAt(make([]byte, 10), rf.Size()-4); n != 4 || !errors.Is(err, io.EOF) {
+				t.Errorf("ReadAt() at the end = %d, %v, want 4, EOF", n, err)
+			}
+			if err := rf.Close(); err != nil {
+				t.Errorf("Close() error = %v", err)
+			}
+			if _, err := rf.Read(make([]byte, 1)); !errors.Is(err, fs.ErrClosed) {
+				t.Errorf("Read() after Close() error = %v, want %v", err, fs.ErrClosed)
+			}
+		})
+	}
+}
+
+func TestRemoteFile_ReadAhead(t *testing.T) {
+	srv := hidrivetest.NewServer()
+	defer srv.Close()
+	data := randomBytes(10000)
+	if err := srv.AddFile("/public/data.bin", data, time.Now()); err != nil {
+		t.Fatalf("AddFile() error = %v", err)
+	}
+	transport := &countingTransport{base: srv.Client().Transport}
+	fileApi := hidrive.NewFile(&http.Client{Transport: transport}, srv.Endpoint())
+
+	rf, err := fileApi.Open(context.Background(), hidrive.NewParameters().SetPath("/public/data.bin").Values,
+		&hidrive.RemoteFileOptions{BlockSize: 1000, ReadAhead: 4})
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	defer rf.Close()
+
+	got, err := io.ReadAll(rf)
+	if err != nil || !bytes.Equal(got, data) {
+		t.Fatalf("ReadAll() content differs, error = %v", err)
+	}
+	// one request for metadata and one per 5 blocks
+	if transport.count != 3 {
+		t.Errorf("ReadAll() made %d requests, want 3", transport.count)
+	}
+
+	transport.count = 0
+	if _, err := rf.ReadAt(make([]byte, 100), 9000); err != nil {
+		t.Errorf("ReadAt() error = %v", err)
+	}
+	if transport.count != 0 {
+		t.Errorf("ReadAt() of a cached block made %d requests, want 0", transport.count)
+	}
+}
+
+func TestRemoteFile_CloseDuringReadAt(t *testing.T) {
+	srv := hidrivetest.NewServer()
+	defer srv.Close()
+	if err := srv.AddFile("/public/data.bin", randomBytes(10000), time.Now()); err != nil {
+		t.Fatalf("AddFile() error = %v", err)
+	}
+	fileApi := hidrive.NewFile(srv.Client(), srv.Endpoint())
+
+	rf, err := fileApi.Open(context.Background(), hidrive.NewParameters().SetPath("/public/data.bin").Values,
+		&hidrive.RemoteFileOptions{BlockSize: 1000})
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+
+	done := make(chan error)
+	go func() {
+		var err error
+		for off := int64(0); err == nil; off = (off + 1000) % 10000 {
+			_, err = rf.ReadAt(make([]byte, 100), off)
+		}
+		done <- err
+	}()
+	time.Sleep(10 * time.Millisecond)
+	if err := rf.Close(); err != nil {
+		t.Errorf("Close() error = %v", err)
+	}
+	if err := <-done; !errors.Is(err, fs.ErrClosed) {
+		t.Errorf("ReadAt() after Close() error = %v, want %v", err, fs.ErrClosed)
+	}
+}
+
+func TestFile_Open_Fields(t *testing.T) {
+	srv := hidrivetest.NewServer()
+	defer srv.Close()
+	data := randomBytes(3000)
+	if err := srv.AddFile("/public/data.bin", data, time.Now()); err != nil {
+		t.Fatalf("AddFile() error = %v", err)
+	}
+	fileApi := hidrive.NewFile(srv.Client(), srv.Endpoint())
+
+	params := hidrive.NewParameters().SetPath("/public/data.bin").SetFields([]string{"name"})
+	rf, err := fileApi.Open(context.Background(), params.Values, nil)
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	defer rf.Close()
+
+	if rf.Size() != 3000 || rf.Stat().Name != "data.bin" {
+		t.Errorf("Open() size = %d, name = %q", rf.Size(), rf.Stat().Name)
+	}
+	if got, err := io.ReadAll(rf); err != nil || !bytes.Equal(got, data) {
+		t.Errorf("ReadAll() content differs, error = %v", err)
+	}
+}
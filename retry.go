@@ -0,0 +1,275 @@
+package go_hidrive
+
+import (
+	"context"
+	"errors"
+	"io"
+	"math/rand"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"syscall"
+	"time"
+)
+
+/*
+RetryPolicy - defines how [Api] retries failed requests.
+
+A request is retried when the HTTP client returns a transient network error (connection reset, refused or
+timed out, unexpected EOF) or when the response status code is listed in `StatusCodes`.
+The delay between attempts grows exponentially starting from `MinBackoff` and is capped by `MaxBackoff`,
+a random jitter is applied to every delay. If the response contains `Retry-After` header, its value is used
+as the delay instead. When `Retry-After` asks for a longer delay than `MaxBackoff`, the request is not retried
+and the error is returned right away.
+
+Idempotent requests (GET, PUT, DELETE) are retried on every retryable condition.
+Non-idempotent requests (POST, PATCH) are only retried on 429 and 503 status codes, which mean the request
+was rejected before processing, unless `RetryNonIdempotent` is set to true.
+
+Requests with a body (e.g. [File.Upload]) are only retried when the body can be replayed, i.e. it implements
+io.ReaderAt and io.Seeker (like os.File does). Every attempt reads the body with its own reader, the same way
+http.Request.GetBody does, so an attempt never shares the read position with the previous one. Other bodies are
+sent only once. An upload with a replayable body and `on_exist=overwrite` ([Parameters.SetOnExist]) is retried
+like an idempotent request, as repeating it leaves the same file. Uploads with other `on_exist` values are not:
+with `autoname`, a retry of an attempt whose response was lost would store a second copy under another name.
+
+Use [NewRetryPolicy] to create a policy with sane defaults.
+*/
+type RetryPolicy struct {
+	MaxAttempts        int           // total number of attempts including the first one
+	MinBackoff         time.Duration // delay before the first retry
+	MaxBackoff         time.Duration // maximum delay between attempts
+	StatusCodes        []int         // response status codes considered as transient
+	RetryNonIdempotent bool          // retry POST and PATCH requests on any retryable condition
+}
+
+/*
+NewRetryPolicy - create new instance of [RetryPolicy] with default values.
+
+Defaults are: 4 attempts, backoff between 500ms and 30s, status codes 429, 500, 502, 503 and 504 are retried.
+*/
+func NewRetryPolicy() *RetryPolicy {
+	return &RetryPolicy{
+		MaxAttempts: 4,
+		MinBackoff:  500 * time.Millisecond,
+		MaxBackoff:  30 * time.Second,
+		StatusCodes: []int{
+			http.StatusTooManyRequests,
+			http.StatusInternalServerError,
+			http.StatusBadGateway,
+			http.StatusServiceUnavailable,
+			http.StatusGatewayTimeout,
+		},
+	}
+}
+
+// attempts returns the number of attempts allowed by the policy, nil policy means a single attempt.
+func (p *RetryPolicy) attempts() int {
+	if p == nil || p.MaxAttempts < 1 {
+		return 1
+	}
+	return p.MaxAttempts
+}
+
+/*
+shouldRetryStatus reports whether a response with the given status code can be retried,
+`idempotent` tells whether the request can be repeated, see isRepeatable.
+*/
+func (p *RetryPolicy) shouldRetryStatus(idempotent bool, code int) bool {
+	if !isItemInSlice(p.StatusCodes, code) {
+		return false
+	}
+	if idempotent || p.RetryNonIdempotent {
+		return true
+	}
+	return code == http.StatusTooManyRequests || code == http.StatusServiceUnavailable
+}
+
+// shouldRetryError reports whether the error returned by the HTTP client can be retried, see shouldRetryStatus.
+func (p *RetryPolicy) shouldRetryError(idempotent bool, err error) bool {
+	if !idempotent && !p.RetryNonIdempotent {
+		return false
+	}
+	return isTransientNetError(err)
+}
+
+// backoff returns the delay before the given retry (starting from 1), `retryAfter` takes precedence if set.
+func (p *RetryPolicy) backoff(retry int, retryAfter time.Duration) time.Duration {
+	if retryAfter > 0 {
+		return retryAfter
+	}
+
+	delay := p.MinBackoff
+	for i := 1; i < retry && delay < p.MaxBackoff; i++ {
+		delay *= 2
+	}
+	if p.MaxBackoff > 0 && delay > p.MaxBackoff {
+		delay = p.MaxBackoff
+	}
+	if delay <= 0 {
+		return 0
+	}
+
+	// "equal jitter": keep half of the delay and randomize the other half
+	half := delay / 2
+	return half + time.Duration(rand.Int63n(int64(delay-half)+1))
+}
+
+// exceedsMaxBackoff reports whether the delay requested by `Retry-After` header is longer than the policy allows.
+func (p *RetryPolicy) exceedsMaxBackoff(retryAfter time.Duration) bool {
+	return p.MaxBackoff > 0 && retryAfter > p.MaxBackoff
+}
+
+// isIdempotent reports whether HTTP method is idempotent by its definition.
+func isIdempotent(method string) bool {
+	return isItemInSlice([]string{http.MethodGet, http.MethodHead, http.MethodPut, http.MethodDelete}, method)
+}
+
+/*
+isRepeatable reports whether the request can be retried on every retryable condition: either the method is
+idempotent, or the request has a replayable body and `on_exist=overwrite`, so a repeated upload leaves the same file.
+*/
+func isRepeatable(method string, params url.Values, replayable bool) bool {
+	return isIdempotent(method) || replayable && params.Get("on_exist") == "overwrite"
+}
+
+// isTransientNetError reports whether the error is a network failure which makes sense to retry.
+func isTransientNetError(err error) bool {
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+
+	if errors.Is(err, syscall.ECONNRESET) || errors.Is(err, syscall.ECONNREFUSED) ||
+		errors.Is(err, syscall.EPIPE) || errors.Is(err, io.ErrUnexpectedEOF) || errors.Is(err, io.EOF) {
+		return true
+	}
+
+	var netErr net.Error
+	return errors.As(err, &netErr) && netErr.Timeout()
+}
+
+// parseRetryAfter parses `Retry-After` header value which can be either delay in seconds or HTTP date.
+func parseRetryAfter(value string, now time.Time) time.Duration {
+	if value == "" {
+		return 0
+	}
+	if secs, err := strconv.Atoi(value); err == nil {
+		if secs < 0 {
+			return 0
+		}
+		return time.Duration(secs) * time.Second
+	}
+	if t, err := http.ParseTime(value); err == nil && t.After(now) {
+		return t.Sub(now)
+	}
+	return 0
+}
+
+// sleepContext waits for the given duration or until the context is done.
+func sleepContext(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+/*
+replayableBody - wraps request body which can be sent multiple times.
+
+The original body is never read by HTTP client directly, instead every attempt gets a new reader of the same section
+of the body (see reader), so a previous attempt which is still being sent can not move the read position of the next
+one. The original body is closed by [Api] once all attempts are done.
+*/
+type replayableBody struct {
+	body  io.ReaderAt
+	start int64
+	size  int64
+}
+
+// newReplayableBody returns replayableBody for the given body or nil if the body can not be replayed.
+func newReplayableBody(body io.ReadCloser) *replayableBody {
+	ra, ok := body.(io.ReaderAt)
+	if !ok {
+		return nil
+	}
+	seeker, ok := body.(io.Seeker)
+	if !ok {
+		return nil
+	}
+
+	start, err := seeker.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return nil
+	}
+	end, err := seeker.Seek(0, io.SeekEnd)
+	if err != nil {
+		return nil
+	}
+	if _, err := seeker.Seek(start, io.SeekStart); err != nil {
+		return nil
+	}
+
+	return &replayableBody{body: ra, start: start, size: end - start}
+}
+
+// reader returns a new reader to be used as request body for the next attempt.
+func (r *replayableBody) reader() io.ReadCloser {
+	return io.NopCloser(io.NewSectionReader(r.body, r.start, r.size))
+}
+
+/*
+retryAttempts calls `call` up to `attempts` times while it fails with a transient error, see isTransientError.
+
+It is used for operations retried as a whole (a chunk of an upload, a part of a download): `call` gets a copy of
+the [Api] with retries disabled, so every attempt is a single request and the number of requests is bounded by
+`attempts` rather than multiplied by [RetryPolicy.MaxAttempts]. The policy (or [NewRetryPolicy] defaults if it is
+nil) still defines the backoff between the attempts, including `Retry-After` of the failed response and giving up
+when it exceeds `MaxBackoff`.
+*/
+func (a Api) retryAttempts(ctx context.Context, attempts int, call func(single Api) error) error {
+	policy := a.RetryPolicy
+	if policy == nil {
+		policy = NewRetryPolicy()
+	}
+	single := a
+	single.RetryPolicy = nil
+
+	for attempt := 1; ; attempt++ {
+		err := call(single)
+		if err == nil || attempt >= attempts || !isTransientError(err) {
+			return err
+		}
+
+		var retryAfter time.Duration
+		var hdErr *Error
+		if errors.As(err, &hdErr) {
+			retryAfter = parseRetryAfter(hdErr.Header.Get("Retry-After"), time.Now())
+			if policy.exceedsMaxBackoff(retryAfter) {
+				a.logf("hidrive: %s %s: not retrying, Retry-After %v exceeds maximum backoff", hdErr.Method, hdErr.URI, retryAfter)
+				return err
+			}
+		}
+		if err := sleepContext(ctx, policy.backoff(attempt, retryAfter)); err != nil {
+			return err
+		}
+	}
+}
+
+// isTransientError reports whether the error returned by API call is temporary and the call can be repeated.
+func isTransientError(err error) bool {
+	var hdErr *Error
+	if errors.As(err, &hdErr) {
+		return hdErr.StatusCode == http.StatusTooManyRequests || hdErr.StatusCode >= http.StatusInternalServerError &&
+			hdErr.StatusCode != http.StatusInsufficientStorage
+	}
+	return isTransientNetError(err)
+}
@@ -0,0 +1,326 @@
+package go_hidrive
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func newTestRetryPolicy(attempts int) *RetryPolicy {
+	policy := NewRetryPolicy()
+	policy.MaxAttempts = attempts
+	policy.MinBackoff = time.Millisecond
+	policy.MaxBackoff = 5 * time.Millisecond
+	return policy
+}
+
+func TestApi_doHTTPRequest_Retry(t *testing.T) {
+	type args struct {
+		method     string
+		params     url.Values
+		codes      []int
+		policy     *RetryPolicy
+		body       func(t *testing.T) io.ReadCloser
+		retryAfter string
+	}
+
+	seekableBody := func(t *testing.T) io.ReadCloser {
+		name := filepath.Join(t.TempDir(), "body")
+		if err := os.WriteFile(name, []byte("file contents"), 0o600); err != nil {
+			t.Fatalf("error writing body file: %s", err)
+		}
+		f, err := os.Open(name)
+		if err != nil {
+			t.Fatalf("error opening body file: %s", err)
+		}
+		return f
+	}
+	pipeBody := func(t *testing.T) io.ReadCloser {
+		r, w := io.Pipe()
+		go func() {
+			_, _ = w.Write([]byte("file contents"))
+			_ = w.Close()
+		}()
+		return r
+	}
+
+	tests := []struct {
+		name         string
+		args         args
+		wantAttempts int32
+		wantErr      bool
+	}{
+		{
+			name:         "no policy makes a single attempt",
+			args:         args{method: http.MethodGet, codes: []int{503, 200}},
+			wantAttempts: 1,
+			wantErr:      true,
+		},
+		{
+			name:         "GET is retried until success",
+			args:         args{method: http.MethodGet, codes: []int{500, 502, 200}, policy: newTestRetryPolicy(4)},
+			wantAttempts: 3,
+			wantErr:      false,
+		},
+		{
+			name:         "GET gives up after max attempts",
+			args:         args{method: http.MethodGet, codes: []int{503, 503, 503, 200}, policy: newTestRetryPolicy(3)},
+			wantAttempts: 3,
+			wantErr:      true,
+		},
+		{
+			name:         "non-retryable status is returned immediately",
+			args:         args{method: http.MethodGet, codes: []int{404, 200}, policy: newTestRetryPolicy(4)},
+			wantAttempts: 1,
+			wantErr:      true,
+		},
+		{
+			name:         "POST is not retried on 500",
+			args:         args{method: http.MethodPost, codes: []int{500, 201}, policy: newTestRetryPolicy(4)},
+			wantAttempts: 1,
+			wantErr:      true,
+		},
+		{
+			name:         "POST is retried on 429",
+			args:         args{method: http.MethodPost, codes: []int{429, 201}, policy: newTestRetryPolicy(4)},
+			wantAttempts: 2,
+			wantErr:      false,
+		},
+		{
+			name:         "POST with seekable body is retried",
+			args:         args{method: http.MethodPost, codes: []int{503, 201}, policy: newTestRetryPolicy(4), body: seekableBody},
+			wantAttempts: 2,
+			wantErr:      false,
+		},
+		{
+			name:         "POST with non-seekable body is sent once",
+			args:         args{method: http.MethodPost, codes: []int{503, 201}, policy: newTestRetryPolicy(4), body: pipeBody},
+			wantAttempts: 1,
+			wantErr:      true,
+		},
+		{
+			name:         "Retry-After longer than max backoff is not waited for",
+			args:         args{method: http.MethodGet, codes: []int{503, 200}, policy: newTestRetryPolicy(4), retryAfter: "3600"},
+			wantAttempts: 1,
+			wantErr:      true,
+		},
+		{
+			name:         "POST with seekable body is not retried on 500",
+			args:         args{method: http.MethodPost, codes: []int{500, 201}, policy: newTestRetryPolicy(4), body: seekableBody},
+			wantAttempts: 1,
+			wantErr:      true,
+		},
+		{
+			name: "upload with seekable body and on_exist=overwrite is retried on 500",
+			args: args{
+				method: http.MethodPost, params: NewParameters().SetOnExist("overwrite").Values,
+				codes: []int{500, 502, 201}, policy: newTestRetryPolicy(4), body: seekableBody,
+			},
+			wantAttempts: 3,
+		},
+		{
+			name: "upload with seekable body and on_exist=autoname is not retried on 500",
+			args: args{
+				method: http.MethodPost, params: NewParameters().SetOnExist("autoname").Values,
+				codes: []int{500, 201}, policy: newTestRetryPolicy(4), body: seekableBody,
+			},
+			wantAttempts: 1,
+			wantErr:      true,
+		},
+		{
+			name: "upload with non-seekable body and on_exist=overwrite is sent once",
+			args: args{
+				method: http.MethodPost, params: NewParameters().SetOnExist("overwrite").Values,
+				codes: []int{500, 201}, policy: newTestRetryPolicy(4), body: pipeBody,
+			},
+			wantAttempts: 1,
+			wantErr:      true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var attempts int32
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				n := atomic.AddInt32(&attempts, 1)
+				if body, _ := io.ReadAll(r.Body); tt.args.body != nil && string(body) != "file contents" {
+					t.Errorf("attempt %d: unexpected body %q", n, string(body))
+				}
+				w.Header().Set("Content-Type", "application/json")
+				if tt.args.retryAfter != "" {
+					w.Header().Set("Retry-After", tt.args.retryAfter)
+				}
+				w.WriteHeader(tt.args.codes[n-1])
+				_, _ = w.Write([]byte(`{"code":"0","msg":"test"}`))
+			}))
+			defer srv.Close()
+
+			api := NewApi(srv.Client(), srv.URL)
+			api.RetryPolicy = tt.args.policy
+			var body io.ReadCloser
+			if tt.args.body != nil {
+				body = tt.args.body(t)
+			}
+
+			okCode := tt.args.codes[len(tt.args.codes)-1]
+			res, err := api.doHTTPRequest(context.Background(), tt.args.method, "test", tt.args.params, []int{okCode}, body)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("doHTTPRequest() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if res != nil {
+				res.Body.Close()
+			}
+			if got := atomic.LoadInt32(&attempts); got != tt.wantAttempts {
+				t.Errorf("doHTTPRequest() attempts = %d, want %d", got, tt.wantAttempts)
+			}
+		})
+	}
+}
+
+func TestReplayableBody(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "body")
+	if err := os.WriteFile(name, []byte("skip file contents"), 0o600); err != nil {
+		t.Fatalf("error writing body file: %s", err)
+	}
+	body, err := os.Open(name)
+	if err != nil {
+		t.Fatalf("error opening body file: %s", err)
+	}
+	defer body.Close()
+	if _, err := body.Seek(5, io.SeekStart); err != nil {
+		t.Fatalf("Seek() error = %v", err)
+	}
+	replay := newReplayableBody(body)
+	if replay == nil {
+		t.Fatalf("newReplayableBody() = nil")
+	}
+
+	// a reader of a previous attempt does not move the position of the next one
+	first := replay.reader()
+	if _, err := io.ReadFull(first, make([]byte, 4)); err != nil {
+		t.Fatalf("Read() error = %v", err)
+	}
+	if got, err := io.ReadAll(replay.reader()); err != nil || string(got) != "file contents" {
+		t.Errorf("reader() = %q, %v, want %q", got, err, "file contents")
+	}
+	if got, _ := io.ReadAll(first); string(got) != " contents" {
+		t.Errorf("reader() of the previous attempt = %q, want %q", got, " contents")
+	}
+
+	if replay := newReplayableBody(io.NopCloser(body)); replay != nil {
+		t.Errorf("newReplayableBody() of a body without ReadAt = %+v, want nil", replay)
+	}
+}
+
+func TestApi_retryAttempts(t *testing.T) {
+	tests := []struct {
+		name         string
+		codes        []int
+		retryAfter   string
+		maxBackoff   time.Duration
+		attempts     int
+		wantErr      error
+		wantRequests int32
+		minElapsed   time.Duration
+	}{
+		{name: "success after transient failure", codes: []int{503, 200}, attempts: 3, wantRequests: 2},
+		{name: "attempts are not multiplied by the policy", codes: []int{500, 500, 500, 500, 500}, attempts: 2, wantErr: ErrInternal, wantRequests: 2},
+		{name: "permanent error", codes: []int{404, 200}, attempts: 3, wantErr: ErrNotFound, wantRequests: 1},
+		{name: "Retry-After is waited for", codes: []int{429, 200}, retryAfter: "1", maxBackoff: 2 * time.Second, attempts: 3, wantRequests: 2, minElapsed: time.Second},
+		{name: "Retry-After longer than max backoff", codes: []int{429, 200}, retryAfter: "60", attempts: 3, wantErr: ErrTooManyRequests, wantRequests: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var requests int32
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				n := atomic.AddInt32(&requests, 1)
+				code := tt.codes[int(n)-1]
+				if code != http.StatusOK && tt.retryAfter != "" {
+					w.Header().Set("Retry-After", tt.retryAfter)
+				}
+				w.WriteHeader(code)
+			}))
+			defer srv.Close()
+
+			api := NewApi(srv.Client(), srv.URL)
+			api.RetryPolicy = newTestRetryPolicy(4)
+			if tt.maxBackoff > 0 {
+				api.RetryPolicy.MaxBackoff = tt.maxBackoff
+			}
+			start := time.Now()
+			err := api.retryAttempts(context.Background(), tt.attempts, func(single Api) error {
+				res, err := single.doGET(context.Background(), "file", nil, []int{http.StatusOK})
+				if err == nil {
+					res.Body.Close()
+				}
+				return err
+			})
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("retryAttempts() error = %v, want %v", err, tt.wantErr)
+			}
+			if got := atomic.LoadInt32(&requests); got != tt.wantRequests {
+				t.Errorf("retryAttempts() requests = %d, want %d", got, tt.wantRequests)
+			}
+			if elapsed := time.Since(start); elapsed < tt.minElapsed {
+				t.Errorf("retryAttempts() took %v, want at least %v", elapsed, tt.minElapsed)
+			}
+		})
+	}
+}
+
+func TestRetryPolicy_backoff(t *testing.T) {
+	policy := &RetryPolicy{MinBackoff: 100 * time.Millisecond, MaxBackoff: time.Second}
+
+	tests := []struct {
+		name       string
+		retry      int
+		retryAfter time.Duration
+		min        time.Duration
+		max        time.Duration
+	}{
+		{name: "first retry", retry: 1, min: 50 * time.Millisecond, max: 100 * time.Millisecond},
+		{name: "third retry", retry: 3, min: 200 * time.Millisecond, max: 400 * time.Millisecond},
+		{name: "capped by max backoff", retry: 10, min: 500 * time.Millisecond, max: time.Second},
+		{name: "retry-after takes precedence", retry: 1, retryAfter: 3 * time.Second, min: 3 * time.Second, max: 3 * time.Second},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for i := 0; i < 50; i++ {
+				if got := policy.backoff(tt.retry, tt.retryAfter); got < tt.min || got > tt.max {
+					t.Errorf("backoff() = %v, want between %v and %v", got, tt.min, tt.max)
+					return
+				}
+			}
+		})
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2023, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name  string
+		value string
+		want  time.Duration
+	}{
+		{name: "empty", value: "", want: 0},
+		{name: "seconds", value: "7", want: 7 * time.Second},
+		{name: "http date", value: now.Add(time.Minute).Format(http.TimeFormat), want: time.Minute},
+		{name: "date in the past", value: now.Add(-time.Minute).Format(http.TimeFormat), want: 0},
+		{name: "garbage", value: "soon", want: 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := parseRetryAfter(tt.value, now); got != tt.want {
+				t.Errorf("parseRetryAfter() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,287 @@
+package go_hidrive
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"path"
+	"time"
+)
+
+/*
+Snapshot - structure represents a set of methods for interacting with HiDrive `/snapshot` API endpoint.
+
+Snapshots are read-only copies of the whole HiDrive storage, created automatically by HiDrive on a schedule
+or manually with [Snapshot.Create]. Any object can be read as of a snapshot by adding the snapshot name
+to the request with [Parameters.SetSnapshot] (e.g. [Dir.Get], [Meta.Get], [File.Get]), see also [FS.Snapshot].
+*/
+type Snapshot struct {
+	Api
+}
+
+/*
+NewSnapshot - create new instance of Snapshot.
+
+Accepts http.Client and API endpoint as input parameters.
+If `endpoint` is empty string, then default `StratoHiDriveAPIV21` value is used.
+*/
+func NewSnapshot(client *http.Client, endpoint string) Snapshot {
+	api := NewApi(client, endpoint)
+	return Snapshot{api}
+}
+
+/*
+Get - list all available snapshots, the most recent first.
+
+Status codes:
+  - 200 - OK
+  - 400 - Bad Request (e.g. invalid parameter)
+  - 401 - Unauthorized (no authentication)
+  - 403 - Forbidden
+  - 500 - Internal Error
+
+Supported parameters:
+  - fields ([Parameters.SetFields])
+
+Returns a slice of [SnapshotObject].
+*/
+func (s Snapshot) Get(ctx context.Context, params url.Values) ([]*SnapshotObject, error) {
+	var (
+		res *http.Response
+		err error
+	)
+
+	if res, err = s.doGET(ctx, "snapshot", params, []int{http.StatusOK}); err != nil {
+		return nil, err
+	}
+
+	var snaps []*SnapshotObject
+	if err := s.unmarshalBody(res, &snaps); err != nil {
+		return nil, err
+	}
+
+	return snaps, nil
+}
+
+/*
+Create - create a manual snapshot of the current state of the storage.
+
+Status codes:
+  - 201 - Created
+  - 400 - Bad Request (e.g. invalid parameter)
+  - 401 - Unauthorized (no authentication)
+  - 403 - Forbidden
+  - 409 - Conflict (a snapshot with the same name already exists)
+  - 500 - Internal Error
+  - 507 - Insufficient Storage (no more manual snapshots allowed)
+
+Supported parameters:
+  - name ([Parameters.SetName])
+
+Returns [SnapshotObject] with information about the snapshot created.
+*/
+func (s Snapshot) Create(ctx context.Context, params url.Values) (*SnapshotObject, error) {
+	var (
+		res *http.Response
+		err error
+	)
+
+	if res, err = s.doPOST(ctx, "snapshot", params, []int{http.StatusCreated}, nil); err != nil {
+		return nil, err
+	}
+
+	snap := &SnapshotObject{}
+	if err := s.unmarshalBody(res, snap); err != nil {
+		return nil, err
+	}
+
+	return snap, nil
+}
+
+/*
+Rename - change the name of a manual snapshot.
+
+Status codes:
+  - 200 - OK
+  - 400 - Bad Request (e.g. invalid parameter)
+  - 401 - Unauthorized (no authentication)
+  - 403 - Forbidden
+  - 404 - Not Found (snapshot does not exist)
+  - 409 - Conflict (a snapshot with the same name already exists)
+  - 500 - Internal Error
+
+Supported parameters:
+  - id ([Parameters.SetId])
+  - name ([Parameters.SetName])
+
+Returns [SnapshotObject] with information about the renamed snapshot.
+*/
+func (s Snapshot) Rename(ctx context.Context, params url.Values) (*SnapshotObject, error) {
+	var (
+		res *http.Response
+		err error
+	)
+
+	if res, err = s.doPOST(ctx, "snapshot/rename", params, []int{http.StatusOK}, nil); err != nil {
+		return nil, err
+	}
+
+	snap := &SnapshotObject{}
+	if err := s.unmarshalBody(res, snap); err != nil {
+		return nil, err
+	}
+
+	return snap, nil
+}
+
+/*
+Delete - delete a manual snapshot.
+
+Status codes:
+  - 204 - No Content
+  - 400 - Bad Request (e.g. invalid parameter)
+  - 401 - Unauthorized (no authentication)
+  - 403 - Forbidden
+  - 404 - Not Found (snapshot does not exist)
+  - 500 - Internal Error
+
+Supported parameters:
+  - id ([Parameters.SetId])
+*/
+func (s Snapshot) Delete(ctx context.Context, params url.Values) error {
+	if _, err := s.doDELETE(ctx, "snapshot", params, []int{http.StatusNoContent}); err != nil {
+		return err
+	}
+	return nil
+}
+
+/*
+Restore - copy a file or a directory from a snapshot to a live path.
+
+The parameters `src` and `src_id` identify the object in the snapshot given by `snapshot`, `dst` and `dst_id` identify
+the target path the same way as for [File.Copy]. Files are copied from the snapshot with [File.Copy] and directories
+with [Dir.Copy]. The modification times are restored unless `preserve_mtime` is explicitly set to false.
+
+If the target exists, `on_exist` decides what happens: with `autoname` the object is restored under a new name,
+with `overwrite` files are replaced and directories are merged member by member (restored files replace the existing
+ones, other files are kept), otherwise an error wrapping [ErrConflict] is returned. Merging directories is not atomic,
+on error the target may contain part of the restored files.
+
+Supported parameters:
+  - snapshot ([Parameters.SetSnapshot]) (mandatory)
+  - src ([Parameters.SetSrc])
+  - src_id ([Parameters.SetSrcId])
+  - dst ([Parameters.SetDst]) (mandatory)
+  - dst_id ([Parameters.SetDstId])
+  - on_exist ([Parameters.SetOnExist]) (possible values: `autoname`, `overwrite`)
+  - preserve_mtime ([Parameters.SetPreserveMTime])
+
+Returns [Object] with information about the restored file or directory.
+*/
+func (s Snapshot) Restore(ctx context.Context, params url.Values) (*Object, error) {
+	if params.Get("snapshot") == "" {
+		return nil, fmt.Errorf("snapshot: %w", ErrShouldNotBeEmpty)
+	}
+	if params.Get("dst") == "" {
+		return nil, fmt.Errorf("dst: %w", ErrShouldNotBeEmpty)
+	}
+	r := restore{
+		api:           s.Api,
+		snapshot:      params.Get("snapshot"),
+		onExist:       params.Get("on_exist"),
+		preserveMTime: params.Get("preserve_mtime") != "false",
+	}
+
+	src, err := r.stat(ctx, params.Get("src_id"), params.Get("src"))
+	if err != nil {
+		return nil, err
+	}
+	if src.Type != "dir" {
+		return r.file(ctx, params.Get("src_id"), params.Get("src"), params.Get("dst_id"), params.Get("dst"))
+	}
+	return r.dir(ctx, src, params.Get("src_id"), params.Get("src"), params.Get("dst_id"), params.Get("dst"))
+}
+
+// restore - state of a single [Snapshot.Restore] operation.
+type restore struct {
+	api           Api
+	snapshot      string
+	onExist       string
+	preserveMTime bool
+}
+
+// params returns parameters with the given key-value pairs, empty values are omitted.
+func (r restore) params(kv ...string) *Parameters {
+	params := NewParameters()
+	for i := 0; i+1 < len(kv); i += 2 {
+		if kv[i+1] != "" {
+			params.Set(kv[i], kv[i+1])
+		}
+	}
+	return params
+}
+
+// stat returns metadata of the object in the snapshot.
+func (r restore) stat(ctx context.Context, srcID, src string) (*Object, error) {
+	params := r.params("pid", srcID, "path", src).SetSnapshot(r.snapshot).SetFields([]string{"id", "name", "type", "mtime"})
+	return Meta{r.api}.Get(ctx, params.Values)
+}
+
+func (r restore) file(ctx context.Context, srcID, src, dstID, dst string) (*Object, error) {
+	params := r.params("src_id", srcID, "src", src, "dst_id", dstID, "dst", dst)
+	params.SetSnapshot(r.snapshot).SetPreserveMTime(r.preserveMTime)
+	if r.onExist != "" {
+		params.SetOnExist(r.onExist)
+	}
+	return File{r.api}.Copy(ctx, params.Values)
+}
+
+// dir copies the directory `src` to `dst` with [Dir.Copy], with `overwrite` an existing directory is merged.
+func (r restore) dir(ctx context.Context, src *Object, srcID, srcPath, dstID, dst string) (*Object, error) {
+	params := r.params("src_id", srcID, "src", srcPath, "dst_id", dstID, "dst", dst)
+	params.SetSnapshot(r.snapshot).SetPreserveMTime(r.preserveMTime)
+	if r.onExist == "autoname" {
+		params.SetOnExist("autoname")
+	}
+	obj, err := Dir{r.api}.Copy(ctx, params.Values)
+	if !errors.Is(err, ErrConflict) || r.onExist != "overwrite" {
+		return obj, err
+	}
+	return r.merge(ctx, src, srcID, srcPath, dstID, dst)
+}
+
+// merge restores all members of the directory `src` into the existing directory `dst`.
+func (r restore) merge(ctx context.Context, src *Object, srcID, srcPath, dstID, dst string) (*Object, error) {
+	target, err := Meta{r.api}.Get(ctx, r.params("pid", dstID, "path", dst).Values)
+	if err != nil {
+		return nil, err
+	}
+	if target.Type != "dir" {
+		return nil, fmt.Errorf("%s: %w: not a directory", dst, ErrConflict)
+	}
+
+	members := r.params("pid", srcID, "path", srcPath).SetSnapshot(r.snapshot).
+		SetFields([]string{"members.name", "members.type", "members.mtime"})
+	for member, err := range (Dir{r.api}).All(ctx, members.Values, 0) {
+		if err != nil {
+			return nil, err
+		}
+		childSrc := path.Join(srcPath, member.Name)
+		if member.Type == "dir" {
+			_, err = r.dir(ctx, member, srcID, childSrc, target.ID, member.Name)
+		} else {
+			_, err = r.file(ctx, srcID, childSrc, target.ID, member.Name)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if r.preserveMTime {
+		update := NewParameters().SetPid(target.ID).SetMTime(time.Time(src.MTime))
+		return Meta{r.api}.Update(ctx, update.Values)
+	}
+	return target, nil
+}
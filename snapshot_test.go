@@ -0,0 +1,209 @@
+package go_hidrive_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"testing"
+	"time"
+
+	hidrive "github.com/Burmuley/go-hidrive"
+	"github.com/Burmuley/go-hidrive/hidrivetest"
+)
+
+func TestSnapshot_Manage(t *testing.T) {
+	srv := hidrivetest.NewServer()
+	defer srv.Close()
+	if err := srv.AddSnapshot("daily", time.Now().Add(-time.Hour)); err != nil {
+		t.Fatalf("AddSnapshot() error = %v", err)
+	}
+	snapApi := hidrive.NewSnapshot(srv.Client(), srv.Endpoint())
+	ctx := context.Background()
+
+	created, err := snapApi.Create(ctx, hidrive.NewParameters().SetName("before-cleanup").Values)
+	if err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	if created.Name != "before-cleanup" || created.Type != "manual" || created.ID == "" {
+		t.Errorf("Create() = %+v", created)
+	}
+	if _, err := snapApi.Create(ctx, hidrive.NewParameters().SetName("daily").Values); !errors.Is(err, hidrive.ErrConflict) {
+		t.Errorf("Create() of existing name error = %v, want %v", err, hidrive.ErrConflict)
+	}
+
+	snaps, err := snapApi.Get(ctx, nil)
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	if len(snaps) != 2 || snaps[0].Name != "before-cleanup" || snaps[1].Name != "daily" {
+		t.Fatalf("Get() = %v, want snapshots sorted newest first", snaps)
+	}
+	if time.Since(time.Time(snaps[1].Created)) < time.Hour-time.Minute {
+		t.Errorf("Get() created = %v", time.Time(snaps[1].Created))
+	}
+
+	renamed, err := snapApi.Rename(ctx, hidrive.NewParameters().SetId(created.ID).SetName("kept").Values)
+	if err != nil || renamed.Name != "kept" || renamed.ID != created.ID {
+		t.Errorf("Rename() = %+v, %v", renamed, err)
+	}
+
+	if err := snapApi.Delete(ctx, hidrive.NewParameters().SetId(created.ID).Values); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+	if err := snapApi.Delete(ctx, hidrive.NewParameters().SetId(created.ID).Values); !errors.Is(err, hidrive.ErrNotFound) {
+		t.Errorf("Delete() of deleted snapshot error = %v, want %v", err, hidrive.ErrNotFound)
+	}
+	if snaps, _ := snapApi.Get(ctx, nil); len(snaps) != 1 {
+		t.Errorf("Get() after Delete() = %v", snaps)
+	}
+}
+
+func TestSnapshot_Browse(t *testing.T) {
+	srv := hidrivetest.NewServer()
+	defer srv.Close()
+	if err := srv.AddFile("/public/docs/a.txt", []byte("version 1"), time.Now()); err != nil {
+		t.Fatalf("AddFile() error = %v", err)
+	}
+	if err := srv.AddSnapshot("daily", time.Now()); err != nil {
+		t.Fatalf("AddSnapshot() error = %v", err)
+	}
+	if err := srv.AddFile("/public/docs/a.txt", []byte("version 2"), time.Now()); err != nil {
+		t.Fatalf("AddFile() error = %v", err)
+	}
+	if err := srv.AddFile("/public/docs/b.txt", []byte("new"), time.Now()); err != nil {
+		t.Fatalf("AddFile() error = %v", err)
+	}
+	ctx := context.Background()
+
+	dir, err := hidrive.NewDir(srv.Client(), srv.Endpoint()).Get(ctx,
+		hidrive.NewParameters().SetPath("/public/docs").SetSnapshot("daily").SetFields([]string{"members.name"}).Values)
+	if err != nil {
+		t.Fatalf("Dir.Get() error = %v", err)
+	}
+	if len(dir.Members) != 1 || dir.Members[0].Name != "a.txt" {
+		t.Errorf("Dir.Get() members = %v, want only a.txt", dir.Members)
+	}
+
+	rdr, err := hidrive.NewFile(srv.Client(), srv.Endpoint()).Get(ctx,
+		hidrive.NewParameters().SetPath("/public/docs/a.txt").SetSnapshot("daily").Values)
+	if err != nil {
+		t.Fatalf("File.Get() error = %v", err)
+	}
+	defer rdr.Close()
+	if data, _ := io.ReadAll(rdr); string(data) != "version 1" {
+		t.Errorf("File.Get() = %q, want %q", data, "version 1")
+	}
+
+	_, err = hidrive.NewMeta(srv.Client(), srv.Endpoint()).Get(ctx,
+		hidrive.NewParameters().SetPath("/public/docs/b.txt").SetSnapshot("daily").Values)
+	if !errors.Is(err, hidrive.ErrNotFound) {
+		t.Errorf("Meta.Get() error = %v, want %v", err, hidrive.ErrNotFound)
+	}
+}
+
+func TestSnapshot_Restore(t *testing.T) {
+	mtime := time.Unix(1600000000, 0)
+	setup := func(t *testing.T) (*hidrivetest.Server, *hidrive.Client) {
+		srv, client := hidrivetest.NewClient(t, map[string]string{
+			"/public/docs/a.txt":       "a v1",
+			"/public/docs/sub/b.txt":   "b v1",
+			"/public/docs/sub/c/d.txt": "d v1",
+		}, mtime)
+		srv.MaxListLimit = 1 // directories are listed in pages shorter than requested
+		if err := srv.AddSnapshot("daily", time.Now()); err != nil {
+			t.Fatalf("AddSnapshot() error = %v", err)
+		}
+		// changes made after the snapshot
+		if err := srv.AddFile("/public/docs/a.txt", []byte("a v2"), time.Now()); err != nil {
+			t.Fatalf("AddFile() error = %v", err)
+		}
+		if err := srv.AddFile("/public/docs/sub/new.txt", []byte("new"), time.Now()); err != nil {
+			t.Fatalf("AddFile() error = %v", err)
+		}
+		if err := srv.AddFile("/public/docs/sub/c/d.txt", []byte("d v2"), time.Now()); err != nil {
+			t.Fatalf("AddFile() error = %v", err)
+		}
+		return srv, client
+	}
+
+	tests := []struct {
+		name    string
+		params  *hidrive.Parameters
+		want    map[string]string
+		wantErr error
+	}{
+		{
+			name:   "file to new path",
+			params: hidrive.NewParameters().SetSrc("/public/docs/a.txt").SetDst("/public/a.restored.txt"),
+			want:   map[string]string{"/public/a.restored.txt": "a v1", "/public/docs/a.txt": "a v2"},
+		},
+		{
+			name:   "file overwrite",
+			params: hidrive.NewParameters().SetSrc("/public/docs/a.txt").SetDst("/public/docs/a.txt").SetOnExist("overwrite"),
+			want:   map[string]string{"/public/docs/a.txt": "a v1"},
+		},
+		{
+			name:    "file conflict",
+			params:  hidrive.NewParameters().SetSrc("/public/docs/a.txt").SetDst("/public/docs/a.txt"),
+			wantErr: hidrive.ErrConflict,
+		},
+		{
+			name:   "file autoname",
+			params: hidrive.NewParameters().SetSrc("/public/docs/a.txt").SetDst("/public/docs/a.txt").SetOnExist("autoname"),
+			want:   map[string]string{"/public/docs/a (1).txt": "a v1", "/public/docs/a.txt": "a v2"},
+		},
+		{
+			name:   "directory to new path",
+			params: hidrive.NewParameters().SetSrc("/public/docs/sub").SetDst("/public/restored"),
+			want:   map[string]string{"/public/restored/b.txt": "b v1", "/public/restored/c/d.txt": "d v1"},
+		},
+		{
+			name:   "directory merge",
+			params: hidrive.NewParameters().SetSrc("/public/docs").SetDst("/public/docs").SetOnExist("overwrite"),
+			want: map[string]string{
+				"/public/docs/a.txt":       "a v1",
+				"/public/docs/sub/b.txt":   "b v1",
+				"/public/docs/sub/c/d.txt": "d v1",
+				"/public/docs/sub/new.txt": "new",
+			},
+		},
+		{
+			name:    "directory conflict",
+			params:  hidrive.NewParameters().SetSrc("/public/docs/sub").SetDst("/public/docs/sub"),
+			wantErr: hidrive.ErrConflict,
+		},
+		{
+			name:    "not in snapshot",
+			params:  hidrive.NewParameters().SetSrc("/public/docs/sub/new.txt").SetDst("/public/new.txt"),
+			wantErr: hidrive.ErrNotFound,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv, client := setup(t)
+			obj, err := client.Snapshot.Restore(context.Background(), tt.params.SetSnapshot("daily").Values)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Restore() error = %v, want %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if !time.Time(obj.MTime).Equal(mtime) {
+				t.Errorf("Restore() mtime = %v, want %v", time.Time(obj.MTime), mtime)
+			}
+			for p, want := range tt.want {
+				if got, err := srv.ReadFile(p); err != nil || string(got) != want {
+					t.Errorf("ReadFile(%q) = %q, %v, want %q", p, got, err, want)
+				}
+			}
+		})
+	}
+
+	t.Run("snapshot is mandatory", func(t *testing.T) {
+		params := hidrive.NewParameters().SetSrc("/public/docs/a.txt").SetDst("/public/a.txt")
+		_, err := hidrive.NewSnapshot(nil, "").Restore(context.Background(), params.Values)
+		if !errors.Is(err, hidrive.ErrShouldNotBeEmpty) {
+			t.Errorf("Restore() error = %v, want %v", err, hidrive.ErrShouldNotBeEmpty)
+		}
+	})
+}
@@ -0,0 +1,463 @@
+package go_hidrive
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// SyncCompare - the way [Client.Sync] and [Client.Mirror] decide whether a file has changed.
+type SyncCompare int
+
+const (
+	SyncCompareSizeMTime SyncCompare = iota // Files differ if their sizes or modification times differ
+	SyncCompareHash                         // Files differ if their sizes or content hashes (chash) differ
+)
+
+// SyncAction - the kind of change made by [Client.Sync], [Client.Mirror] and [Client.TwoWaySync].
+type SyncAction string
+
+const (
+	SyncMkdir    SyncAction = "mkdir"    // Directory created
+	SyncUpload   SyncAction = "upload"   // New file uploaded
+	SyncDownload SyncAction = "download" // New file downloaded
+	SyncUpdate   SyncAction = "update"   // Changed file transferred over the existing one
+	SyncSetMTime SyncAction = "mtime"    // Only the modification time updated, the content is the same
+	SyncDelete   SyncAction = "delete"   // Extraneous file or directory deleted (recursively)
+	SyncRename   SyncAction = "rename"   // File renamed to keep both versions of a conflict, see [Client.TwoWaySync]
+	SyncConflict SyncAction = "conflict" // File changed on both sides, see [SyncConflictPolicy]
+)
+
+/*
+SyncOptions - options for [Client.Sync], [Client.Mirror] and [Client.TwoWaySync].
+
+Property `Compare` defines how changed files are detected, defaults to [SyncCompareSizeMTime]. With [SyncCompareHash]
+every local file of the same size is read and its content hash computed with [NewHash] is compared with `chash` of the
+remote file, so changes keeping the size and modification time are detected too. Files with the same content but
+different modification time only get their modification time updated.
+
+Property `Delete` enables deletion of files and directories of the destination which do not exist in the source.
+
+Property `DryRun` makes the sync only report what would be done, the destination is not modified.
+
+Property `ChunkSize` defines the size above which files are uploaded with [File.ChunkedUpload]
+instead of a single request, defaults to [DefaultChunkSize]. Used by [Client.Sync] only.
+
+Property `State` is used by [Client.Mirror] to skip unchanged directories and by [Client.TwoWaySync] to detect
+changes made on each side since the previous run, see [SyncState].
+
+Property `Conflict` defines how [Client.TwoWaySync] resolves files changed on both sides, defaults to
+[SyncConflictReport].
+*/
+type SyncOptions struct {
+	Compare   SyncCompare
+	Delete    bool
+	DryRun    bool
+	ChunkSize int64
+	State     *SyncState
+	Conflict  SyncConflictPolicy
+}
+
+/*
+SyncEntry - a single change made by [Client.Sync], [Client.Mirror] or [Client.TwoWaySync].
+
+Property `Path` is slash-separated and relative to the synced roots, `Local` tells whether the change was made in
+the local directory or on HiDrive.
+*/
+type SyncEntry struct {
+	Path   string
+	Action SyncAction
+	Dir    bool
+	Local  bool
+	Size   int64
+}
+
+/*
+SyncReport - the result of [Client.Sync], [Client.Mirror] or [Client.TwoWaySync].
+
+Property `Entries` lists the changes in the order they were made, `Unchanged` is the number of files left untouched,
+`Skipped` is the number of directories skipped as unchanged by [Client.Mirror] and `Bytes` is the total size of the
+transferred files. With [SyncOptions.DryRun] the report describes the changes which would be made.
+*/
+type SyncReport struct {
+	Entries   []SyncEntry
+	Unchanged int
+	Skipped   int
+	Bytes     int64
+}
+
+// Count returns the number of entries with the given action.
+func (r *SyncReport) Count(action SyncAction) int {
+	n := 0
+	for _, e := range r.Entries {
+		if e.Action == action {
+			n++
+		}
+	}
+	return n
+}
+
+// add records the change in the report.
+func (r *SyncReport) add(rel string, action SyncAction, dir, local bool, size int64) {
+	r.Entries = append(r.Entries, SyncEntry{Path: rel, Action: action, Dir: dir, Local: local, Size: size})
+	if action == SyncUpload || action == SyncDownload || action == SyncUpdate {
+		r.Bytes += size
+	}
+}
+
+/*
+Sync - make the HiDrive directory `remotePath` a copy of the local directory `localDir`, like `rsync -rt`.
+
+The remote tree is listed with [Dir.Walk] and compared with the local tree read with os.ReadDir: missing directories
+are created (the remote root with [Dir.CreatePath]), new files are uploaded with [File.Upload] and changed files with
+[File.Update]. Modification times of files and directories are preserved. Remote objects of a different type than
+the local ones (a file in place of a directory or vice versa) are replaced. Files which are neither regular files
+nor directories (e.g. symbolic links) are skipped.
+
+The sync stops at the first error, the returned report then lists the changes made so far.
+*/
+func (c *Client) Sync(ctx context.Context, localDir, remotePath string, opts *SyncOptions) (*SyncReport, error) {
+	s := newSyncer(ctx, c.api, localDir, remotePath, opts)
+
+	info, err := os.Stat(localDir)
+	if err != nil {
+		return s.report, err
+	}
+	if !info.IsDir() {
+		return s.report, fmt.Errorf("%s: not a directory", localDir)
+	}
+
+	if err := s.index(); err != nil {
+		return s.report, err
+	}
+	return s.report, s.syncDir(".", info, time.Time{})
+}
+
+// syncer holds the state of a single [Client.Sync] call.
+type syncer struct {
+	ctx    context.Context
+	api    Api
+	opts   SyncOptions
+	local  string
+	remote string
+	report *SyncReport
+
+	objects map[string]*Object   // remote objects by relative path
+	members map[string][]*Object // remote directory members by relative path of the directory
+}
+
+func newSyncer(ctx context.Context, api Api, localDir, remotePath string, opts *SyncOptions) *syncer {
+	var o SyncOptions
+	if opts != nil {
+		o = *opts
+	}
+	if o.ChunkSize <= 0 {
+		o.ChunkSize = DefaultChunkSize
+	}
+
+	return &syncer{
+		ctx:     ctx,
+		api:     api,
+		opts:    o,
+		local:   localDir,
+		remote:  path.Clean("/" + remotePath),
+		report:  &SyncReport{},
+		objects: map[string]*Object{},
+		members: map[string][]*Object{},
+	}
+}
+
+// index lists the remote tree, a missing remote root is not an error.
+func (s *syncer) index() error {
+	fields := []string{"size", "mtime"}
+	if s.opts.Compare == SyncCompareHash {
+		fields = append(fields, "chash")
+	}
+
+	err := Dir{s.api}.Walk(s.ctx, s.remote, func(p string, obj *Object, err error) error {
+		if err != nil {
+			if obj == nil && errors.Is(err, ErrNotFound) {
+				return nil
+			}
+			return err
+		}
+
+		rel := s.rel(p)
+		s.objects[rel] = obj
+		if rel != "." {
+			s.members[path.Dir(rel)] = append(s.members[path.Dir(rel)], obj)
+		}
+		return nil
+	}, &WalkOptions{Fields: fields})
+	if err != nil {
+		return err
+	}
+
+	if root, ok := s.objects["."]; ok && root.Type != "dir" {
+		return fmt.Errorf("%s: not a directory", s.remote)
+	}
+	return nil
+}
+
+// rel returns the path relative to the remote root.
+func (s *syncer) rel(remotePath string) string {
+	rel := strings.TrimPrefix(strings.TrimPrefix(remotePath, s.remote), "/")
+	if rel == "" {
+		return "."
+	}
+	return rel
+}
+
+// remotePath returns the absolute remote path of the relative path.
+func (s *syncer) remotePath(rel string) string {
+	return path.Join(s.remote, rel)
+}
+
+// localPath returns the local path of the relative path.
+func (s *syncer) localPath(rel string) string {
+	return filepath.Join(s.local, filepath.FromSlash(rel))
+}
+
+/*
+syncDir syncs the directory and its contents. `parentMTime` is the local modification time of the parent directory,
+it is restored on the remote parent when the directory is created or replaced.
+*/
+func (s *syncer) syncDir(rel string, info fs.FileInfo, parentMTime time.Time) error {
+	if err := s.ctx.Err(); err != nil {
+		return err
+	}
+
+	mtime := info.ModTime()
+	obj := s.objects[rel]
+	if obj != nil && obj.Type != "dir" {
+		if err := s.delete(rel, obj, parentMTime); err != nil {
+			return err
+		}
+		obj = nil
+	}
+
+	created := obj == nil
+	var remoteMTime time.Time
+	if created {
+		var err error
+		if remoteMTime, err = s.mkdir(rel, mtime, parentMTime); err != nil {
+			return err
+		}
+	} else {
+		remoteMTime = time.Time(obj.MTime)
+	}
+
+	entries, err := os.ReadDir(s.localPath(rel))
+	if err != nil {
+		return err
+	}
+
+	seen := map[string]bool{}
+	for _, e := range entries {
+		seen[e.Name()] = true
+		childRel := path.Join(rel, e.Name())
+		childInfo, err := e.Info()
+		if err != nil {
+			return err
+		}
+
+		switch {
+		case childInfo.IsDir():
+			if err := s.syncDir(childRel, childInfo, mtime); err != nil {
+				return err
+			}
+			if s.objects[childRel] == nil || s.objects[childRel].Type != "dir" {
+				remoteMTime = mtime
+			}
+		case childInfo.Mode().IsRegular():
+			touched, err := s.syncFile(childRel, childInfo, mtime)
+			if err != nil {
+				return err
+			}
+			if touched {
+				remoteMTime = mtime
+			}
+		}
+	}
+
+	if s.opts.Delete {
+		for _, m := range s.members[rel] {
+			if seen[m.Name] {
+				continue
+			}
+			if err := s.delete(path.Join(rel, m.Name), m, mtime); err != nil {
+				return err
+			}
+			remoteMTime = mtime
+		}
+	}
+
+	if remoteMTime.Unix() == mtime.Unix() {
+		return nil
+	}
+	if !created {
+		s.report.add(rel, SyncSetMTime, true, false, 0)
+	}
+	if s.opts.DryRun {
+		return nil
+	}
+	_, err = Meta{s.api}.Update(s.ctx, NewParameters().SetPath(s.remotePath(rel)).SetMTime(mtime).Values)
+	return err
+}
+
+// mkdir creates the remote directory and returns its modification time.
+func (s *syncer) mkdir(rel string, mtime, parentMTime time.Time) (time.Time, error) {
+	s.report.add(rel, SyncMkdir, true, false, 0)
+	if s.opts.DryRun {
+		return mtime, nil
+	}
+
+	dir := Dir{s.api}
+	if rel == "." {
+		obj, err := dir.CreatePath(s.ctx, NewParameters().SetPath(s.remote).Values)
+		if err != nil {
+			return time.Time{}, err
+		}
+		return time.Time(obj.MTime), nil
+	}
+
+	params := NewParameters().SetPath(s.remotePath(rel)).SetMTime(mtime)
+	setParentMTime(params, parentMTime)
+	if _, err := dir.Create(s.ctx, params.Values); err != nil {
+		return time.Time{}, err
+	}
+	return mtime, nil
+}
+
+/*
+syncFile uploads the file if it is new or changed, `parentMTime` is restored on the remote parent.
+Reports whether the remote parent directory has been modified.
+*/
+func (s *syncer) syncFile(rel string, info fs.FileInfo, parentMTime time.Time) (bool, error) {
+	obj := s.objects[rel]
+	if obj != nil && obj.Type == "dir" {
+		if err := s.delete(rel, obj, parentMTime); err != nil {
+			return false, err
+		}
+		obj = nil
+	}
+
+	action := SyncUpload
+	if obj != nil {
+		var err error
+		if action, err = compareFile(s.localPath(rel), info, obj, s.opts.Compare); err != nil {
+			return false, err
+		}
+	}
+
+	switch action {
+	case "":
+		s.report.Unchanged++
+		return false, nil
+	case SyncSetMTime:
+		s.report.add(rel, SyncSetMTime, false, false, info.Size())
+		if s.opts.DryRun {
+			return false, nil
+		}
+		_, err := Meta{s.api}.Update(s.ctx, NewParameters().SetPath(s.remotePath(rel)).SetMTime(info.ModTime()).Values)
+		return false, err
+	}
+
+	s.report.add(rel, action, false, false, info.Size())
+	if s.opts.DryRun {
+		return true, nil
+	}
+	_, err := s.upload(rel, info, parentMTime, action == SyncUpdate)
+	return true, err
+}
+
+/*
+compareFile returns the action required to make the local file `name` and the remote file the same according to
+`mode`, empty if they do not differ. Local objects other than regular files always differ.
+*/
+func compareFile(name string, info fs.FileInfo, obj *Object, mode SyncCompare) (SyncAction, error) {
+	sameMTime := time.Time(obj.MTime).Unix() == info.ModTime().Unix()
+	if !info.Mode().IsRegular() || obj.Size != info.Size() {
+		return SyncUpdate, nil
+	}
+	if mode != SyncCompareHash {
+		if sameMTime {
+			return "", nil
+		}
+		return SyncUpdate, nil
+	}
+
+	f, err := os.Open(name)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	sum, err := ContentHash(f)
+	if err != nil {
+		return "", err
+	}
+	switch {
+	case sum != obj.CHash:
+		return SyncUpdate, nil
+	case !sameMTime:
+		return SyncSetMTime, nil
+	default:
+		return "", nil
+	}
+}
+
+// upload uploads the local file, replacing the remote one if `overwrite` is set.
+func (s *syncer) upload(rel string, info fs.FileInfo, parentMTime time.Time, overwrite bool) (*Object, error) {
+	params := NewParameters().SetDir(s.remotePath(path.Dir(rel))).SetName(path.Base(rel)).SetMTime(info.ModTime())
+	setParentMTime(params, parentMTime)
+	return s.send(rel, info, params, overwrite)
+}
+
+// send uploads the local file with the upload parameters, replacing the remote one if `overwrite` is set.
+func (s *syncer) send(rel string, info fs.FileInfo, params *Parameters, overwrite bool) (*Object, error) {
+	f, err := os.Open(s.localPath(rel))
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	file := File{s.api}
+	switch {
+	case info.Size() > s.opts.ChunkSize:
+		return file.ChunkedUpload(s.ctx, params.Values, f, &ChunkedUploadOptions{ChunkSize: s.opts.ChunkSize, Overwrite: overwrite})
+	case overwrite:
+		return file.Update(s.ctx, params.Values, f)
+	default:
+		return file.Upload(s.ctx, params.Values, f)
+	}
+}
+
+// delete deletes the remote file or directory (recursively), `parentMTime` is restored on the remote parent.
+func (s *syncer) delete(rel string, obj *Object, parentMTime time.Time) error {
+	dir := obj.Type == "dir"
+	s.report.add(rel, SyncDelete, dir, false, obj.Size)
+	if s.opts.DryRun {
+		return nil
+	}
+
+	params := NewParameters().SetPath(s.remotePath(rel))
+	setParentMTime(params, parentMTime)
+	if dir {
+		return Dir{s.api}.Delete(s.ctx, params.SetRecursive(true).Values)
+	}
+	return File{s.api}.Delete(s.ctx, params.Values)
+}
+
+// setParentMTime sets `parent_mtime` parameter unless the time is zero.
+func setParentMTime(params *Parameters, t time.Time) {
+	if !t.IsZero() {
+		params.SetParentMTime(t)
+	}
+}
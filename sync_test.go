@@ -0,0 +1,264 @@
+package go_hidrive_test
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	hidrive "github.com/Burmuley/go-hidrive"
+	"github.com/Burmuley/go-hidrive/hidrivetest"
+)
+
+var syncMTime = time.Unix(1600000000, 0)
+
+// writeLocalTree creates the files (empty directories for names ending with "/") and sets mtime of everything.
+func writeLocalTree(t *testing.T, root string, files map[string]string, mtime time.Time) {
+	t.Helper()
+	for name, content := range files {
+		p := filepath.Join(root, filepath.FromSlash(name))
+		if name[len(name)-1] == '/' {
+			if err := os.MkdirAll(p, 0o755); err != nil {
+				t.Fatal(err)
+			}
+			continue
+		}
+		if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	err := filepath.WalkDir(root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		return os.Chtimes(p, mtime, mtime)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+// syncActions returns the report entries as "action path" strings.
+func syncActions(report *hidrive.SyncReport) []string {
+	out := []string{}
+	for _, e := range report.Entries {
+		out = append(out, string(e.Action)+" "+e.Path)
+	}
+	return out
+}
+
+func TestClient_Sync(t *testing.T) {
+	files := map[string]string{
+		"a.txt":       "a",
+		"b/c.txt":     "c",
+		"b/d/e.txt":   "e",
+		"empty/":      "",
+		"large.bin":   "0123456789abcdef0123",
+		"with space/": "",
+	}
+	setup := func(t *testing.T) (*hidrive.Client, *hidrivetest.Server, string) {
+		srv, client := hidrivetest.NewClient(t, nil, syncMTime)
+		local := t.TempDir()
+		writeLocalTree(t, local, files, syncMTime)
+		return client, srv, local
+	}
+	ctx := context.Background()
+
+	t.Run("initial", func(t *testing.T) {
+		client, srv, local := setup(t)
+		report, err := client.Sync(ctx, local, "/public/backup/data", &hidrive.SyncOptions{ChunkSize: 8})
+		if err != nil {
+			t.Fatalf("Sync() error = %v", err)
+		}
+		want := []string{
+			"mkdir .", "upload a.txt", "mkdir b", "upload b/c.txt", "mkdir b/d", "upload b/d/e.txt",
+			"mkdir empty", "upload large.bin", "mkdir with space",
+		}
+		if got := syncActions(report); !reflect.DeepEqual(got, want) {
+			t.Errorf("Sync() actions = %q, want %q", got, want)
+		}
+		if report.Bytes != 23 || report.Count(hidrive.SyncUpload) != 4 {
+			t.Errorf("Sync() report = %+v", report)
+		}
+
+		for name, content := range files {
+			p := strings.TrimSuffix("/public/backup/data/"+name, "/")
+			if !srv.Exists(p) {
+				t.Errorf("%s does not exist", p)
+				continue
+			}
+			if content != "" {
+				if got, _ := srv.ReadFile(p); string(got) != content {
+					t.Errorf("ReadFile(%q) = %q, want %q", p, got, content)
+				}
+			}
+		}
+		for _, p := range []string{"/public/backup/data", "/public/backup/data/a.txt", "/public/backup/data/b", "/public/backup/data/b/d", "/public/backup/data/large.bin"} {
+			if got, _ := srv.ModTime(p); !got.Equal(syncMTime) {
+				t.Errorf("ModTime(%q) = %v, want %v", p, got, syncMTime)
+			}
+		}
+
+		report, err = client.Sync(ctx, local, "/public/backup/data", &hidrive.SyncOptions{Compare: hidrive.SyncCompareHash})
+		if err != nil {
+			t.Fatalf("second Sync() error = %v", err)
+		}
+		if len(report.Entries) != 0 || report.Unchanged != 4 {
+			t.Errorf("second Sync() actions = %q, unchanged = %d", syncActions(report), report.Unchanged)
+		}
+	})
+
+	t.Run("changes", func(t *testing.T) {
+		client, srv, local := setup(t)
+		if _, err := client.Sync(ctx, local, "/public/data", nil); err != nil {
+			t.Fatalf("Sync() error = %v", err)
+		}
+		later := syncMTime.Add(time.Hour)
+		if err := os.WriteFile(filepath.Join(local, "b", "c.txt"), []byte("C"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		for _, name := range []string{"a.txt", "b/c.txt", "b"} {
+			if err := os.Chtimes(filepath.Join(local, name), later, later); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		report, err := client.Sync(ctx, local, "/public/data", &hidrive.SyncOptions{Compare: hidrive.SyncCompareHash})
+		if err != nil {
+			t.Fatalf("Sync() error = %v", err)
+		}
+		want := []string{"mtime a.txt", "update b/c.txt"}
+		if got := syncActions(report); !reflect.DeepEqual(got, want) {
+			t.Errorf("Sync() actions = %q, want %q", got, want)
+		}
+		if got, _ := srv.ReadFile("/public/data/b/c.txt"); string(got) != "C" {
+			t.Errorf("ReadFile() = %q, want %q", got, "C")
+		}
+		for _, p := range []string{"/public/data/a.txt", "/public/data/b", "/public/data/b/c.txt"} {
+			if got, _ := srv.ModTime(p); !got.Equal(later) {
+				t.Errorf("ModTime(%q) = %v, want %v", p, got, later)
+			}
+		}
+
+		// size and mtime mode uploads files with changed mtime
+		if err := os.Chtimes(filepath.Join(local, "b", "d", "e.txt"), later, later); err != nil {
+			t.Fatal(err)
+		}
+		report, err = client.Sync(ctx, local, "/public/data", nil)
+		if err != nil {
+			t.Fatalf("Sync() error = %v", err)
+		}
+		if got := syncActions(report); !reflect.DeepEqual(got, []string{"update b/d/e.txt"}) {
+			t.Errorf("Sync() actions = %q", got)
+		}
+	})
+
+	t.Run("same size and mtime", func(t *testing.T) {
+		client, srv, local := setup(t)
+		if _, err := client.Sync(ctx, local, "/public/data", nil); err != nil {
+			t.Fatalf("Sync() error = %v", err)
+		}
+		writeLocalTree(t, local, map[string]string{"b/c.txt": "C"}, syncMTime)
+
+		report, err := client.Sync(ctx, local, "/public/data", nil)
+		if err != nil {
+			t.Fatalf("Sync() error = %v", err)
+		}
+		if len(report.Entries) != 0 {
+			t.Errorf("Sync() actions = %q, want none", syncActions(report))
+		}
+
+		report, err = client.Sync(ctx, local, "/public/data", &hidrive.SyncOptions{Compare: hidrive.SyncCompareHash})
+		if err != nil {
+			t.Fatalf("Sync() error = %v", err)
+		}
+		if got := syncActions(report); !reflect.DeepEqual(got, []string{"update b/c.txt"}) {
+			t.Errorf("Sync() actions = %q", got)
+		}
+		if got, _ := srv.ReadFile("/public/data/b/c.txt"); string(got) != "C" {
+			t.Errorf("ReadFile() = %q, want %q", got, "C")
+		}
+	})
+
+	t.Run("delete and replace", func(t *testing.T) {
+		client, srv, local := setup(t)
+		for p, content := range map[string]string{
+			"/public/data/a.txt/nested.txt": "a is a directory",
+			"/public/data/b/extra.txt":      "extra",
+			"/public/data/empty":            "empty is a file",
+			"/public/data/old/x.txt":        "x",
+		} {
+			if err := srv.AddFile(p, []byte(content), syncMTime); err != nil {
+				t.Fatalf("AddFile() error = %v", err)
+			}
+		}
+
+		report, err := client.Sync(ctx, local, "/public/data", &hidrive.SyncOptions{DryRun: true, Delete: true})
+		if err != nil {
+			t.Fatalf("Sync() error = %v", err)
+		}
+		want := []string{
+			"delete a.txt", "upload a.txt", "upload b/c.txt", "mkdir b/d", "upload b/d/e.txt", "delete b/extra.txt",
+			"delete empty", "mkdir empty", "upload large.bin", "mkdir with space", "delete old",
+		}
+		if got := syncActions(report); !reflect.DeepEqual(got, want) {
+			t.Errorf("Sync() dry run actions = %q, want %q", got, want)
+		}
+		if !srv.Exists("/public/data/old/x.txt") || srv.Exists("/public/data/large.bin") {
+			t.Errorf("dry run modified the remote tree")
+		}
+
+		report, err = client.Sync(ctx, local, "/public/data", nil)
+		if err != nil {
+			t.Fatalf("Sync() error = %v", err)
+		}
+		if report.Count(hidrive.SyncDelete) != 2 || !srv.Exists("/public/data/old/x.txt") || !srv.Exists("/public/data/b/extra.txt") {
+			t.Errorf("Sync() without delete actions = %q", syncActions(report))
+		}
+
+		if _, err := client.Sync(ctx, local, "/public/data", &hidrive.SyncOptions{Delete: true}); err != nil {
+			t.Fatalf("Sync() error = %v", err)
+		}
+		for _, p := range []string{"/public/data/old", "/public/data/b/extra.txt"} {
+			if srv.Exists(p) {
+				t.Errorf("%s exists", p)
+			}
+		}
+		if got, _ := srv.ReadFile("/public/data/a.txt"); string(got) != "a" {
+			t.Errorf("ReadFile() = %q, want %q", got, "a")
+		}
+		if got, _ := srv.ModTime("/public/data/b"); !got.Equal(syncMTime) {
+			t.Errorf("ModTime() = %v, want %v", got, syncMTime)
+		}
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		client, srv, local := setup(t)
+		if err := srv.AddFile("/public/file", []byte("x"), syncMTime); err != nil {
+			t.Fatalf("AddFile() error = %v", err)
+		}
+		if _, err := client.Sync(ctx, local, "/public/file", nil); err == nil {
+			t.Errorf("Sync() to a file error = nil")
+		}
+		if _, err := client.Sync(ctx, filepath.Join(local, "missing"), "/public/data", nil); !errors.Is(err, fs.ErrNotExist) {
+			t.Errorf("Sync() of missing directory error = %v, want %v", err, fs.ErrNotExist)
+		}
+
+		srv.InjectError("POST", "file", 403, 1)
+		report, err := client.Sync(ctx, local, "/public/data", nil)
+		if !errors.Is(err, hidrive.ErrForbidden) {
+			t.Errorf("Sync() error = %v, want %v", err, hidrive.ErrForbidden)
+		}
+		if got := syncActions(report); !reflect.DeepEqual(got, []string{"mkdir .", "upload a.txt"}) {
+			t.Errorf("Sync() actions = %q", got)
+		}
+	})
+}
@@ -0,0 +1,73 @@
+package go_hidrive
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/url"
+)
+
+// ThumbnailMode - the way an image is scaled to the requested thumbnail size, see [Parameters.SetThumbnailMode].
+type ThumbnailMode string
+
+const (
+	ThumbnailModeFit  ThumbnailMode = "fit"  // Scale the image to fit into width x height keeping the aspect ratio
+	ThumbnailModeCrop ThumbnailMode = "crop" // Scale the image to cover width x height and crop what exceeds it
+)
+
+/*
+Thumbnail - result of [File.Thumbnail].
+
+`Body` contains the encoded thumbnail image and must be closed by the caller, `ContentType` is the MIME type
+of the image (e.g. "image/jpeg").
+*/
+type Thumbnail struct {
+	Body          io.ReadCloser
+	ContentType   string
+	ContentLength int64
+}
+
+/*
+Thumbnail - retrieve a scaled down version of an image file without downloading the original.
+
+Both, the `pid` and `path` parameters identify a filesystem object, at least one of them is always mandatory.
+It is allowed to use both together, in which case `pid` addresses a parent directory and the value of `path` is then
+considered relative to that directory (<pid>/<path>).
+
+At least one of `width` and `height` should be given, the thumbnail is never larger than the original image.
+
+Status codes:
+  - 200 - OK
+  - 400 - Bad Request (e.g. invalid parameter)
+  - 401 - Unauthorized (password required)
+  - 403 - Forbidden (wrong password)
+  - 404 - Not Found (ID does not exist or given path is not shared).
+  - 415 - Unsupported Media Type (the file is not an image)
+  - 500 - Internal Error
+
+Supported parameters:
+  - path ([Parameters.SetPath])
+  - pid ([Parameters.SetPid])
+  - width ([Parameters.SetWidth])
+  - height ([Parameters.SetHeight])
+  - mode ([Parameters.SetThumbnailMode])
+  - snapshot ([Parameters.SetSnapshot])
+
+Returns [Thumbnail] with the image data and its content type.
+*/
+func (f File) Thumbnail(ctx context.Context, params url.Values) (*Thumbnail, error) {
+	var (
+		res *http.Response
+		err error
+	)
+
+	if res, err = f.doGET(ctx, "file/thumbnail", params, []int{http.StatusOK}); err != nil {
+		return nil, err
+	}
+
+	return &Thumbnail{
+		Body:          res.Body,
+		ContentType:   res.Header.Get("Content-Type"),
+		ContentLength: res.ContentLength,
+	}, nil
+}
@@ -0,0 +1,131 @@
+package go_hidrive_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"testing"
+	"time"
+
+	hidrive "github.com/Burmuley/go-hidrive"
+	"github.com/Burmuley/go-hidrive/hidrivetest"
+)
+
+// encodeImage returns a width x height image encoded as PNG or JPEG.
+func encodeImage(t *testing.T, width, height int, format string) []byte {
+	t.Helper()
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			img.Set(x, y, color.RGBA{uint8(x), uint8(y), 128, 255})
+		}
+	}
+
+	var buf bytes.Buffer
+	var err error
+	if format == "jpeg" {
+		err = jpeg.Encode(&buf, img, nil)
+	} else {
+		err = png.Encode(&buf, img)
+	}
+	if err != nil {
+		t.Fatalf("encode %s error = %v", format, err)
+	}
+	return buf.Bytes()
+}
+
+func TestFile_Thumbnail(t *testing.T) {
+	srv := hidrivetest.NewServer()
+	defer srv.Close()
+	now := time.Now()
+	if err := srv.AddFile("/public/photo.png", encodeImage(t, 200, 100, "png"), now); err != nil {
+		t.Fatalf("AddFile() error = %v", err)
+	}
+	if err := srv.AddFile("/public/photo.jpg", encodeImage(t, 200, 100, "jpeg"), now); err != nil {
+		t.Fatalf("AddFile() error = %v", err)
+	}
+	if err := srv.AddFile("/public/notes.txt", []byte("not an image"), now); err != nil {
+		t.Fatalf("AddFile() error = %v", err)
+	}
+	fileApi := hidrive.NewFile(srv.Client(), srv.Endpoint())
+
+	tests := []struct {
+		name        string
+		params      *hidrive.Parameters
+		contentType string
+		width       int
+		height      int
+		wantErr     error
+	}{
+		{"fit", hidrive.NewParameters().SetPath("/public/photo.png").SetWidth(50).SetHeight(50), "image/png", 50, 25, nil},
+		{
+			"crop", hidrive.NewParameters().SetPath("/public/photo.png").SetWidth(50).SetHeight(50).SetThumbnailMode(hidrive.ThumbnailModeCrop),
+			"image/png", 50, 50, nil,
+		},
+		{"width only", hidrive.NewParameters().SetPath("/public/photo.jpg").SetWidth(100), "image/jpeg", 100, 50, nil},
+		{"no upscaling", hidrive.NewParameters().SetPath("/public/photo.jpg").SetWidth(400).SetHeight(400), "image/jpeg", 200, 100, nil},
+		{"not an image", hidrive.NewParameters().SetPath("/public/notes.txt").SetWidth(50), "", 0, 0, hidrive.ErrUnsupportedMedia},
+		{"not found", hidrive.NewParameters().SetPath("/public/missing.png").SetWidth(50), "", 0, 0, hidrive.ErrNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			thumb, err := fileApi.Thumbnail(context.Background(), tt.params.Values)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Thumbnail() error = %v, want %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			defer thumb.Body.Close()
+
+			if thumb.ContentType != tt.contentType {
+				t.Errorf("Thumbnail() content type = %q, want %q", thumb.ContentType, tt.contentType)
+			}
+			cfg, _, err := image.DecodeConfig(thumb.Body)
+			if err != nil {
+				t.Fatalf("decode thumbnail error = %v", err)
+			}
+			if cfg.Width != tt.width || cfg.Height != tt.height {
+				t.Errorf("Thumbnail() size = %dx%d, want %dx%d", cfg.Width, cfg.Height, tt.width, tt.height)
+			}
+		})
+	}
+}
+
+func TestObject_Image(t *testing.T) {
+	srv := hidrivetest.NewServer()
+	defer srv.Close()
+	if err := srv.AddFile("/public/photo.png", encodeImage(t, 64, 48, "png"), time.Now()); err != nil {
+		t.Fatalf("AddFile() error = %v", err)
+	}
+	if err := srv.AddFile("/public/notes.txt", []byte("text"), time.Now()); err != nil {
+		t.Fatalf("AddFile() error = %v", err)
+	}
+
+	params := hidrive.NewParameters().SetPath("/public").SetMembers([]string{"file"}).
+		SetFields([]string{"members.name", "members.category", "members.image.width", "members.image.height", "members.image.exif"})
+	dir, err := hidrive.NewDir(srv.Client(), srv.Endpoint()).Get(context.Background(), params.Values)
+	if err != nil {
+		t.Fatalf("Dir.Get() error = %v", err)
+	}
+	if len(dir.Members) != 2 {
+		t.Fatalf("Dir.Get() returned %d members, want 2", len(dir.Members))
+	}
+
+	for _, m := range dir.Members {
+		switch m.Name {
+		case "photo.png":
+			if m.Category != "image" || m.Image == nil || m.Image.Width != 64 || m.Image.Height != 48 || m.Image.Exif == nil {
+				t.Errorf("photo.png: category = %q, image = %+v", m.Category, m.Image)
+			}
+		case "notes.txt":
+			if m.Image != nil {
+				t.Errorf("notes.txt: image = %+v, want nil", m.Image)
+			}
+		}
+	}
+}
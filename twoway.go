@@ -0,0 +1,495 @@
+package go_hidrive
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"maps"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"time"
+)
+
+// SyncConflictPolicy - the way [Client.TwoWaySync] resolves files changed on both sides since the previous sync.
+type SyncConflictPolicy int
+
+const (
+	SyncConflictReport   SyncConflictPolicy = iota // Conflicts are only reported, both versions are left as they are
+	SyncConflictNewer                              // The version with the later modification time replaces the other one
+	SyncConflictKeepBoth                           // The local version is kept under a new name on both sides
+)
+
+/*
+TwoWaySync - synchronize the local directory `localDir` and the HiDrive directory `remotePath` in both directions.
+
+The last synced version of every path is recorded in [SyncOptions.State] (see [SyncRecord]), which should be saved
+and passed to the next run. Both trees are compared with the recorded versions: local files are changed if their
+size or modification time differ, remote files if their size, modification time or content hash (chash) differ.
+Changes made on one side only are applied to the other: new and changed files are transferred, deleted files are
+deleted and new directories are created. Directories deleted on one side are deleted on the other once they are
+empty, directories which still contain something are kept.
+
+Files changed on both sides are compared by content hash, if the contents are the same only the state is updated.
+Otherwise the file is reported as [SyncConflict] and resolved according to [SyncOptions.Conflict]:
+  - [SyncConflictReport] - nothing is changed, the conflict is reported again by the next run
+  - [SyncConflictNewer] - the version with the later modification time wins, the remote one if they are the same
+  - [SyncConflictKeepBoth] - the local version is uploaded with `on_exist=autoname`, the local file is renamed to
+    the name picked by HiDrive and the remote version is downloaded under the original name. With [SyncOptions.DryRun]
+    the copy is reported under the original name.
+
+With [SyncConflictNewer] and [SyncConflictKeepBoth] a changed file wins over a deleted one. A file on one side in
+place of a directory on the other is always only reported. Without a state every file existing on both sides is
+considered changed on both sides, so the first run only reports files whose contents differ.
+
+When the state records any paths, a missing local or remote root directory is an error rather than a deletion of
+everything on the other side. Remote names which can not be used locally stop the sync with [ErrInvalidName] before
+anything is changed. Files which are neither regular files nor directories are skipped.
+
+The sync stops at the first error, the returned report then lists the changes made so far and the state
+records all of them.
+*/
+func (c *Client) TwoWaySync(ctx context.Context, localDir, remotePath string, opts *SyncOptions) (*SyncReport, error) {
+	s := newSyncer(ctx, c.api, localDir, remotePath, opts)
+	s.opts.Compare = SyncCompareHash
+	t := &twoWay{
+		push:      s,
+		pull:      &mirror{ctx: ctx, api: c.api, opts: s.opts, remote: s.remote, local: localDir, report: s.report},
+		report:    s.report,
+		state:     s.opts.State,
+		local:     map[string]fs.FileInfo{},
+		dirs:      map[string]bool{},
+		conflicts: map[string]bool{},
+	}
+	if t.state == nil {
+		t.state = NewSyncState()
+	}
+	t.state.reset(s.remote, localDir)
+
+	if err := t.index(); err != nil {
+		return t.report, err
+	}
+	return t.report, t.run()
+}
+
+// twoWay holds the state of a single [Client.TwoWaySync] call.
+type twoWay struct {
+	push   *syncer // uploads and remote changes
+	pull   *mirror // downloads and local changes
+	report *SyncReport
+	state  *SyncState
+
+	local     map[string]fs.FileInfo // local objects by relative path
+	dirs      map[string]bool        // directories existing on both sides
+	conflicts map[string]bool        // directories of a different type on each side
+	prune     []pruneEntry           // directories deleted on one side
+}
+
+// pruneEntry - a directory to be deleted once it is empty, `local` tells the side.
+type pruneEntry struct {
+	rel   string
+	local bool
+}
+
+// index lists both trees, creating missing roots.
+func (t *twoWay) index() error {
+	if err := t.push.index(); err != nil {
+		return err
+	}
+	for rel, obj := range t.push.objects {
+		if rel == "." {
+			continue
+		}
+		if err := checkLocalName(obj.Name); err != nil {
+			return fmt.Errorf("%s: %w", t.push.remotePath(path.Dir(rel)), err)
+		}
+	}
+	if t.push.objects["."] == nil {
+		if len(t.state.Paths) > 0 {
+			return fmt.Errorf("%s: %w", t.push.remote, ErrNotFound)
+		}
+		if _, err := t.push.mkdir(".", time.Time{}, time.Time{}); err != nil {
+			return err
+		}
+	}
+
+	info, err := os.Lstat(t.push.local)
+	switch {
+	case err == nil && !info.IsDir():
+		return fmt.Errorf("%s: not a directory", t.push.local)
+	case err != nil && (!os.IsNotExist(err) || len(t.state.Paths) > 0):
+		return err
+	case err != nil:
+		t.report.add(".", SyncMkdir, true, true, 0)
+		if t.push.opts.DryRun {
+			return nil
+		}
+		return os.MkdirAll(t.push.local, 0o755)
+	}
+
+	err = filepath.WalkDir(t.push.local, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(t.push.local, p)
+		if err != nil {
+			return err
+		}
+		t.local[filepath.ToSlash(rel)], err = d.Info()
+		return err
+	})
+	delete(t.local, ".")
+	return err
+}
+
+// run syncs all paths existing on either side or recorded in the state.
+func (t *twoWay) run() error {
+	paths := map[string]bool{}
+	for rel := range t.push.objects {
+		paths[rel] = true
+	}
+	for rel := range t.local {
+		paths[rel] = true
+	}
+	for rel := range t.state.Paths {
+		paths[rel] = true
+	}
+	delete(paths, ".")
+
+	for _, rel := range slices.Sorted(maps.Keys(paths)) {
+		if err := t.push.ctx.Err(); err != nil {
+			return err
+		}
+		if err := t.syncPath(rel); err != nil {
+			return err
+		}
+	}
+
+	for _, p := range slices.Backward(t.prune) {
+		if err := t.pruneDir(p.rel, p.local); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// syncPath syncs a single file or directory.
+func (t *twoWay) syncPath(rel string) error {
+	for dir := path.Dir(rel); dir != "."; dir = path.Dir(dir) {
+		if t.conflicts[dir] {
+			return nil
+		}
+	}
+
+	info, obj := t.local[rel], t.push.objects[rel]
+	rec, synced := t.state.Paths[rel]
+	if info != nil && !info.IsDir() && !info.Mode().IsRegular() {
+		return nil
+	}
+
+	localDir, remoteDir := info != nil && info.IsDir(), obj != nil && obj.Type == "dir"
+	switch {
+	case info != nil && obj != nil && localDir != remoteDir:
+		t.conflicts[rel] = true
+		t.report.add(rel, SyncConflict, false, false, 0)
+		return nil
+	case localDir || remoteDir || info == nil && obj == nil && rec.Dir:
+		return t.syncDir(rel, info, obj, synced && rec.Dir)
+	}
+	return t.syncFile(rel, info, obj, rec, synced && !rec.Dir)
+}
+
+// syncDir syncs the directory, `synced` tells whether it existed on both sides after the previous sync.
+func (t *twoWay) syncDir(rel string, info fs.FileInfo, obj *Object, synced bool) error {
+	switch {
+	case info != nil && obj != nil:
+		t.dirs[rel] = true
+		t.record(rel, SyncRecord{Dir: true})
+	case info == nil && obj == nil:
+		t.forget(rel)
+	case synced:
+		t.prune = append(t.prune, pruneEntry{rel: rel, local: info != nil})
+	default:
+		return t.ensureDir(rel)
+	}
+	return nil
+}
+
+/*
+syncFile syncs the file, `rec` is its version recorded by the previous sync if `synced` is set.
+Both `info` and `obj` are nil if the file does not exist on the corresponding side.
+*/
+func (t *twoWay) syncFile(rel string, info fs.FileInfo, obj *Object, rec SyncRecord, synced bool) error {
+	localChanged, remoteChanged := info != nil, obj != nil
+	if synced {
+		localChanged = info == nil || info.Size() != rec.Size || info.ModTime().Unix() != rec.MTime
+		remoteChanged = obj == nil || obj.Size != rec.Size || time.Time(obj.MTime).Unix() != rec.MTime ||
+			obj.CHash != rec.CHash
+	}
+
+	switch {
+	case !localChanged && !remoteChanged:
+		t.report.Unchanged++
+		return nil
+	case !remoteChanged && info == nil:
+		t.forget(rel)
+		return t.push.delete(rel, obj, time.Time{})
+	case !remoteChanged:
+		return t.upload(rel, info, obj != nil)
+	case !localChanged && obj == nil:
+		t.forget(rel)
+		return t.pull.delete(rel, info)
+	case !localChanged:
+		return t.download(rel, obj, info != nil)
+	case info == nil && obj == nil:
+		t.forget(rel)
+		return nil
+	}
+
+	if info != nil && obj != nil && info.Size() == obj.Size {
+		same, err := t.sameContent(rel, obj)
+		if err != nil {
+			return err
+		}
+		if same {
+			return t.adopt(rel, info, obj)
+		}
+	}
+	return t.conflict(rel, info, obj)
+}
+
+// adopt records the remote file with the same content as the local one, setting the local modification time.
+func (t *twoWay) adopt(rel string, info fs.FileInfo, obj *Object) error {
+	t.report.Unchanged++
+	if mtime := time.Time(obj.MTime); info.ModTime().Unix() != mtime.Unix() {
+		t.report.add(rel, SyncSetMTime, false, true, obj.Size)
+		if t.push.opts.DryRun {
+			return nil
+		}
+		if err := os.Chtimes(t.push.localPath(rel), mtime, mtime); err != nil {
+			return err
+		}
+	}
+	t.record(rel, remoteRecord(obj))
+	return nil
+}
+
+// conflict reports the file changed on both sides and resolves the conflict according to the policy.
+func (t *twoWay) conflict(rel string, info fs.FileInfo, obj *Object) error {
+	t.report.add(rel, SyncConflict, false, false, 0)
+	switch policy := t.push.opts.Conflict; {
+	case policy == SyncConflictReport:
+		return nil
+	case info == nil:
+		return t.download(rel, obj, false)
+	case obj == nil:
+		return t.upload(rel, info, false)
+	case policy == SyncConflictKeepBoth:
+		return t.keepBoth(rel, info, obj)
+	case info.ModTime().Unix() > time.Time(obj.MTime).Unix():
+		return t.upload(rel, info, true)
+	default:
+		return t.download(rel, obj, true)
+	}
+}
+
+// keepBoth uploads the local file under a new name, renames the local file to it and downloads the remote file.
+func (t *twoWay) keepBoth(rel string, info fs.FileInfo, obj *Object) error {
+	if t.push.opts.DryRun {
+		t.report.add(rel, SyncUpload, false, false, info.Size())
+		t.report.add(rel, SyncRename, false, true, info.Size())
+		return t.download(rel, obj, false)
+	}
+
+	params := NewParameters().SetDir(t.push.remotePath(path.Dir(rel))).SetName(path.Base(rel)).
+		SetMTime(info.ModTime()).SetOnExist("autoname")
+	copied, err := t.push.send(rel, info, params, false)
+	if err != nil {
+		return err
+	}
+	if err := checkLocalName(copied.Name); err != nil {
+		return err
+	}
+	copyRel := path.Join(path.Dir(rel), copied.Name)
+	t.report.add(copyRel, SyncUpload, false, false, info.Size())
+	if err := t.recordUpload(copyRel, info, copied); err != nil {
+		return err
+	}
+
+	target := t.push.localPath(copyRel)
+	if _, err := os.Lstat(target); err == nil {
+		return fmt.Errorf("%s: %w", target, fs.ErrExist)
+	}
+	t.report.add(copyRel, SyncRename, false, true, info.Size())
+	if err := os.Rename(t.push.localPath(rel), target); err != nil {
+		return err
+	}
+	return t.download(rel, obj, false)
+}
+
+// upload uploads the local file, replacing the remote one if `overwrite` is set.
+func (t *twoWay) upload(rel string, info fs.FileInfo, overwrite bool) error {
+	if err := t.ensureDir(path.Dir(rel)); err != nil {
+		return err
+	}
+	action := SyncUpload
+	if overwrite {
+		action = SyncUpdate
+	}
+	t.report.add(rel, action, false, false, info.Size())
+	if t.push.opts.DryRun {
+		return nil
+	}
+
+	obj, err := t.push.upload(rel, info, time.Time{}, overwrite)
+	if err != nil {
+		return err
+	}
+	return t.recordUpload(rel, info, obj)
+}
+
+// download downloads the remote file, replacing the local one if `overwrite` is set.
+func (t *twoWay) download(rel string, obj *Object, overwrite bool) error {
+	if err := t.ensureDir(path.Dir(rel)); err != nil {
+		return err
+	}
+	action := SyncDownload
+	if overwrite {
+		action = SyncUpdate
+	}
+	t.report.add(rel, action, false, true, obj.Size)
+	if t.push.opts.DryRun {
+		return nil
+	}
+
+	if err := t.pull.download(rel, obj); err != nil {
+		return err
+	}
+	t.record(rel, remoteRecord(obj))
+	return nil
+}
+
+// ensureDir creates the directory and its parents on the side where they are missing.
+func (t *twoWay) ensureDir(rel string) error {
+	if rel == "." || t.dirs[rel] {
+		return nil
+	}
+	if err := t.ensureDir(path.Dir(rel)); err != nil {
+		return err
+	}
+
+	info := t.local[rel]
+	if t.push.objects[rel] == nil {
+		mtime := time.Now()
+		if info != nil {
+			mtime = info.ModTime()
+		}
+		if _, err := t.push.mkdir(rel, mtime, time.Time{}); err != nil {
+			return err
+		}
+		t.push.objects[rel] = &Object{Name: path.Base(rel), Type: "dir"}
+	}
+	if info == nil {
+		t.report.add(rel, SyncMkdir, true, true, 0)
+		if !t.push.opts.DryRun {
+			if err := os.Mkdir(t.push.localPath(rel), 0o755); err != nil {
+				return err
+			}
+		}
+	}
+
+	t.dirs[rel] = true
+	t.record(rel, SyncRecord{Dir: true})
+	return nil
+}
+
+/*
+pruneDir deletes the directory deleted on the other side if it is empty, `local` tells the side to delete it from.
+With [SyncOptions.DryRun] the deletion is always reported.
+*/
+func (t *twoWay) pruneDir(rel string, local bool) error {
+	if t.dirs[rel] {
+		return nil
+	}
+	if t.push.opts.DryRun {
+		t.report.add(rel, SyncDelete, true, local, 0)
+		return nil
+	}
+
+	if local {
+		entries, err := os.ReadDir(t.push.localPath(rel))
+		if err != nil || len(entries) > 0 {
+			return err
+		}
+		if err := os.Remove(t.push.localPath(rel)); err != nil {
+			return err
+		}
+	} else {
+		err := Dir{t.push.api}.Delete(t.push.ctx, NewParameters().SetPath(t.push.remotePath(rel)).Values)
+		switch {
+		case errors.Is(err, ErrConflict):
+			return nil
+		case errors.Is(err, ErrNotFound):
+			t.forget(rel)
+			return nil
+		case err != nil:
+			return err
+		}
+	}
+	t.report.add(rel, SyncDelete, true, local, 0)
+	t.forget(rel)
+	return nil
+}
+
+// sameContent reports whether the local file has the same content as the remote one comparing its content hash with
+// `chash` of the remote file.
+func (t *twoWay) sameContent(rel string, obj *Object) (bool, error) {
+	f, err := os.Open(t.push.localPath(rel))
+	if err != nil {
+		return false, err
+	}
+	defer f.Close()
+
+	sum, err := ContentHash(f)
+	if err != nil {
+		return false, err
+	}
+	return sum == obj.CHash, nil
+}
+
+/*
+recordUpload stores the version of the file uploaded from the local file in the state. The content hash is the one
+of the remote file as computed by HiDrive, it is requested with [Meta.Get] if the upload response lacks it.
+*/
+func (t *twoWay) recordUpload(rel string, info fs.FileInfo, obj *Object) error {
+	if obj.CHash == "" {
+		var err error
+		params := NewParameters().SetPid(obj.ID).SetFields([]string{"id", "chash"})
+		if obj, err = (Meta{t.push.api}).Get(t.push.ctx, params.Values); err != nil {
+			return err
+		}
+	}
+	t.record(rel, SyncRecord{Size: info.Size(), MTime: info.ModTime().Unix(), CHash: obj.CHash, PID: obj.ID})
+	return nil
+}
+
+// record stores the synced version of the path in the state.
+func (t *twoWay) record(rel string, rec SyncRecord) {
+	if !t.push.opts.DryRun {
+		t.state.Paths[rel] = rec
+	}
+}
+
+// forget removes the path from the state.
+func (t *twoWay) forget(rel string) {
+	if !t.push.opts.DryRun {
+		delete(t.state.Paths, rel)
+	}
+}
+
+// remoteRecord returns the version of the remote file.
+func remoteRecord(obj *Object) SyncRecord {
+	return SyncRecord{Size: obj.Size, MTime: time.Time(obj.MTime).Unix(), CHash: obj.CHash, PID: obj.ID}
+}
@@ -0,0 +1,274 @@
+package go_hidrive_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"testing"
+	"time"
+
+	hidrive "github.com/Burmuley/go-hidrive"
+	"github.com/Burmuley/go-hidrive/hidrivetest"
+)
+
+func TestClient_TwoWaySync(t *testing.T) {
+	later := syncMTime.Add(time.Hour)
+	ctx := context.Background()
+
+	// setup returns a client and both trees synced with the returned state
+	setup := func(t *testing.T) (*hidrive.Client, *hidrivetest.Server, string, *hidrive.SyncState) {
+		remote := map[string]string{"/public/sync/a.txt": "a", "/public/sync/same.txt": "same", "/public/sync/r/x.txt": "x"}
+		srv, client := hidrivetest.NewClient(t, remote, syncMTime)
+		local := t.TempDir()
+		writeLocalTree(t, local, map[string]string{"b.txt": "b", "same.txt": "same", "l/y.txt": "y", "l/empty/": ""}, later)
+
+		state := hidrive.NewSyncState()
+		report, err := client.TwoWaySync(ctx, local, "/public/sync", &hidrive.SyncOptions{State: state})
+		if err != nil {
+			t.Fatalf("TwoWaySync() error = %v", err)
+		}
+		want := []string{
+			"download a.txt", "upload b.txt", "mkdir l", "mkdir l/empty", "upload l/y.txt",
+			"mkdir r", "download r/x.txt", "mtime same.txt",
+		}
+		if got := syncActions(report); !reflect.DeepEqual(got, want) {
+			t.Fatalf("TwoWaySync() actions = %q, want %q", got, want)
+		}
+		return client, srv, local, state
+	}
+	localFile := func(t *testing.T, local, rel string) string {
+		t.Helper()
+		data, err := os.ReadFile(filepath.Join(local, filepath.FromSlash(rel)))
+		if err != nil {
+			t.Errorf("ReadFile() error = %v", err)
+		}
+		return string(data)
+	}
+	writeLocal := func(t *testing.T, local, rel, content string, mtime time.Time) {
+		t.Helper()
+		name := filepath.Join(local, filepath.FromSlash(rel))
+		if err := os.WriteFile(name, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Chtimes(name, mtime, mtime); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	t.Run("propagation", func(t *testing.T) {
+		client, srv, local, state := setup(t)
+		for _, rel := range []string{"a.txt", "b.txt", "l/y.txt", "r/x.txt"} {
+			if got, _ := srv.ReadFile("/public/sync/" + rel); string(got) != localFile(t, local, rel) {
+				t.Errorf("%s: remote %q, local %q", rel, got, localFile(t, local, rel))
+			}
+		}
+
+		report, err := client.TwoWaySync(ctx, local, "/public/sync", &hidrive.SyncOptions{State: state})
+		if err != nil {
+			t.Fatalf("TwoWaySync() error = %v", err)
+		}
+		if len(report.Entries) != 0 || report.Unchanged != 5 {
+			t.Errorf("TwoWaySync() actions = %q, unchanged = %d", syncActions(report), report.Unchanged)
+		}
+
+		// changes on each side go to the other one
+		writeLocal(t, local, "b.txt", "B", later.Add(time.Hour))
+		if err := os.Remove(filepath.Join(local, "l", "y.txt")); err != nil {
+			t.Fatal(err)
+		}
+		if err := srv.AddFile("/public/sync/r/x.txt", []byte("X"), later); err != nil {
+			t.Fatalf("AddFile() error = %v", err)
+		}
+		if err := os.Chmod(filepath.Join(local, "r", "x.txt"), 0o640); err != nil {
+			t.Fatal(err)
+		}
+		if err := client.File.Delete(ctx, hidrive.NewParameters().SetPath("/public/sync/a.txt").Values); err != nil {
+			t.Fatalf("Delete() error = %v", err)
+		}
+		saved := filepath.Join(t.TempDir(), "state.json")
+		if err := state.Save(saved); err != nil {
+			t.Fatalf("Save() error = %v", err)
+		}
+		if state, err = hidrive.LoadSyncState(saved); err != nil {
+			t.Fatalf("LoadSyncState() error = %v", err)
+		}
+
+		report, err = client.TwoWaySync(ctx, local, "/public/sync", &hidrive.SyncOptions{State: state})
+		if err != nil {
+			t.Fatalf("TwoWaySync() error = %v", err)
+		}
+		want := []string{"delete a.txt", "update b.txt", "delete l/y.txt", "update r/x.txt"}
+		if got := syncActions(report); !reflect.DeepEqual(got, want) {
+			t.Errorf("TwoWaySync() actions = %q, want %q", got, want)
+		}
+		if _, err := os.Stat(filepath.Join(local, "a.txt")); !errors.Is(err, os.ErrNotExist) {
+			t.Errorf("a.txt has not been deleted locally: %v", err)
+		}
+		if srv.Exists("/public/sync/l/y.txt") {
+			t.Errorf("l/y.txt has not been deleted remotely")
+		}
+		if got, _ := srv.ReadFile("/public/sync/b.txt"); string(got) != "B" {
+			t.Errorf("remote b.txt = %q, want %q", got, "B")
+		}
+		if got := localFile(t, local, "r/x.txt"); got != "X" {
+			t.Errorf("local r/x.txt = %q, want %q", got, "X")
+		}
+		if got := mustStat(t, filepath.Join(local, "r", "x.txt")).Mode().Perm(); runtime.GOOS != "windows" && got != 0o640 {
+			t.Errorf("mode of local r/x.txt = %v, want %v", got, os.FileMode(0o640))
+		}
+		if len(state.Paths) != 6 {
+			t.Errorf("state paths = %v", state.Paths)
+		}
+	})
+
+	t.Run("invalid remote name", func(t *testing.T) {
+		transport := &nameTransport{from: `"name":"r"`, to: `"name":".."`}
+		srv, client := hidrivetest.NewClient(t, map[string]string{"/public/sync/r/x.txt": "x"}, syncMTime,
+			hidrive.WithHTTPClient(&http.Client{Transport: transport}))
+		transport.base = srv.Client().Transport
+		local := filepath.Join(t.TempDir(), "sync")
+
+		if _, err := client.TwoWaySync(ctx, local, "/public/sync", nil); !errors.Is(err, hidrive.ErrInvalidName) {
+			t.Errorf("TwoWaySync() error = %v, want %v", err, hidrive.ErrInvalidName)
+		}
+		if entries, _ := os.ReadDir(filepath.Dir(local)); len(entries) != 0 {
+			t.Errorf("TwoWaySync() modified the local filesystem: %v", entries)
+		}
+	})
+
+	t.Run("deleted directories", func(t *testing.T) {
+		client, srv, local, state := setup(t)
+		if err := os.RemoveAll(filepath.Join(local, "l")); err != nil {
+			t.Fatal(err)
+		}
+		params := hidrive.NewParameters().SetPath("/public/sync/r").SetRecursive(true)
+		if err := client.Dir.Delete(ctx, params.Values); err != nil {
+			t.Fatalf("Delete() error = %v", err)
+		}
+
+		report, err := client.TwoWaySync(ctx, local, "/public/sync", &hidrive.SyncOptions{State: state, DryRun: true})
+		if err != nil {
+			t.Fatalf("TwoWaySync() error = %v", err)
+		}
+		want := []string{"delete l/y.txt", "delete r/x.txt", "delete r", "delete l/empty", "delete l"}
+		if got := syncActions(report); !reflect.DeepEqual(got, want) {
+			t.Errorf("TwoWaySync() dry run actions = %q, want %q", got, want)
+		}
+		if !srv.Exists("/public/sync/l/y.txt") || len(state.Paths) != 8 {
+			t.Errorf("dry run modified the remote tree or the state")
+		}
+
+		report, err = client.TwoWaySync(ctx, local, "/public/sync", &hidrive.SyncOptions{State: state})
+		if err != nil {
+			t.Fatalf("TwoWaySync() error = %v", err)
+		}
+		if got := syncActions(report); !reflect.DeepEqual(got, want) {
+			t.Errorf("TwoWaySync() actions = %q, want %q", got, want)
+		}
+		if srv.Exists("/public/sync/l") {
+			t.Errorf("l has not been deleted remotely")
+		}
+		if _, err := os.Stat(filepath.Join(local, "r")); !errors.Is(err, os.ErrNotExist) {
+			t.Errorf("r has not been deleted locally: %v", err)
+		}
+		if len(state.Paths) != 3 {
+			t.Errorf("state paths = %v", state.Paths)
+		}
+
+		// a missing root is not taken for deleted contents
+		if err := os.RemoveAll(local); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := client.TwoWaySync(ctx, local, "/public/sync", &hidrive.SyncOptions{State: state}); err == nil {
+			t.Errorf("TwoWaySync() of missing local directory error = nil")
+		}
+		if !srv.Exists("/public/sync/a.txt") {
+			t.Errorf("a.txt has been deleted remotely")
+		}
+	})
+
+	tests := []struct {
+		name       string
+		policy     hidrive.SyncConflictPolicy
+		want       []string
+		wantLocal  map[string]string
+		wantRemote map[string]string
+	}{
+		{
+			name:       "report",
+			policy:     hidrive.SyncConflictReport,
+			want:       []string{"conflict a.txt", "conflict b.txt"},
+			wantLocal:  map[string]string{"a.txt": "local a", "b.txt": ""},
+			wantRemote: map[string]string{"a.txt": "remote a", "b.txt": "remote b"},
+		},
+		{
+			name:       "newer",
+			policy:     hidrive.SyncConflictNewer,
+			want:       []string{"conflict a.txt", "update a.txt", "conflict b.txt", "download b.txt"},
+			wantLocal:  map[string]string{"a.txt": "local a", "b.txt": "remote b"},
+			wantRemote: map[string]string{"a.txt": "local a", "b.txt": "remote b"},
+		},
+		{
+			name:       "keep both",
+			policy:     hidrive.SyncConflictKeepBoth,
+			want:       []string{"conflict a.txt", "upload a (1).txt", "rename a (1).txt", "download a.txt", "conflict b.txt", "download b.txt"},
+			wantLocal:  map[string]string{"a.txt": "remote a", "a (1).txt": "local a", "b.txt": "remote b"},
+			wantRemote: map[string]string{"a.txt": "remote a", "a (1).txt": "local a", "b.txt": "remote b"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run("conflict "+tt.name, func(t *testing.T) {
+			client, srv, local, state := setup(t)
+			writeLocal(t, local, "a.txt", "local a", later.Add(2*time.Hour))
+			if err := srv.AddFile("/public/sync/a.txt", []byte("remote a"), later.Add(time.Hour)); err != nil {
+				t.Fatalf("AddFile() error = %v", err)
+			}
+			if err := os.Remove(filepath.Join(local, "b.txt")); err != nil {
+				t.Fatal(err)
+			}
+			if err := srv.AddFile("/public/sync/b.txt", []byte("remote b"), later.Add(time.Hour)); err != nil {
+				t.Fatalf("AddFile() error = %v", err)
+			}
+
+			opts := &hidrive.SyncOptions{State: state, Conflict: tt.policy}
+			report, err := client.TwoWaySync(ctx, local, "/public/sync", opts)
+			if err != nil {
+				t.Fatalf("TwoWaySync() error = %v", err)
+			}
+			if got := syncActions(report); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("TwoWaySync() actions = %q, want %q", got, tt.want)
+			}
+			for rel, content := range tt.wantLocal {
+				if content == "" {
+					if _, err := os.Stat(filepath.Join(local, rel)); !errors.Is(err, os.ErrNotExist) {
+						t.Errorf("local %s exists", rel)
+					}
+				} else if got := localFile(t, local, rel); got != content {
+					t.Errorf("local %s = %q, want %q", rel, got, content)
+				}
+			}
+			for rel, content := range tt.wantRemote {
+				if got, _ := srv.ReadFile("/public/sync/" + rel); string(got) != content {
+					t.Errorf("remote %s = %q, want %q", rel, got, content)
+				}
+			}
+
+			// resolved conflicts are synced, reported ones are reported again
+			report, err = client.TwoWaySync(ctx, local, "/public/sync", opts)
+			if err != nil {
+				t.Fatalf("TwoWaySync() error = %v", err)
+			}
+			wantConflicts := 0
+			if tt.policy == hidrive.SyncConflictReport {
+				wantConflicts = 2
+			}
+			if got := report.Count(hidrive.SyncConflict); len(report.Entries) != got || got != wantConflicts {
+				t.Errorf("TwoWaySync() second run actions = %q", syncActions(report))
+			}
+		})
+	}
+}
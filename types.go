@@ -2,6 +2,7 @@ package go_hidrive
 
 import (
 	"encoding/json"
+	"fmt"
 	"net/url"
 	"strconv"
 	"time"
@@ -47,9 +48,24 @@ type Object struct {
 	Writable     bool           `json:"writable"`
 	Shareable    bool           `json:"shareable"`
 	MIMEType     string         `json:"mime_type"`
+	Category     string         `json:"category"`
+	Image        *Image         `json:"image"`
 	RShare       []*ShareObject `json:"rshare"`
 }
 
+/*
+Image - image properties of a file, included in [Object] when requested with `image.*` fields
+(e.g. "image.width", "members.image.exif") for files of the "image" category.
+
+`Exif` holds selected EXIF tags of the image as returned by HiDrive (e.g. "DateTimeOriginal", "Make", "Model"),
+it is nil if not requested or not available.
+*/
+type Image struct {
+	Width  int            `json:"width"`
+	Height int            `json:"height"`
+	Exif   map[string]any `json:"exif"`
+}
+
 func (h *Object) UnmarshalJSON(b []byte) error {
 	type HiDriveObjectAlias Object
 	defaultObject := HiDriveObjectAlias{
@@ -125,3 +141,89 @@ type ShareInviteResponse struct {
 	Done   []ShareInviteStatus `json:"done"`
 	Failed []ShareInviteStatus `json:"failed"`
 }
+
+/*
+FileHash - result of [File.Hash].
+
+`List` contains one list of hex-encoded checksums of the requested `Level` for every requested range,
+in the order the ranges were given. `CHash` is the content hash of the whole file (see [NewHash]).
+*/
+type FileHash struct {
+	Level int        `json:"level"`
+	CHash string     `json:"chash"`
+	List  [][]string `json:"list"`
+}
+
+// HashRange - inclusive range of checksum indices of a single level, used with [Parameters.SetRanges].
+type HashRange struct {
+	Start int64
+	End   int64
+}
+
+// String returns the range in "start-end" form used by HiDrive.
+func (r HashRange) String() string {
+	return fmt.Sprintf("%d-%d", r.Start, r.End)
+}
+
+// SnapshotObject represents HiDrive snapshot, see [Snapshot].
+type SnapshotObject struct {
+	ID      string `json:"id"`
+	Name    string `json:"name"`
+	Type    string `json:"type"` // "manual" for snapshots created with [Snapshot.Create], otherwise created by HiDrive
+	Created Time   `json:"created"`
+	Size    int64  `json:"size"`
+}
+
+/*
+UserObject represents HiDrive user and account information, see [User.Me] and [User.Get].
+
+Property `Quota` reports the quota of the user if one is set, the quota of the account otherwise.
+*/
+type UserObject struct {
+	Account       string          `json:"account"`
+	Alias         string          `json:"alias"`
+	Descriptor    string          `json:"descriptor"`
+	Email         string          `json:"email"`
+	EmailPending  string          `json:"email_pending"`
+	EmailVerified bool            `json:"email_verified"`
+	Encrypted     bool            `json:"encrypted"`
+	Home          string          `json:"home"`
+	HomeID        string          `json:"home_id"`
+	IsAdmin       bool            `json:"is_admin"`
+	IsOwner       bool            `json:"is_owner"`
+	Language      string          `json:"language"`
+	Protocols     map[string]bool `json:"protocols"`
+	Quota         *Quota          `json:"quota"`
+}
+
+// PermissionObject represents access rights of a user or group to a filesystem object, see [Permission.Get].
+type PermissionObject struct {
+	Account  string `json:"account"`
+	ID       string `json:"id"`
+	Path     string `json:"path"`
+	Readable bool   `json:"readable"`
+	Writable bool   `json:"writable"`
+}
+
+// Rights returns the access rights as [Rights] value.
+func (p PermissionObject) Rights() Rights {
+	switch {
+	case p.Readable && p.Writable:
+		return RightsReadWrite
+	case p.Readable:
+		return RightsRead
+	default:
+		return RightsNone
+	}
+}
+
+// Quota represents storage quota of HiDrive account or user, sizes are in bytes.
+type Quota struct {
+	Total int64 `json:"total"`
+	Used  int64 `json:"used"`
+}
+
+// Free returns the number of bytes that can still be stored.
+func (q Quota) Free() int64 {
+	return max(q.Total-q.Used, 0)
+}
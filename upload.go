@@ -0,0 +1,544 @@
+package go_hidrive
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"io/fs"
+	"net/url"
+	"path"
+
+	"github.com/google/uuid"
+)
+
+const (
+	DefaultChunkSize        = 64 << 20 // Default size of a chunk for [File.ChunkedUpload]
+	DefaultMaxChunkAttempts = 3        // Default number of attempts to upload a single chunk
+)
+
+var (
+	ErrSizeMismatch = errors.New("size mismatch")         // size of uploaded or downloaded file does not match
+	ErrHashMismatch = errors.New("content hash mismatch") // content hash of uploaded file does not match the source
+)
+
+// uploadFields - object fields requested by [File.ChunkedUpload] and [File.ResumableUpload] to verify the upload.
+var uploadFields = []string{"id", "name", "path", "type", "size", "mtime", "ctime", "parent_id", "chash"}
+
+/*
+ChunkedUploadOptions - options for [File.ChunkedUpload].
+
+Property `ChunkSize` defines the size of data sent in a single request, it must not exceed the request body size
+limit of HiDrive (2G), defaults to [DefaultChunkSize].
+
+Property `MaxChunkAttempts` defines how many times a single chunk is tried to be uploaded if the upload fails with
+a transient error (network failure or 5xx status), defaults to [DefaultMaxChunkAttempts].
+It is the only retry count for appended chunks: [Api.RetryPolicy] does not retry them, it only defines the backoff.
+
+Property `Progress` is an optional callback called after every chunk with the total number of bytes uploaded.
+
+Property `Overwrite` makes the first chunk to be uploaded with [File.Update] instead of [File.Upload],
+so an existing file is replaced instead of failing with [ErrConflict].
+
+Property `SkipHashCheck` disables checking the content hash (`chash`) of the uploaded file against the hash of the
+source computed locally with [NewHash].
+*/
+type ChunkedUploadOptions struct {
+	ChunkSize        int64
+	MaxChunkAttempts int
+	Progress         func(uploaded int64)
+	Overwrite        bool
+	SkipHashCheck    bool
+}
+
+// withDefaults returns a copy of options with default values applied.
+func (o *ChunkedUploadOptions) withDefaults() ChunkedUploadOptions {
+	out := ChunkedUploadOptions{}
+	if o != nil {
+		out = *o
+	}
+	if out.ChunkSize <= 0 {
+		out.ChunkSize = DefaultChunkSize
+	}
+	if out.MaxChunkAttempts <= 0 {
+		out.MaxChunkAttempts = DefaultMaxChunkAttempts
+	}
+	return out
+}
+
+/*
+ChunkedUpload - upload a file of any size, including files larger than the 2G request body limit of [File.Upload].
+
+The content is read from `r` and sent in chunks of `opts.ChunkSize` bytes: the first chunk creates a new file
+using [File.Upload] (or [File.Update] if `opts.Overwrite` is set), all subsequent chunks are appended to the file
+using [File.Patch] with the corresponding offset. Each appended chunk is tried up to `opts.MaxChunkAttempts` times
+on transient errors (instead of [Api.RetryPolicy] retries), as writing the same data at the same offset is
+idempotent. The first chunk is not retried by this method (only by [Api.RetryPolicy]), as creating a file is not
+idempotent.
+
+After all chunks are uploaded the file is verified with [Meta.Get]: if the size of the remote file differs from
+the number of bytes read from `r`, an error wrapping [ErrSizeMismatch] is returned, if its content hash (`chash`)
+differs from the hash of the data read from `r` (see [NewHash]), an error wrapping [ErrHashMismatch] is returned.
+The hash is not checked if `opts.SkipHashCheck` is set.
+
+If the upload fails in the middle, the partially uploaded file is left on HiDrive.
+
+Supported parameters are the same as for [File.Upload]:
+  - dir ([Parameters.SetDir])
+  - dir_id ([Parameters.SetDirId])
+  - name ([Parameters.SetName])
+  - on_exist ([Parameters.SetOnExist])
+  - mtime ([Parameters.SetMTime])
+  - parent_mtime ([Parameters.SetParentMTime])
+
+Returns [Object] with information about uploaded file.
+*/
+func (f File) ChunkedUpload(ctx context.Context, params url.Values, r io.Reader, opts *ChunkedUploadOptions) (*Object, error) {
+	o := opts.withDefaults()
+
+	var h hash.Hash
+	if !o.SkipHashCheck {
+		h = NewHash()
+		r = io.TeeReader(r, h)
+	}
+	buf := make([]byte, o.ChunkSize)
+	chunk, err := readChunk(r, buf)
+	if err != nil {
+		return nil, err
+	}
+
+	obj, err := f.uploadFirstChunk(ctx, params, chunk, o)
+	if err != nil {
+		return nil, err
+	}
+	offset := int64(len(chunk))
+	if o.Progress != nil {
+		o.Progress(offset)
+	}
+
+	if offset == o.ChunkSize {
+		if offset, err = f.uploadChunks(ctx, obj.ID, offset, r, buf, o, nil); err != nil {
+			return nil, err
+		}
+	}
+
+	var chash string
+	if h != nil {
+		chash = hex.EncodeToString(h.Sum(nil))
+	}
+	return f.finishUpload(ctx, obj.ID, offset, params.Get("mtime"), chash)
+}
+
+// uploadFirstChunk creates the file with the first chunk of data.
+func (f File) uploadFirstChunk(ctx context.Context, params url.Values, chunk []byte, o ChunkedUploadOptions) (*Object, error) {
+	if o.Overwrite {
+		return f.Update(ctx, params, newBytesBody(chunk))
+	}
+	return f.Upload(ctx, params, newBytesBody(chunk))
+}
+
+// uploadChunks appends chunks read from `r` to the file with `pid` starting at `offset`, returns the final offset.
+func (f File) uploadChunks(ctx context.Context, pid string, offset int64, r io.Reader, buf []byte, o ChunkedUploadOptions, onChunk func(offset int64) error) (int64, error) {
+	for {
+		chunk, err := readChunk(r, buf)
+		if err != nil {
+			return offset, err
+		}
+		if len(chunk) == 0 {
+			return offset, nil
+		}
+
+		if err := f.patchChunk(ctx, pid, offset, chunk, o.MaxChunkAttempts); err != nil {
+			return offset, fmt.Errorf("chunk at offset %d: %w", offset, err)
+		}
+		offset += int64(len(chunk))
+
+		if o.Progress != nil {
+			o.Progress(offset)
+		}
+		if onChunk != nil {
+			if err := onChunk(offset); err != nil {
+				return offset, err
+			}
+		}
+		if int64(len(chunk)) < o.ChunkSize {
+			return offset, nil
+		}
+	}
+}
+
+// patchChunk writes the chunk at `offset` retrying transient failures, see Api.retryAttempts.
+func (f File) patchChunk(ctx context.Context, pid string, offset int64, chunk []byte, attempts int) error {
+	params := NewParameters().SetPid(pid).SetOffset(offset).Values
+	return f.retryAttempts(ctx, attempts, func(single Api) error {
+		return File{single}.Patch(ctx, params, newBytesBody(chunk))
+	})
+}
+
+/*
+finishUpload restores requested mtime (changed by partial updates) and verifies the size and the content hash
+(hex-encoded `chash`) of the uploaded file, empty `chash` skips the hash check.
+*/
+func (f File) finishUpload(ctx context.Context, pid string, size int64, mtime, chash string) (*Object, error) {
+	meta := Meta{f.Api}
+	if mtime != "" {
+		params := NewParameters().SetPid(pid)
+		params.Set("mtime", mtime)
+		if _, err := meta.Update(ctx, params.Values); err != nil {
+			return nil, err
+		}
+	}
+
+	obj, err := meta.Get(ctx, NewParameters().SetPid(pid).SetFields(uploadFields).Values)
+	if err != nil {
+		return nil, err
+	}
+	if obj.Size != size {
+		return obj, fmt.Errorf("%w: uploaded %d bytes, remote file size is %d", ErrSizeMismatch, size, obj.Size)
+	}
+	if chash != "" && obj.CHash != chash {
+		return obj, fmt.Errorf("%w: source hash is %s, remote file hash is %s", ErrHashMismatch, chash, obj.CHash)
+	}
+	return obj, nil
+}
+
+// readChunk reads up to len(buf) bytes from the reader into buf, returns shorter slice at the end of data.
+func readChunk(r io.Reader, buf []byte) ([]byte, error) {
+	n, err := io.ReadFull(r, buf)
+	if err != nil && !errors.Is(err, io.EOF) && !errors.Is(err, io.ErrUnexpectedEOF) {
+		return nil, err
+	}
+	return buf[:n], nil
+}
+
+/*
+UploadState - progress of a resumable upload performed by [File.ResumableUpload].
+
+The state records the parameters used to create the remote file, the temporary name the file is uploaded under,
+its public id (pid) once the file is created and the number of bytes committed on HiDrive. It can be serialized
+to JSON (see [UploadState.Save] and [LoadUploadState]) to resume the upload in a separate process invocation.
+*/
+type UploadState struct {
+	Params   url.Values `json:"params"`    // parameters used to create the file, see [File.Upload]
+	TempName string     `json:"temp_name"` // name of the file until the upload is complete, empty until it is picked
+	PID      string     `json:"pid"`       // public id of the remote file, empty until the file is created
+	Offset   int64      `json:"offset"`    // number of bytes committed on HiDrive
+	Size     int64      `json:"size"`      // total size of the source, -1 if not known yet
+	Done     bool       `json:"done"`      // whether the upload has been completed
+}
+
+// NewUploadState - create new instance of [UploadState] for uploading a file with the given parameters.
+func NewUploadState(params url.Values) *UploadState {
+	return &UploadState{Params: params, Size: -1}
+}
+
+// LoadUploadState reads [UploadState] previously stored with [UploadState.Save] from the file.
+func LoadUploadState(name string) (*UploadState, error) {
+	state := &UploadState{}
+	if err := loadJSON(name, "upload state", state); err != nil {
+		return nil, err
+	}
+	return state, nil
+}
+
+// Save writes the state as JSON to the file, the file is replaced atomically.
+func (s *UploadState) Save(name string) error {
+	return saveJSON(name, s)
+}
+
+/*
+ResumableUploadOptions - options for [File.ResumableUpload].
+
+Besides the options of chunked upload, property `Checkpoint` is an optional callback called every time the state
+changes (after the temporary name is picked, after the file is created and after each chunk committed on HiDrive),
+use it to persist the state, e.g. with [UploadState.Save]. An error returned from the callback aborts the upload.
+*/
+type ResumableUploadOptions struct {
+	ChunkedUploadOptions
+	Checkpoint func(state *UploadState) error
+}
+
+/*
+ResumableUpload - upload a file in chunks recording the progress in `state`, so the upload can be continued
+after a failure from the last committed offset instead of starting from the beginning.
+
+On the first call a unique temporary name is picked and passed to `opts.Checkpoint` in `state.TempName`, then an
+empty file with this name is created in the target directory of `state.Params` using [File.Upload] and its pid is
+passed to `opts.Checkpoint` before any data is sent. If the previous run died before the pid was recorded, the empty
+file with the recorded temporary name is taken over. Once all data is uploaded, the file is renamed to the name from
+`state.Params` with [File.Rename]: an existing file with this name is replaced if `opts.Overwrite` is set, otherwise
+`on_exist` of `state.Params` applies and the upload fails with [ErrConflict] if it is not set, leaving the uploaded
+file under the temporary name. Objects not created by the upload itself are never written to.
+
+When called with a state of an interrupted upload, the size of the remote file is queried with [Meta.Get] and
+used as the offset to continue from, as it reflects the data actually committed on HiDrive. The source `r` is
+positioned to that offset and the remaining chunks are appended using [File.Patch].
+
+The size of `r` must stay the same between invocations, otherwise an error wrapping [ErrSizeMismatch] is returned.
+Once all chunks are uploaded, the whole `r` is read again to verify the content hash of the remote file (unless
+`opts.SkipHashCheck` is set), a mismatch (e.g. `r` has been modified between invocations) is reported with an error
+wrapping [ErrHashMismatch].
+If the remote file disappeared, an error wrapping [ErrNotFound] is returned and the upload should be started over
+with a new state. As HiDrive assigns a new pid on rename, this also happens if the previous run died right after
+renaming the file, before the new pid was passed to `opts.Checkpoint`.
+
+Example resuming the upload in another process:
+
+	state, err := hidrive.LoadUploadState("upload.json")
+	if errors.Is(err, os.ErrNotExist) {
+		state = hidrive.NewUploadState(hidrive.NewParameters().SetFilePath("/public/disk.img").Values)
+	}
+	obj, err := fileApi.ResumableUpload(ctx, state, file, &hidrive.ResumableUploadOptions{
+		Checkpoint: func(s *hidrive.UploadState) error { return s.Save("upload.json") },
+	})
+
+Returns [Object] with information about uploaded file.
+*/
+func (f File) ResumableUpload(ctx context.Context, state *UploadState, r io.ReadSeeker, opts *ResumableUploadOptions) (*Object, error) {
+	var o ResumableUploadOptions
+	if opts != nil {
+		o = *opts
+	}
+	co := o.ChunkedUploadOptions.withDefaults()
+	checkpoint := func() error {
+		if o.Checkpoint != nil {
+			return o.Checkpoint(state)
+		}
+		return nil
+	}
+
+	size, err := r.Seek(0, io.SeekEnd)
+	if err != nil {
+		return nil, err
+	}
+	if state.Size < 0 {
+		state.Size = size
+	} else if state.Size != size {
+		return nil, fmt.Errorf("%w: source size is %d, upload state expects %d", ErrSizeMismatch, size, state.Size)
+	}
+
+	buf := make([]byte, co.ChunkSize)
+	if state.PID == "" {
+		if state.TempName == "" {
+			state.TempName = fmt.Sprintf(".%s.%s.upload", state.Params.Get("name"), uuid.NewString())
+			if err := checkpoint(); err != nil {
+				return nil, err
+			}
+		}
+		obj, err := f.createUploadTarget(ctx, state.Params, state.TempName)
+		if err != nil {
+			return nil, err
+		}
+		state.PID, state.Offset = obj.ID, 0
+		if err := checkpoint(); err != nil {
+			return nil, err
+		}
+	} else {
+		remote, err := Meta{f.Api}.Get(ctx, NewParameters().SetPid(state.PID).SetFields([]string{"id", "size"}).Values)
+		if err != nil {
+			return nil, fmt.Errorf("resuming upload of %s: %w", state.PID, err)
+		}
+		if remote.Size > size {
+			return nil, fmt.Errorf("%w: remote file size %d exceeds source size %d", ErrSizeMismatch, remote.Size, size)
+		}
+		if remote.Size != state.Offset {
+			state.Offset = remote.Size
+			if err := checkpoint(); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if state.Offset < size {
+		if _, err := r.Seek(state.Offset, io.SeekStart); err != nil {
+			return nil, err
+		}
+		_, err := f.uploadChunks(ctx, state.PID, state.Offset, r, buf, co, func(offset int64) error {
+			state.Offset = offset
+			return checkpoint()
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var chash string
+	if !co.SkipHashCheck {
+		if _, err := r.Seek(0, io.SeekStart); err != nil {
+			return nil, err
+		}
+		if chash, err = ContentHash(r); err != nil {
+			return nil, err
+		}
+	}
+	obj, err := f.finishUpload(ctx, state.PID, size, state.Params.Get("mtime"), chash)
+	if err != nil {
+		return nil, err
+	}
+	if obj.Name == state.TempName {
+		if obj, err = f.renameUploadTarget(ctx, state.PID, state.Params, co.Overwrite); err != nil {
+			return nil, err
+		}
+		state.PID = obj.ID
+	}
+	state.Done = true
+	if err := checkpoint(); err != nil {
+		return nil, err
+	}
+	return obj, nil
+}
+
+/*
+createUploadTarget creates an empty file named `tempName` in the target directory of [File.ResumableUpload].
+The name is unique and recorded in the upload state before the file is created, so an empty file already existing
+under this name is left by a run which died before saving its pid and is returned instead of failing with
+[ErrConflict].
+*/
+func (f File) createUploadTarget(ctx context.Context, params url.Values, tempName string) (*Object, error) {
+	create := NewParameters()
+	for key, values := range params {
+		create.Values[key] = values
+	}
+	create.SetName(tempName).Del("on_exist")
+	obj, err := f.Upload(ctx, create.Values, newBytesBody(nil))
+	if !errors.Is(err, ErrConflict) {
+		return obj, err
+	}
+
+	target := NewParameters().SetPath(path.Join(params.Get("dir"), tempName))
+	if dirId := params.Get("dir_id"); dirId != "" {
+		target.SetPid(dirId)
+	}
+	existing, lookupErr := Meta{f.Api}.Get(ctx, target.SetFields([]string{"id", "type", "size"}).Values)
+	if lookupErr != nil || existing.Type != "file" || existing.Size != 0 {
+		return nil, err
+	}
+	return existing, nil
+}
+
+// renameUploadTarget gives the file uploaded by [File.ResumableUpload] its final name, the file gets a new pid.
+func (f File) renameUploadTarget(ctx context.Context, pid string, params url.Values, overwrite bool) (*Object, error) {
+	rename := NewParameters().SetPid(pid).SetName(params.Get("name"))
+	if onExist := params.Get("on_exist"); onExist != "" {
+		rename.SetOnExist(onExist)
+	}
+	if overwrite {
+		rename.SetOnExist("overwrite")
+	}
+	if parentMTime := params.Get("parent_mtime"); parentMTime != "" {
+		rename.Set("parent_mtime", parentMTime)
+	}
+	obj, err := f.Rename(ctx, rename.Values)
+	if err != nil {
+		return nil, err
+	}
+	return Meta{f.Api}.Get(ctx, NewParameters().SetPid(obj.ID).SetFields(uploadFields).Values)
+}
+
+/*
+UploadWriter - io.WriteCloser uploading data written to it to a file on HiDrive, see [File.Create].
+
+Data is uploaded in the background while it is being written, Write blocks until the data is consumed by the upload.
+If the upload fails, subsequent Write calls return the upload error. Close must always be called: it finalizes the
+upload and reports its result. UploadWriter must not be used from multiple goroutines concurrently.
+*/
+type UploadWriter struct {
+	pw     *io.PipeWriter
+	done   chan struct{}
+	closed bool
+	obj    *Object
+	err    error
+}
+
+/*
+Create - create a file on HiDrive and return [UploadWriter] which uploads everything written to it into the file.
+
+This is convenient when the content is produced incrementally, e.g. by gzip.Writer, tar.Writer or json.Encoder:
+
+	w := fileApi.Create(ctx, hidrive.NewParameters().SetFilePath("/public/data.json.gz").Values, nil)
+	zw := gzip.NewWriter(w)
+	if err := json.NewEncoder(zw).Encode(data); err != nil {
+		w.CloseWithError(err)
+		return err
+	}
+	if err := zw.Close(); err != nil {
+		w.CloseWithError(err)
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	obj := w.Object()
+
+The data is uploaded with [File.ChunkedUpload] using the given options, so files of any size are supported
+and only one chunk is kept in memory. Cancelling `ctx` aborts the upload, Write and Close return the context error.
+If the upload is aborted after the first chunk was sent, the partially uploaded file is left on HiDrive.
+
+Supported parameters are the same as for [File.ChunkedUpload].
+*/
+func (f File) Create(ctx context.Context, params url.Values, opts *ChunkedUploadOptions) *UploadWriter {
+	pr, pw := io.Pipe()
+	w := &UploadWriter{pw: pw, done: make(chan struct{})}
+
+	go func() {
+		select {
+		case <-ctx.Done():
+			pr.CloseWithError(ctx.Err())
+		case <-w.done:
+		}
+	}()
+
+	go func() {
+		defer close(w.done)
+		w.obj, w.err = f.ChunkedUpload(ctx, params, pr, opts)
+		if w.err != nil {
+			pr.CloseWithError(w.err)
+		} else {
+			pr.Close()
+		}
+	}()
+
+	return w
+}
+
+// Write writes data to the file, it returns an error if the upload has failed.
+func (w *UploadWriter) Write(p []byte) (int, error) {
+	if w.closed {
+		return 0, fs.ErrClosed
+	}
+	return w.pw.Write(p)
+}
+
+// Close finishes the upload and waits for its completion, it returns nil if the file has been uploaded successfully.
+func (w *UploadWriter) Close() error {
+	return w.CloseWithError(nil)
+}
+
+/*
+CloseWithError aborts the upload with the given error which is then returned from Close.
+If `err` is nil, it finishes the upload the same way as Close does.
+*/
+func (w *UploadWriter) CloseWithError(err error) error {
+	if w.closed {
+		return fs.ErrClosed
+	}
+	w.closed = true
+
+	if err != nil {
+		w.pw.CloseWithError(err)
+	} else {
+		w.pw.Close()
+	}
+	<-w.done
+	return w.err
+}
+
+// Object returns [Object] with information about the uploaded file, it is nil until Close succeeds.
+func (w *UploadWriter) Object() *Object {
+	if !w.closed || w.err != nil {
+		return nil
+	}
+	return w.obj
+}
@@ -0,0 +1,548 @@
+package go_hidrive_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"io/fs"
+	"math/rand"
+	"net/http"
+	"regexp"
+	"slices"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	hidrive "github.com/Burmuley/go-hidrive"
+	"github.com/Burmuley/go-hidrive/hidrivetest"
+)
+
+// countingTransport counts requests made through it.
+type countingTransport struct {
+	base  http.RoundTripper
+	count int64
+}
+
+func (t *countingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	atomic.AddInt64(&t.count, 1)
+	return t.base.RoundTrip(req)
+}
+
+func randomBytes(size int) []byte {
+	buf := make([]byte, size)
+	rand.New(rand.NewSource(int64(size))).Read(buf)
+	return buf
+}
+
+func newTestFile(srv *hidrivetest.Server) hidrive.File {
+	fileApi := hidrive.NewFile(srv.Client(), srv.Endpoint())
+	fileApi.RetryPolicy = hidrive.NewRetryPolicy()
+	fileApi.RetryPolicy.MinBackoff = time.Millisecond
+	fileApi.RetryPolicy.MaxBackoff = time.Millisecond
+	return fileApi
+}
+
+func TestFile_ChunkedUpload(t *testing.T) {
+	type args struct {
+		size      int
+		chunkSize int64
+		mtime     time.Time
+		faults    int
+	}
+
+	tests := []struct {
+		name       string
+		args       args
+		wantChunks int
+		wantErr    bool
+	}{
+		{
+			name:       "single chunk",
+			args:       args{size: 100, chunkSize: 1024},
+			wantChunks: 1,
+		},
+		{
+			name:       "empty file",
+			args:       args{size: 0, chunkSize: 1024},
+			wantChunks: 1,
+		},
+		{
+			name:       "size is a multiple of chunk size",
+			args:       args{size: 4096, chunkSize: 1024},
+			wantChunks: 4,
+		},
+		{
+			name:       "last chunk is shorter",
+			args:       args{size: 5000, chunkSize: 1024, mtime: time.Unix(1600000000, 0)},
+			wantChunks: 5,
+		},
+		{
+			name:       "failed chunks are retried",
+			args:       args{size: 5000, chunkSize: 1024, faults: 2},
+			wantChunks: 5,
+		},
+		{
+			name:    "chunk retries are exhausted",
+			args:    args{size: 5000, chunkSize: 1024, faults: 10},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := hidrivetest.NewServer()
+			defer srv.Close()
+			srv.MaxUploadSize = tt.args.chunkSize
+			srv.InjectError(http.MethodPatch, "file", http.StatusInternalServerError, tt.args.faults)
+			fileApi := newTestFile(srv)
+
+			data := randomBytes(tt.args.size)
+			params := hidrive.NewParameters().SetFilePath("/public/big.bin")
+			if !tt.args.mtime.IsZero() {
+				params.SetMTime(tt.args.mtime)
+			}
+			chunks := 0
+			opts := &hidrive.ChunkedUploadOptions{
+				ChunkSize: tt.args.chunkSize,
+				Progress:  func(int64) { chunks++ },
+			}
+
+			obj, err := fileApi.ChunkedUpload(context.Background(), params.Values, bytes.NewReader(data), opts)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ChunkedUpload() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if chunks != tt.wantChunks {
+				t.Errorf("ChunkedUpload() chunks = %d, want %d", chunks, tt.wantChunks)
+			}
+			if obj.Size != int64(tt.args.size) {
+				t.Errorf("ChunkedUpload() size = %d, want %d", obj.Size, tt.args.size)
+			}
+			if !tt.args.mtime.IsZero() && !time.Time(obj.MTime).Equal(tt.args.mtime) {
+				t.Errorf("ChunkedUpload() mtime = %v, want %v", time.Time(obj.MTime), tt.args.mtime)
+			}
+			if got, _ := srv.ReadFile("/public/big.bin"); !bytes.Equal(got, data) {
+				t.Errorf("ChunkedUpload() remote content differs from uploaded")
+			}
+		})
+	}
+
+	t.Run("retry policy does not multiply chunk attempts", func(t *testing.T) {
+		srv := hidrivetest.NewServer()
+		defer srv.Close()
+		transport := &countingTransport{base: srv.Client().Transport}
+		fileApi := newTestFile(srv)
+		fileApi.HTTPClient = &http.Client{Transport: transport}
+
+		// 503 is retried by the policy even for PATCH
+		srv.InjectError(http.MethodPatch, "file", http.StatusServiceUnavailable, 100)
+		opts := &hidrive.ChunkedUploadOptions{ChunkSize: 1024, MaxChunkAttempts: 3}
+		params := hidrive.NewParameters().SetFilePath("/public/big.bin")
+		if _, err := fileApi.ChunkedUpload(context.Background(), params.Values, bytes.NewReader(randomBytes(2000)), opts); err == nil {
+			t.Fatalf("ChunkedUpload() error = nil")
+		}
+		// the first chunk and three attempts of the second one
+		if transport.count != 4 {
+			t.Errorf("ChunkedUpload() requests = %d, want 4", transport.count)
+		}
+	})
+
+	t.Run("corrupted chunk is detected", func(t *testing.T) {
+		srv := hidrivetest.NewServer()
+		defer srv.Close()
+		fileApi := newTestFile(srv)
+		fileApi.HTTPClient = &http.Client{Transport: &corruptingTransport{base: srv.Client().Transport}}
+
+		opts := &hidrive.ChunkedUploadOptions{ChunkSize: 1024}
+		params := hidrive.NewParameters().SetFilePath("/public/big.bin")
+		if _, err := fileApi.ChunkedUpload(context.Background(), params.Values, bytes.NewReader(randomBytes(3000)), opts); !errors.Is(err, hidrive.ErrHashMismatch) {
+			t.Errorf("ChunkedUpload() error = %v, want %v", err, hidrive.ErrHashMismatch)
+		}
+	})
+
+	t.Run("different remote hash is not checked on request", func(t *testing.T) {
+		srv := hidrivetest.NewServer()
+		defer srv.Close()
+		fileApi := newTestFile(srv)
+		fileApi.HTTPClient = &http.Client{Transport: &chashTransport{base: srv.Client().Transport}}
+		data := randomBytes(3000)
+
+		opts := &hidrive.ChunkedUploadOptions{ChunkSize: 1024}
+		params := hidrive.NewParameters().SetFilePath("/public/big.bin")
+		if _, err := fileApi.ChunkedUpload(context.Background(), params.Values, bytes.NewReader(data), opts); !errors.Is(err, hidrive.ErrHashMismatch) {
+			t.Errorf("ChunkedUpload() error = %v, want %v", err, hidrive.ErrHashMismatch)
+		}
+
+		opts.SkipHashCheck, opts.Overwrite = true, true
+		if _, err := fileApi.ChunkedUpload(context.Background(), params.Values, bytes.NewReader(data), opts); err != nil {
+			t.Errorf("ChunkedUpload() error = %v", err)
+		}
+		if got, _ := srv.ReadFile("/public/big.bin"); !bytes.Equal(got, data) {
+			t.Errorf("ChunkedUpload() remote content differs from uploaded")
+		}
+	})
+}
+
+// chashTransport reverses `chash` values in all responses, like a server computing a different content hash.
+type chashTransport struct {
+	base http.RoundTripper
+}
+
+func (t *chashTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	res, err := t.base.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	body, err := io.ReadAll(res.Body)
+	res.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	body = regexp.MustCompile(`"chash":"[0-9a-f]*"`).ReplaceAllFunc(body, func(field []byte) []byte {
+		slices.Reverse(field[len(`"chash":"`) : len(field)-1])
+		return field
+	})
+	res.Body = io.NopCloser(bytes.NewReader(body))
+	res.ContentLength = int64(len(body))
+	res.Header.Del("Content-Length")
+	return res, nil
+}
+
+// corruptingTransport replaces the body of the first PATCH request with null bytes of the same length.
+type corruptingTransport struct {
+	base http.RoundTripper
+	done bool
+}
+
+func (t *corruptingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Method == http.MethodPatch && !t.done {
+		t.done = true
+		body, err := io.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		req = req.Clone(req.Context())
+		req.Body = io.NopCloser(bytes.NewReader(make([]byte, len(body))))
+	}
+	return t.base.RoundTrip(req)
+}
+
+func TestFile_ResumableUpload(t *testing.T) {
+	type args struct {
+		size        int
+		chunkSize   int64
+		interruptAt int // abort the first run after this number of checkpoints, 0 means no interruption
+		saveState   bool
+	}
+
+	tests := []struct {
+		name string
+		args args
+	}{
+		{
+			name: "upload without interruption",
+			args: args{size: 5000, chunkSize: 1024},
+		},
+		{
+			name: "resume after interruption",
+			args: args{size: 5000, chunkSize: 1024, interruptAt: 3, saveState: true},
+		},
+		{
+			name: "resume with stale state uses remote size",
+			args: args{size: 5000, chunkSize: 1024, interruptAt: 3},
+		},
+		{
+			name: "interrupted right after the temporary name was picked",
+			args: args{size: 5000, chunkSize: 1024, interruptAt: 1, saveState: true},
+		},
+		{
+			name: "interrupted right after the file was created",
+			args: args{size: 5000, chunkSize: 1024, interruptAt: 2, saveState: true},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := hidrivetest.NewServer()
+			defer srv.Close()
+			fileApi := newTestFile(srv)
+			ctx := context.Background()
+			stateFile := t.TempDir() + "/upload.json"
+			data := randomBytes(tt.args.size)
+
+			state := hidrive.NewUploadState(hidrive.NewParameters().SetFilePath("/public/resumable.bin").Values)
+			if err := state.Save(stateFile); err != nil {
+				t.Fatalf("Save() error = %v", err)
+			}
+
+			checkpoints := 0
+			opts := &hidrive.ResumableUploadOptions{
+				ChunkedUploadOptions: hidrive.ChunkedUploadOptions{ChunkSize: tt.args.chunkSize},
+				Checkpoint: func(s *hidrive.UploadState) error {
+					checkpoints++
+					if tt.args.interruptAt > 0 && checkpoints == tt.args.interruptAt {
+						if tt.args.saveState {
+							_ = s.Save(stateFile)
+						}
+						return context.Canceled
+					}
+					return s.Save(stateFile)
+				},
+			}
+
+			_, err := fileApi.ResumableUpload(ctx, state, bytes.NewReader(data), opts)
+			if tt.args.interruptAt > 0 {
+				if err == nil {
+					t.Fatalf("ResumableUpload() expected interruption")
+				}
+				opts.Checkpoint = func(s *hidrive.UploadState) error { return s.Save(stateFile) }
+				if state, err = hidrive.LoadUploadState(stateFile); err != nil {
+					t.Fatalf("LoadUploadState() error = %v", err)
+				}
+				_, err = fileApi.ResumableUpload(ctx, state, bytes.NewReader(data), opts)
+			}
+			if err != nil {
+				t.Fatalf("ResumableUpload() error = %v", err)
+			}
+
+			if got, _ := srv.ReadFile("/public/resumable.bin"); !bytes.Equal(got, data) {
+				t.Errorf("ResumableUpload() remote content differs from uploaded")
+			}
+			if final, err := hidrive.LoadUploadState(stateFile); err != nil || !final.Done || final.Offset != int64(tt.args.size) {
+				t.Errorf("LoadUploadState() = %+v, %v", final, err)
+			}
+		})
+	}
+
+	t.Run("run died before the pid was saved", func(t *testing.T) {
+		srv := hidrivetest.NewServer()
+		defer srv.Close()
+		fileApi := newTestFile(srv)
+		ctx := context.Background()
+		data := randomBytes(3000)
+
+		// the previous run saved the temporary name and created the file
+		state := hidrive.NewUploadState(hidrive.NewParameters().SetFilePath("/public/resumable.bin").Values)
+		opts := &hidrive.ResumableUploadOptions{
+			ChunkedUploadOptions: hidrive.ChunkedUploadOptions{ChunkSize: 1024},
+			Checkpoint: func(s *hidrive.UploadState) error {
+				if s.PID != "" {
+					return context.Canceled
+				}
+				return nil
+			},
+		}
+		if _, err := fileApi.ResumableUpload(ctx, state, bytes.NewReader(data), opts); err == nil {
+			t.Fatalf("ResumableUpload() expected interruption")
+		}
+		if !srv.Exists("/public/" + state.TempName) {
+			t.Fatalf("ResumableUpload() did not create /public/%s", state.TempName)
+		}
+
+		// the empty file with the recorded temporary name is taken over
+		state.PID, opts.Checkpoint = "", nil
+		obj, err := fileApi.ResumableUpload(ctx, state, bytes.NewReader(data), opts)
+		if err != nil {
+			t.Fatalf("ResumableUpload() error = %v", err)
+		}
+		if got, _ := srv.ReadFile("/public/resumable.bin"); !bytes.Equal(got, data) || obj.Name != "resumable.bin" {
+			t.Errorf("ResumableUpload() remote content differs from uploaded, name = %s", obj.Name)
+		}
+		if srv.Exists("/public/" + state.TempName) {
+			t.Errorf("ResumableUpload() left /public/%s", state.TempName)
+		}
+	})
+
+	t.Run("existing file is not taken over", func(t *testing.T) {
+		srv := hidrivetest.NewServer()
+		defer srv.Close()
+		fileApi := newTestFile(srv)
+		ctx := context.Background()
+		opts := &hidrive.ResumableUploadOptions{ChunkedUploadOptions: hidrive.ChunkedUploadOptions{ChunkSize: 1024}}
+
+		for _, content := range [][]byte{nil, []byte("keep")} {
+			if err := srv.AddFile("/public/keep.txt", content, time.Now()); err != nil {
+				t.Fatalf("AddFile() error = %v", err)
+			}
+			state := hidrive.NewUploadState(hidrive.NewParameters().SetFilePath("/public/keep.txt").Values)
+			if _, err := fileApi.ResumableUpload(ctx, state, bytes.NewReader([]byte("new data")), opts); !errors.Is(err, hidrive.ErrConflict) {
+				t.Errorf("ResumableUpload() error = %v, want %v", err, hidrive.ErrConflict)
+			}
+			if got, _ := srv.ReadFile("/public/keep.txt"); !bytes.Equal(got, content) {
+				t.Errorf("ResumableUpload() modified existing file: %q", got)
+			}
+		}
+
+		// the existing file is only replaced on request
+		opts.Overwrite = true
+		state := hidrive.NewUploadState(hidrive.NewParameters().SetFilePath("/public/keep.txt").Values)
+		if _, err := fileApi.ResumableUpload(ctx, state, bytes.NewReader([]byte("new data")), opts); err != nil {
+			t.Fatalf("ResumableUpload() error = %v", err)
+		}
+		if got, _ := srv.ReadFile("/public/keep.txt"); string(got) != "new data" {
+			t.Errorf("ResumableUpload() content = %q, want %q", got, "new data")
+		}
+	})
+
+	t.Run("pid is saved before data is sent", func(t *testing.T) {
+		srv := hidrivetest.NewServer()
+		defer srv.Close()
+		fileApi := newTestFile(srv)
+
+		state := hidrive.NewUploadState(hidrive.NewParameters().SetFilePath("/public/resumable.bin").Values)
+		checkpoints := 0
+		opts := &hidrive.ResumableUploadOptions{
+			ChunkedUploadOptions: hidrive.ChunkedUploadOptions{ChunkSize: 1024},
+			Checkpoint: func(s *hidrive.UploadState) error {
+				checkpoints++
+				if s.TempName == "" || checkpoints > 1 && s.PID == "" {
+					t.Errorf("Checkpoint() %d called with temporary name %q and pid %q", checkpoints, s.TempName, s.PID)
+				}
+				if s.PID != "" {
+					return context.Canceled
+				}
+				return nil
+			},
+		}
+		if _, err := fileApi.ResumableUpload(context.Background(), state, bytes.NewReader(randomBytes(3000)), opts); err == nil {
+			t.Fatalf("ResumableUpload() expected interruption")
+		}
+		if got, _ := srv.ReadFile("/public/" + state.TempName); len(got) != 0 || state.Offset != 0 {
+			t.Errorf("ResumableUpload() sent %d bytes before the pid was saved, offset = %d", len(got), state.Offset)
+		}
+	})
+
+	t.Run("source modified between runs is detected", func(t *testing.T) {
+		srv := hidrivetest.NewServer()
+		defer srv.Close()
+		fileApi := newTestFile(srv)
+		ctx := context.Background()
+		data := randomBytes(5000)
+
+		state := hidrive.NewUploadState(hidrive.NewParameters().SetFilePath("/public/resumable.bin").Values)
+		opts := &hidrive.ResumableUploadOptions{
+			ChunkedUploadOptions: hidrive.ChunkedUploadOptions{ChunkSize: 1024},
+			Checkpoint: func(s *hidrive.UploadState) error {
+				if s.Offset >= 2048 {
+					return context.Canceled
+				}
+				return nil
+			},
+		}
+		if _, err := fileApi.ResumableUpload(ctx, state, bytes.NewReader(data), opts); err == nil {
+			t.Fatalf("ResumableUpload() expected interruption")
+		}
+
+		// the already uploaded part changes, the size stays the same
+		modified := bytes.Clone(data)
+		modified[0] ^= 0xff
+		opts.Checkpoint = nil
+		if _, err := fileApi.ResumableUpload(ctx, state, bytes.NewReader(modified), opts); !errors.Is(err, hidrive.ErrHashMismatch) {
+			t.Errorf("ResumableUpload() error = %v, want %v", err, hidrive.ErrHashMismatch)
+		}
+	})
+}
+
+func TestFile_Create(t *testing.T) {
+	type args struct {
+		size      int
+		chunkSize int64
+		faults    int
+		abort     bool
+		cancel    bool
+	}
+
+	tests := []struct {
+		name    string
+		args    args
+		wantErr error
+	}{
+		{
+			name: "single chunk",
+			args: args{size: 100, chunkSize: 1024},
+		},
+		{
+			name: "empty file",
+			args: args{size: 0, chunkSize: 1024},
+		},
+		{
+			name: "multiple chunks",
+			args: args{size: 10000, chunkSize: 1024},
+		},
+		{
+			name:    "upload error is reported",
+			args:    args{size: 10000, chunkSize: 1024, faults: 100},
+			wantErr: hidrive.ErrInternal,
+		},
+		{
+			name:    "aborted by the producer",
+			args:    args{size: 10000, chunkSize: 1024, abort: true},
+			wantErr: io.ErrShortWrite,
+		},
+		{
+			name:    "context cancelled",
+			args:    args{size: 10000, chunkSize: 1024, cancel: true},
+			wantErr: context.Canceled,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := hidrivetest.NewServer()
+			defer srv.Close()
+			srv.InjectError(http.MethodPatch, "file", http.StatusInternalServerError, tt.args.faults)
+			fileApi := newTestFile(srv)
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			data := randomBytes(tt.args.size)
+			w := fileApi.Create(ctx, hidrive.NewParameters().SetFilePath("/public/stream.bin").Values,
+				&hidrive.ChunkedUploadOptions{ChunkSize: tt.args.chunkSize})
+
+			var err error
+			for off := 0; off < len(data) && err == nil; off += 300 {
+				if off >= len(data)/2 {
+					if tt.args.abort {
+						break
+					}
+					if tt.args.cancel {
+						cancel()
+					}
+				}
+				end := off + 300
+				if end > len(data) {
+					end = len(data)
+				}
+				_, err = w.Write(data[off:end])
+			}
+			if tt.args.abort {
+				err = w.CloseWithError(io.ErrShortWrite)
+			} else if cerr := w.Close(); err == nil {
+				err = cerr
+			}
+
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("UploadWriter error = %v, want %v", err, tt.wantErr)
+				}
+				if w.Object() != nil {
+					t.Errorf("Object() = %v, want nil", w.Object())
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("UploadWriter error = %v", err)
+			}
+			if obj := w.Object(); obj == nil || obj.Size != int64(tt.args.size) {
+				t.Errorf("Object() = %v, want size %d", obj, tt.args.size)
+			}
+			if got, _ := srv.ReadFile("/public/stream.bin"); !bytes.Equal(got, data) {
+				t.Errorf("UploadWriter remote content differs from written")
+			}
+			if _, err := w.Write([]byte("x")); !errors.Is(err, fs.ErrClosed) {
+				t.Errorf("Write() after Close() error = %v, want %v", err, fs.ErrClosed)
+			}
+		})
+	}
+}
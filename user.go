@@ -0,0 +1,230 @@
+package go_hidrive
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+)
+
+/*
+User - structure represents a set of methods for interacting with HiDrive `/user` API endpoint.
+
+Except [User.Me], all methods manage the users of the account (package) and require a token of an administrator.
+*/
+type User struct {
+	Api
+}
+
+/*
+NewUser - create new instance of User.
+
+Accepts http.Client and API endpoint as input parameters.
+If `endpoint` is empty string, then default `StratoHiDriveAPIV21` value is used.
+*/
+func NewUser(client *http.Client, endpoint string) User {
+	api := NewApi(client, endpoint)
+	return User{api}
+}
+
+/*
+Me - get information about the user the access token belongs to and the account: the home directory,
+the language, the storage quota and whether the user has administrative rights.
+
+Only a subset of the information is returned by default, use `fields` parameter to request everything needed
+(e.g. "home", "home_id", "quota").
+
+Status codes:
+  - 200 - OK
+  - 400 - Bad Request (e.g. invalid parameter)
+  - 401 - Unauthorized (no authentication)
+  - 403 - Forbidden
+  - 500 - Internal Error
+
+Supported parameters:
+  - fields ([Parameters.SetFields])
+
+Returns [UserObject] with information about the current user.
+*/
+func (u User) Me(ctx context.Context, params url.Values) (*UserObject, error) {
+	var (
+		res *http.Response
+		err error
+	)
+
+	if res, err = u.doGET(ctx, "user/me", params, []int{http.StatusOK}); err != nil {
+		return nil, err
+	}
+
+	obj := &UserObject{}
+	if err := u.unmarshalBody(res, obj); err != nil {
+		return nil, err
+	}
+
+	return obj, nil
+}
+
+/*
+Get - get the list of users of the account, or a single user if `alias` parameter is given.
+
+Status codes:
+  - 200 - OK
+  - 400 - Bad Request (e.g. invalid parameter)
+  - 401 - Unauthorized (no authentication)
+  - 403 - Forbidden (the user is not an administrator)
+  - 404 - Not Found (the user does not exist)
+  - 500 - Internal Error
+
+Supported parameters:
+  - alias ([Parameters.SetAlias])
+  - fields ([Parameters.SetFields])
+
+Returns slice of [UserObject] sorted by alias.
+*/
+func (u User) Get(ctx context.Context, params url.Values) ([]*UserObject, error) {
+	var (
+		res *http.Response
+		err error
+	)
+
+	if res, err = u.doGET(ctx, "user", params, []int{http.StatusOK}); err != nil {
+		return nil, err
+	}
+
+	var users []*UserObject
+	if err := u.unmarshalBody(res, &users); err != nil {
+		return nil, err
+	}
+
+	return users, nil
+}
+
+/*
+Create - create a new user of the account.
+
+The `alias` and `password` parameters are mandatory. The home directory is "/users/<alias>" unless `home` is given,
+the directory is created if it does not exist. Without `quota` the user can use the whole storage of the account.
+The password is sent in a form-encoded request body, so it does not end up in the URL (e.g. in logs of proxies).
+
+Status codes:
+  - 201 - Created
+  - 400 - Bad Request (e.g. invalid parameter, password too weak)
+  - 401 - Unauthorized (no authentication)
+  - 403 - Forbidden (the user is not an administrator)
+  - 409 - Conflict (the user already exists)
+  - 500 - Internal Error
+
+Supported parameters:
+  - alias ([Parameters.SetAlias])
+  - password ([Parameters.SetPassword])
+  - descriptor ([Parameters.SetDescriptor])
+  - email ([Parameters.SetEmail])
+  - home ([Parameters.SetHome])
+  - is_admin ([Parameters.SetIsAdmin])
+  - quota ([Parameters.SetQuota])
+
+Returns [UserObject] of the created user.
+*/
+func (u User) Create(ctx context.Context, params url.Values) (*UserObject, error) {
+	var (
+		res *http.Response
+		err error
+	)
+
+	if res, err = u.doUserRequest(ctx, http.MethodPost, params, http.StatusCreated); err != nil {
+		return nil, err
+	}
+
+	obj := &UserObject{}
+	if err := u.unmarshalBody(res, obj); err != nil {
+		return nil, err
+	}
+
+	return obj, nil
+}
+
+/*
+Update - update a user of the account: reset the password, change the home directory, the quota, etc.
+
+The `alias` parameter is mandatory and identifies the user, other parameters are only changed when given.
+Use [Parameters.SetQuota] with 0 to remove the quota of the user. As with [User.Create], the password is sent in
+the request body.
+
+Status codes:
+  - 200 - OK
+  - 400 - Bad Request (e.g. invalid parameter, password too weak)
+  - 401 - Unauthorized (no authentication)
+  - 403 - Forbidden (the user is not an administrator)
+  - 404 - Not Found (the user does not exist)
+  - 500 - Internal Error
+
+Supported parameters:
+  - alias ([Parameters.SetAlias])
+  - password ([Parameters.SetPassword])
+  - descriptor ([Parameters.SetDescriptor])
+  - email ([Parameters.SetEmail])
+  - home ([Parameters.SetHome])
+  - is_admin ([Parameters.SetIsAdmin])
+  - quota ([Parameters.SetQuota])
+
+Returns [UserObject] of the updated user.
+*/
+func (u User) Update(ctx context.Context, params url.Values) (*UserObject, error) {
+	var (
+		res *http.Response
+		err error
+	)
+
+	if res, err = u.doUserRequest(ctx, http.MethodPut, params, http.StatusOK); err != nil {
+		return nil, err
+	}
+
+	obj := &UserObject{}
+	if err := u.unmarshalBody(res, obj); err != nil {
+		return nil, err
+	}
+
+	return obj, nil
+}
+
+// doUserRequest sends the request to `/user` endpoint with `password` parameter moved to a form-encoded body.
+func (u User) doUserRequest(ctx context.Context, method string, params url.Values, okCode int) (*http.Response, error) {
+	query, form := url.Values{}, url.Values{}
+	for k, v := range params {
+		if k == "password" {
+			form[k] = v
+		} else {
+			query[k] = v
+		}
+	}
+	if len(form) == 0 {
+		return u.doHTTPRequest(ctx, method, "user", query, []int{okCode}, nil)
+	}
+
+	header := http.Header{"Content-Type": {"application/x-www-form-urlencoded"}}
+	body := newBytesBody([]byte(form.Encode()))
+	return u.doHTTPRequestWithHeader(ctx, method, "user", query, header, []int{okCode}, body)
+}
+
+/*
+Delete - delete a user of the account, the home directory and its content are kept.
+
+The owner of the account cannot be deleted.
+
+Status codes:
+  - 204 - No Content
+  - 400 - Bad Request (e.g. invalid parameter)
+  - 401 - Unauthorized (no authentication)
+  - 403 - Forbidden (the user is not an administrator or the owner is to be deleted)
+  - 404 - Not Found (the user does not exist)
+  - 500 - Internal Error
+
+Supported parameters:
+  - alias ([Parameters.SetAlias])
+*/
+func (u User) Delete(ctx context.Context, params url.Values) error {
+	if _, err := u.doDELETE(ctx, "user", params, []int{http.StatusNoContent}); err != nil {
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,160 @@
+package go_hidrive_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"testing"
+	"time"
+
+	hidrive "github.com/Burmuley/go-hidrive"
+	"github.com/Burmuley/go-hidrive/hidrivetest"
+)
+
+func TestUser_Me(t *testing.T) {
+	srv := hidrivetest.NewServer()
+	defer srv.Close()
+	srv.Quota = 1 << 30
+	if err := srv.AddFile("/users/test/data.bin", make([]byte, 1000), time.Now()); err != nil {
+		t.Fatalf("AddFile() error = %v", err)
+	}
+	client := hidrive.NewClient(hidrive.WithHTTPClient(srv.Client()), hidrive.WithEndpoint(srv.Endpoint()))
+	ctx := context.Background()
+
+	me, err := client.User.Me(ctx, hidrive.NewParameters().SetFields([]string{"alias", "home", "home_id", "is_admin", "quota"}).Values)
+	if err != nil {
+		t.Fatalf("Me() error = %v", err)
+	}
+	if me.Alias != hidrivetest.DefaultUser || !me.IsAdmin || me.Home != "/users/test" || me.HomeID == "" {
+		t.Errorf("Me() = %+v", me)
+	}
+	if me.Language != "" || me.Protocols != nil {
+		t.Errorf("Me() returned fields not requested: %+v", me)
+	}
+	if me.Quota == nil || me.Quota.Total != 1<<30 || me.Quota.Used != 1000 || me.Quota.Free() != 1<<30-1000 {
+		t.Errorf("Me() quota = %+v", me.Quota)
+	}
+
+	home, err := client.Meta.Get(ctx, hidrive.NewParameters().SetPid(me.HomeID).Values)
+	if err != nil {
+		t.Fatalf("Meta.Get() error = %v", err)
+	}
+	if home.Type != "dir" || home.Size != 1000 {
+		t.Errorf("home directory = %+v", home)
+	}
+
+	all, err := client.User.Me(ctx, nil)
+	if err != nil {
+		t.Fatalf("Me() error = %v", err)
+	}
+	if !all.IsOwner || all.Language == "" || all.Descriptor == "" || !all.Protocols["webdav"] {
+		t.Errorf("Me() = %+v", all)
+	}
+}
+
+func TestUser_Manage(t *testing.T) {
+	srv := hidrivetest.NewServer()
+	defer srv.Close()
+	userApi := hidrive.NewUser(srv.Client(), srv.Endpoint())
+	fileApi := hidrive.NewFile(srv.Client(), srv.Endpoint())
+	ctx := context.Background()
+
+	created, err := userApi.Create(ctx, hidrive.NewParameters().SetAlias("contractor").SetPassword("s3cret-pass").
+		SetEmail("contractor@example.com").SetQuota(10).Values)
+	if err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	if created.Alias != "contractor" || created.Home != "/users/contractor" || created.IsAdmin ||
+		created.Email != "contractor@example.com" || created.Quota == nil || created.Quota.Total != 10 {
+		t.Errorf("Create() = %+v", created)
+	}
+	if !srv.Exists("/users/contractor") {
+		t.Errorf("home directory of the created user does not exist")
+	}
+
+	tests := []struct {
+		name    string
+		params  *hidrive.Parameters
+		wantErr error
+	}{
+		{
+			name:    "existing user",
+			params:  hidrive.NewParameters().SetAlias("contractor").SetPassword("s3cret-pass"),
+			wantErr: hidrive.ErrConflict,
+		},
+		{
+			name:    "weak password",
+			params:  hidrive.NewParameters().SetAlias("other").SetPassword("short"),
+			wantErr: hidrive.ErrBadRequest,
+		},
+		{
+			name:    "missing password",
+			params:  hidrive.NewParameters().SetAlias("other"),
+			wantErr: hidrive.ErrBadRequest,
+		},
+	}
+	for _, tt := range tests {
+		t.Run("create "+tt.name, func(t *testing.T) {
+			_, err := userApi.Create(ctx, tt.params.Values)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("Create() error = %v, want %v", err, tt.wantErr)
+			}
+			// the password is sent in the body, not in the query
+			var hdErr *hidrive.Error
+			if errors.As(err, &hdErr) && hdErr.Params.Has("password") {
+				t.Errorf("Create() sent password in the query: %v", hdErr.Params)
+			}
+		})
+	}
+
+	// the quota of the user is enforced in its home directory only
+	upload := func(p string, size int) error {
+		_, err := fileApi.Upload(ctx, hidrive.NewParameters().SetFilePath(p).Values, io.NopCloser(bytes.NewReader(make([]byte, size))))
+		return err
+	}
+	if err := upload("/users/contractor/big.bin", 11); !errors.Is(err, hidrive.ErrInsufficientStorage) {
+		t.Errorf("Upload() over user quota error = %v, want %v", err, hidrive.ErrInsufficientStorage)
+	}
+	if err := upload("/public/big.bin", 11); err != nil {
+		t.Errorf("Upload() outside of home error = %v", err)
+	}
+
+	updated, err := userApi.Update(ctx, hidrive.NewParameters().SetAlias("contractor").SetPassword("new-s3cret-pass").
+		SetHome("/public/projects/contractor").SetQuota(0).Values)
+	if err != nil {
+		t.Fatalf("Update() error = %v", err)
+	}
+	if updated.Home != "/public/projects/contractor" || updated.Email != "contractor@example.com" || updated.Quota.Total != 0 {
+		t.Errorf("Update() = %+v", updated)
+	}
+	if _, err := userApi.Update(ctx, hidrive.NewParameters().SetAlias("missing").SetEmail("x@example.com").Values); !errors.Is(err, hidrive.ErrNotFound) {
+		t.Errorf("Update() of missing user error = %v, want %v", err, hidrive.ErrNotFound)
+	}
+
+	users, err := userApi.Get(ctx, hidrive.NewParameters().SetFields([]string{"alias", "is_owner"}).Values)
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	if len(users) != 2 || users[0].Alias != "contractor" || users[1].Alias != hidrivetest.DefaultUser || !users[1].IsOwner {
+		t.Errorf("Get() = %v", users)
+	}
+
+	if err := userApi.Delete(ctx, hidrive.NewParameters().SetAlias(hidrivetest.DefaultUser).Values); !errors.Is(err, hidrive.ErrForbidden) {
+		t.Errorf("Delete() of the owner error = %v, want %v", err, hidrive.ErrForbidden)
+	}
+	if err := userApi.Delete(ctx, hidrive.NewParameters().SetAlias("contractor").Values); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+	if _, err := userApi.Get(ctx, hidrive.NewParameters().SetAlias("contractor").Values); !errors.Is(err, hidrive.ErrNotFound) {
+		t.Errorf("Get() of deleted user error = %v, want %v", err, hidrive.ErrNotFound)
+	}
+
+	// without administrative rights users cannot be managed
+	if _, err := userApi.Update(ctx, hidrive.NewParameters().SetAlias(hidrivetest.DefaultUser).SetIsAdmin(false).Values); err != nil {
+		t.Fatalf("Update() error = %v", err)
+	}
+	if _, err := userApi.Get(ctx, nil); !errors.Is(err, hidrive.ErrForbidden) {
+		t.Errorf("Get() by non-admin error = %v, want %v", err, hidrive.ErrForbidden)
+	}
+}
@@ -1,5 +1,13 @@
 package go_hidrive
 
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
 func isItemInSlice[T comparable](slice []T, item T) bool {
 	for _, v := range slice {
 		if v == item {
@@ -9,3 +17,62 @@ func isItemInSlice[T comparable](slice []T, item T) bool {
 
 	return false
 }
+
+// bytesBody wraps a byte slice into a request body which can be replayed by [Api] on retries.
+type bytesBody struct {
+	*bytes.Reader
+}
+
+func newBytesBody(b []byte) *bytesBody {
+	return &bytesBody{bytes.NewReader(b)}
+}
+
+func (b *bytesBody) Close() error {
+	return nil
+}
+
+// loadJSON reads JSON stored with saveJSON from the file into `v`, `what` describes the content in errors.
+func loadJSON(name, what string, v any) error {
+	data, err := os.ReadFile(name)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return fmt.Errorf("%s %s: %w", what, name, err)
+	}
+	return nil
+}
+
+/*
+saveJSON writes `v` as JSON to the file atomically: the data is written to a new temporary file in the same
+directory, flushed to disk and renamed over the target, so concurrent savers do not collide and a crash never
+leaves a partially written file.
+*/
+func saveJSON(name string, v any) (err error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	f, err := os.CreateTemp(filepath.Dir(name), "."+filepath.Base(name)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			f.Close()
+			os.Remove(f.Name())
+		}
+	}()
+
+	if _, err = f.Write(data); err != nil {
+		return err
+	}
+	if err = f.Sync(); err != nil {
+		return err
+	}
+	if err = f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(f.Name(), name)
+}
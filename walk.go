@@ -0,0 +1,252 @@
+package go_hidrive
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"path"
+	"sort"
+	"sync"
+)
+
+var (
+	// SkipDir can be returned from [WalkFunc] to skip the directory, see [Dir.Walk].
+	SkipDir = fs.SkipDir
+	// SkipAll can be returned from [WalkFunc] to skip all remaining files and directories, see [Dir.Walk].
+	SkipAll = fs.SkipAll
+)
+
+// DefaultWalkFields - object fields requested by [Dir.Walk] when [WalkOptions.Fields] is empty.
+var DefaultWalkFields = []string{"id", "name", "type", "size", "mtime", "ctime", "mime_type"}
+
+/*
+WalkFunc - the type of function called by [Dir.Walk] for each file and directory.
+
+The `path` argument is the path of the object built from `root` and names of the members, `obj` contains information
+about the object. The semantics of `err` argument and returned value is the same as for fs.WalkDirFunc:
+  - if the root can not be queried, the function is called with nil `obj` and the error
+  - if a directory can not be listed, the function is called for the second time with the directory and the error
+  - returning [SkipDir] for a directory skips its contents, for a file - all remaining files in its parent directory
+  - returning [SkipAll] stops the walk, [Dir.Walk] then returns nil
+  - returning any other error stops the walk, [Dir.Walk] then returns that error
+*/
+type WalkFunc func(path string, obj *Object, err error) error
+
+/*
+WalkOptions - options for [Dir.Walk].
+
+Property `Workers` defines the number of directories listed concurrently, defaults to 1.
+Property `PageSize` defines the number of members requested at once, see [Dir.List].
+Property `Fields` defines object fields requested for every member, defaults to [DefaultWalkFields],
+fields "id", "name" and "type" are always requested as required by the walk.
+*/
+type WalkOptions struct {
+	Workers  int
+	PageSize uint
+	Fields   []string
+}
+
+/*
+Walk - walk the tree rooted at `root`, calling `fn` for each file or directory including `root`,
+modeled on filepath.WalkDir.
+
+Directories are listed using [Dir.List], so directories of any size are supported. Members of every directory are
+visited in lexical order of names. With a single worker (the default) the walk is performed depth-first and the order
+of calls is deterministic, exactly like filepath.WalkDir.
+
+With `opts.Workers` > 1 up to that number of directories is listed concurrently: `fn` is still never called
+concurrently and members of a directory are still visited in lexical order, but the order in which directories
+are visited is not defined, apart from a directory always being visited before its contents.
+
+The walk stops when `ctx` is cancelled, the context error is then passed to `fn` as an error listing a directory.
+*/
+func (d Dir) Walk(ctx context.Context, root string, fn WalkFunc, opts *WalkOptions) error {
+	w := newWalker(ctx, d, fn, opts)
+	defer w.cancel()
+
+	root = path.Clean(root)
+	obj, err := Meta{d.Api}.Get(w.ctx, NewParameters().SetPath(root).SetFields(w.fields).Values)
+	if err != nil {
+		err = fn(root, nil, err)
+	} else if w.workers == 1 {
+		err = w.walk(root, obj)
+	} else {
+		err = w.walkConcurrent(root, obj)
+	}
+
+	if errors.Is(err, SkipDir) || errors.Is(err, SkipAll) {
+		return nil
+	}
+	return err
+}
+
+// walker holds the state of a single [Dir.Walk] call.
+type walker struct {
+	ctx      context.Context
+	cancel   context.CancelFunc
+	dir      Dir
+	fn       WalkFunc
+	workers  int
+	pageSize uint
+	fields   []string
+
+	sem     chan struct{}
+	wg      sync.WaitGroup
+	mu      sync.Mutex // serializes calls of fn
+	stopped bool
+	err     error
+}
+
+func newWalker(ctx context.Context, d Dir, fn WalkFunc, opts *WalkOptions) *walker {
+	var o WalkOptions
+	if opts != nil {
+		o = *opts
+	}
+	if o.Workers < 1 {
+		o.Workers = 1
+	}
+	if len(o.Fields) == 0 {
+		o.Fields = DefaultWalkFields
+	}
+
+	fields := []string{"id", "name", "type"}
+	for _, f := range o.Fields {
+		if !isItemInSlice(fields, f) {
+			fields = append(fields, f)
+		}
+	}
+
+	w := &walker{dir: d, fn: fn, workers: o.Workers, pageSize: o.PageSize, fields: fields}
+	w.ctx, w.cancel = context.WithCancel(ctx)
+	w.sem = make(chan struct{}, o.Workers)
+	return w
+}
+
+// readDir returns all members of the directory sorted by name.
+func (w *walker) readDir(obj *Object) ([]*Object, error) {
+	fields := []string{"nmembers"}
+	for _, f := range w.fields {
+		fields = append(fields, "members."+f)
+	}
+
+	var members []*Object
+	it := w.dir.List(w.ctx, NewParameters().SetPid(obj.ID).SetFields(fields).Values, w.pageSize)
+	for it.Next() {
+		members = append(members, it.Object())
+	}
+	if err := it.Err(); err != nil {
+		return nil, err
+	}
+
+	sort.Slice(members, func(i, j int) bool { return members[i].Name < members[j].Name })
+	return members, nil
+}
+
+// walk visits the object and its contents sequentially, depth-first.
+func (w *walker) walk(name string, obj *Object) error {
+	if err := w.fn(name, obj, nil); err != nil || obj.Type != "dir" {
+		if errors.Is(err, SkipDir) && obj.Type == "dir" {
+			err = nil
+		}
+		return err
+	}
+
+	members, err := w.readDir(obj)
+	if err != nil {
+		if err = w.fn(name, obj, err); err != nil {
+			if errors.Is(err, SkipDir) {
+				err = nil
+			}
+			return err
+		}
+	}
+
+	for _, m := range members {
+		if err := w.walk(path.Join(name, m.Name), m); err != nil {
+			if errors.Is(err, SkipDir) {
+				break
+			}
+			return err
+		}
+	}
+	return nil
+}
+
+// walkConcurrent visits the root and lists directories in parallel, fn calls are serialized.
+func (w *walker) walkConcurrent(root string, obj *Object) error {
+	if err := w.fn(root, obj, nil); err != nil || obj.Type != "dir" {
+		return err
+	}
+
+	w.wg.Add(1)
+	go w.walkDir(root, obj)
+	w.wg.Wait()
+	return w.err
+}
+
+// walkDir lists the directory and visits its members, subdirectories are walked in new goroutines.
+func (w *walker) walkDir(name string, obj *Object) {
+	defer w.wg.Done()
+
+	var (
+		members []*Object
+		err     error
+	)
+	select {
+	case w.sem <- struct{}{}:
+		members, err = w.readDir(obj)
+		<-w.sem
+	case <-w.ctx.Done():
+		err = w.ctx.Err()
+	}
+
+	if err != nil {
+		if err = w.call(name, obj, err); err != nil && !errors.Is(err, SkipDir) {
+			w.stop(err)
+		}
+		return
+	}
+
+	for _, m := range members {
+		p := path.Join(name, m.Name)
+		if err := w.call(p, m, nil); err != nil {
+			if !errors.Is(err, SkipDir) {
+				w.stop(err)
+				return
+			}
+			if m.Type == "dir" {
+				continue
+			}
+			break
+		}
+		if m.Type == "dir" {
+			w.wg.Add(1)
+			go w.walkDir(p, m)
+		}
+	}
+}
+
+// call invokes fn unless the walk has been stopped.
+func (w *walker) call(name string, obj *Object, err error) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.stopped {
+		return SkipAll
+	}
+	return w.fn(name, obj, err)
+}
+
+// stop stops the walk recording the first error.
+func (w *walker) stop(err error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if !w.stopped {
+		w.stopped = true
+		if !errors.Is(err, SkipAll) {
+			w.err = err
+		}
+		w.cancel()
+	}
+}
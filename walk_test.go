@@ -0,0 +1,198 @@
+package go_hidrive_test
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"path"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	hidrive "github.com/Burmuley/go-hidrive"
+	"github.com/Burmuley/go-hidrive/hidrivetest"
+)
+
+var walkTree = []string{
+	"root/a.txt",
+	"root/b/c.txt",
+	"root/b/d/e.txt",
+	"root/b/d/f.txt",
+	"root/b/g.txt",
+	"root/h/",
+	"root/i/j.txt",
+	"root/i/k/l.txt",
+	"root/m.txt",
+}
+
+func newWalkServer(t *testing.T) (*hidrivetest.Server, fstest.MapFS) {
+	t.Helper()
+	srv := hidrivetest.NewServer()
+	mapFS := fstest.MapFS{}
+	for _, p := range walkTree {
+		if dir, ok := strings.CutSuffix(p, "/"); ok {
+			mapFS[dir] = &fstest.MapFile{Mode: fs.ModeDir}
+			if err := srv.AddDir("/public/" + dir); err != nil {
+				t.Fatalf("AddDir() error = %v", err)
+			}
+			continue
+		}
+		mapFS[p] = &fstest.MapFile{Data: []byte(p)}
+		if err := srv.AddFile("/public/"+p, []byte(p), time.Now()); err != nil {
+			t.Fatalf("AddFile() error = %v", err)
+		}
+	}
+	return srv, mapFS
+}
+
+func TestDir_Walk(t *testing.T) {
+	tests := []struct {
+		name string
+		// skip returns the error to be returned by the walk function for the path relative to /public
+		skip func(p string) error
+	}{
+		{
+			name: "whole tree",
+			skip: func(string) error { return nil },
+		},
+		{
+			name: "skip directory",
+			skip: func(p string) error {
+				if p == "root/b/d" || p == "root/i" {
+					return hidrive.SkipDir
+				}
+				return nil
+			},
+		},
+		{
+			name: "skip rest of directory from file",
+			skip: func(p string) error {
+				if p == "root/b/c.txt" {
+					return hidrive.SkipDir
+				}
+				return nil
+			},
+		},
+		{
+			name: "skip all",
+			skip: func(p string) error {
+				if p == "root/b/d/e.txt" {
+					return hidrive.SkipAll
+				}
+				return nil
+			},
+		},
+		{
+			name: "skip root",
+			skip: func(p string) error {
+				if p == "root" {
+					return hidrive.SkipDir
+				}
+				return nil
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv, mapFS := newWalkServer(t)
+			defer srv.Close()
+			dirApi := hidrive.NewDir(srv.Client(), srv.Endpoint())
+
+			var want []string
+			err := fs.WalkDir(mapFS, "root", func(p string, _ fs.DirEntry, err error) error {
+				if err != nil {
+					return err
+				}
+				want = append(want, p)
+				return tt.skip(p)
+			})
+			if err != nil {
+				t.Fatalf("fs.WalkDir() error = %v", err)
+			}
+
+			for _, workers := range []int{1, 4} {
+				var got []string
+				err := dirApi.Walk(context.Background(), "/public/root", func(p string, obj *hidrive.Object, err error) error {
+					if err != nil {
+						return err
+					}
+					rel := strings.TrimPrefix(p, "/public/")
+					if obj.Name != path.Base(p) {
+						t.Errorf("Walk() object name = %q, path = %q", obj.Name, p)
+					}
+					got = append(got, rel)
+					return tt.skip(rel)
+				}, &hidrive.WalkOptions{Workers: workers, PageSize: 2})
+				if err != nil {
+					t.Fatalf("Walk(workers=%d) error = %v", workers, err)
+				}
+
+				if workers == 1 {
+					if !reflect.DeepEqual(got, want) {
+						t.Errorf("Walk(workers=1) = %v, want %v", got, want)
+					}
+					continue
+				}
+				// concurrent walk visits the same objects in undefined order unless it's stopped by SkipAll
+				if tt.name == "skip all" {
+					continue
+				}
+				sort.Strings(got)
+				sortedWant := append([]string(nil), want...)
+				sort.Strings(sortedWant)
+				if !reflect.DeepEqual(got, sortedWant) {
+					t.Errorf("Walk(workers=%d) = %v, want %v", workers, got, sortedWant)
+				}
+			}
+		})
+	}
+}
+
+func TestDir_Walk_Errors(t *testing.T) {
+	srv, _ := newWalkServer(t)
+	defer srv.Close()
+	dirApi := hidrive.NewDir(srv.Client(), srv.Endpoint())
+	errStop := errors.New("stop")
+
+	for _, workers := range []int{1, 4} {
+		t.Run("root does not exist", func(t *testing.T) {
+			var gotErr error
+			err := dirApi.Walk(context.Background(), "/public/missing", func(p string, obj *hidrive.Object, err error) error {
+				gotErr = err
+				return err
+			}, &hidrive.WalkOptions{Workers: workers})
+			if !errors.Is(err, hidrive.ErrNotFound) || !errors.Is(gotErr, hidrive.ErrNotFound) {
+				t.Errorf("Walk() error = %v, fn error = %v, want %v", err, gotErr, hidrive.ErrNotFound)
+			}
+		})
+
+		t.Run("error returned from function", func(t *testing.T) {
+			err := dirApi.Walk(context.Background(), "/public/root", func(p string, obj *hidrive.Object, err error) error {
+				if p == "/public/root/b/d" {
+					return errStop
+				}
+				return err
+			}, &hidrive.WalkOptions{Workers: workers})
+			if !errors.Is(err, errStop) {
+				t.Errorf("Walk() error = %v, want %v", err, errStop)
+			}
+		})
+
+		t.Run("context cancelled", func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			err := dirApi.Walk(ctx, "/public/root", func(p string, obj *hidrive.Object, err error) error {
+				if p == "/public/root/b" {
+					cancel()
+				}
+				return err
+			}, &hidrive.WalkOptions{Workers: workers})
+			if !errors.Is(err, context.Canceled) {
+				t.Errorf("Walk() error = %v, want %v", err, context.Canceled)
+			}
+		})
+	}
+}
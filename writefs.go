@@ -0,0 +1,347 @@
+package go_hidrive
+
+import (
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"time"
+)
+
+// fsWriteChunkSize - size of data buffered by files opened for writing by [FS] before it is sent to HiDrive.
+const fsWriteChunkSize = 8 << 20
+
+/*
+WritableFS - a filesystem supporting modifications, implemented by [FS] for HiDrive and [OSFS] for the local disk,
+so they can be used interchangeably.
+
+Names follow the rules of fs.ValidPath, the semantics of the methods are the same as of the functions
+with the same names from the os package.
+*/
+type WritableFS interface {
+	fs.StatFS
+	OpenFile(name string, flag int, perm fs.FileMode) (WritableFile, error)
+	Mkdir(name string, perm fs.FileMode) error
+	MkdirAll(name string, perm fs.FileMode) error
+	Rename(oldname, newname string) error
+	Remove(name string) error
+	RemoveAll(name string) error
+	Chtimes(name string, atime, mtime time.Time) error
+}
+
+// WritableFile - a file opened by [WritableFS.OpenFile], *os.File satisfies this interface.
+type WritableFile interface {
+	fs.File
+	io.Writer
+}
+
+var (
+	_ WritableFS = (*FS)(nil)
+	_ WritableFS = (*OSFS)(nil)
+)
+
+/*
+OpenFile opens the named file with the given flags (os.O_RDONLY, os.O_WRONLY, os.O_CREATE etc.), `perm` is ignored.
+
+Files can be opened either for reading or for writing, os.O_RDWR is not supported.
+Files opened for writing with os.O_TRUNC or newly created are uploaded with [File.Create] while data is written,
+the content is replaced once the file is closed. Otherwise, data is written at the beginning of the file
+(or at its end with os.O_APPEND) with [File.Patch] in chunks of 8M.
+The result of the write is only guaranteed to be stored on HiDrive after Close returns nil.
+*/
+func (fsys *FS) OpenFile(name string, flag int, perm fs.FileMode) (WritableFile, error) {
+	if flag&(os.O_WRONLY|os.O_RDWR) == 0 {
+		f, err := fsys.Open(name)
+		if err != nil {
+			return nil, err
+		}
+		if fd, ok := f.(*fsFile); ok {
+			return fd, nil
+		}
+		return f.(*fsDir), nil
+	}
+	if flag&os.O_RDWR != 0 {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: errors.ErrUnsupported}
+	}
+	if err := fsys.checkWritable("open", name); err != nil {
+		return nil, err
+	}
+
+	p, err := fsys.remotePath("open", name)
+	if err != nil {
+		return nil, err
+	}
+	obj, err := fsys.stat("open", name)
+	exists := err == nil
+	switch {
+	case err != nil && (!errors.Is(err, fs.ErrNotExist) || flag&os.O_CREATE == 0):
+		return nil, err
+	case exists && obj.Type == "dir":
+		return nil, &fs.PathError{Op: "open", Path: name, Err: errIsDir}
+	case exists && flag&os.O_CREATE != 0 && flag&os.O_EXCL != 0:
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrExist}
+	case !exists:
+		// the upload starts with the first chunk written, so check the parent directory in advance
+		if parent, err := fsys.stat("open", path.Dir(name)); err != nil {
+			return nil, &fs.PathError{Op: "open", Path: name, Err: errors.Unwrap(err)}
+		} else if parent.Type != "dir" {
+			return nil, &fs.PathError{Op: "open", Path: name, Err: errNotDir}
+		}
+	}
+
+	w := &fsWriter{fsys: fsys, name: name, obj: obj}
+	if !exists || flag&os.O_TRUNC != 0 {
+		params := NewParameters().SetDir(path.Dir(p)).SetName(path.Base(p))
+		w.obj = &Object{Name: path.Base(p), Type: "file", MTime: Time(time.Now())}
+		w.upload = File{fsys.api}.Create(fsys.ctx, params.Values, &ChunkedUploadOptions{
+			ChunkSize: fsWriteChunkSize,
+			Overwrite: exists,
+		})
+		return w, nil
+	}
+
+	if flag&os.O_APPEND != 0 {
+		w.offset = obj.Size
+	}
+	return w, nil
+}
+
+// Create creates or truncates the named file, the same as OpenFile(name, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0666).
+func (fsys *FS) Create(name string) (WritableFile, error) {
+	return fsys.OpenFile(name, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0o666)
+}
+
+// Mkdir creates a new directory, the parent directory must exist. `perm` is ignored.
+func (fsys *FS) Mkdir(name string, perm fs.FileMode) error {
+	p, err := fsys.writablePath("mkdir", name)
+	if err != nil {
+		return err
+	}
+	if _, err := (Dir{fsys.api}).Create(fsys.ctx, NewParameters().SetPath(p).Values); err != nil {
+		return newPathError("mkdir", name, err)
+	}
+	return nil
+}
+
+// MkdirAll creates a directory along with any missing parents, it does nothing if the directory exists.
+// `perm` is ignored.
+func (fsys *FS) MkdirAll(name string, perm fs.FileMode) error {
+	p, err := fsys.writablePath("mkdir", name)
+	if err != nil {
+		return err
+	}
+
+	obj, err := fsys.stat("mkdir", name)
+	switch {
+	case err == nil && obj.Type == "dir":
+		return nil
+	case err == nil:
+		return &fs.PathError{Op: "mkdir", Path: name, Err: errNotDir}
+	case !errors.Is(err, fs.ErrNotExist):
+		return err
+	}
+
+	if _, err := (Dir{fsys.api}).CreatePath(fsys.ctx, NewParameters().SetPath(p).Values); err != nil {
+		return newPathError("mkdir", name, err)
+	}
+	return nil
+}
+
+/*
+Rename renames (moves) `oldname` to `newname`, an existing file at `newname` is replaced.
+
+Objects are renamed with [File.Rename] or [Dir.Rename] if the parent directory does not change and moved with
+[File.Move] or [Dir.Move] otherwise. A directory can only be renamed to a name which does not exist yet.
+*/
+func (fsys *FS) Rename(oldname, newname string) error {
+	linkErr := func(err error) error {
+		return &os.LinkError{Op: "rename", Old: oldname, New: newname, Err: err}
+	}
+
+	oldPath, err := fsys.writablePath("rename", oldname)
+	if err != nil {
+		return linkErr(errors.Unwrap(err))
+	}
+	newPath, err := fsys.writablePath("rename", newname)
+	if err != nil {
+		return linkErr(errors.Unwrap(err))
+	}
+
+	src, err := fsys.stat("rename", oldname)
+	if err != nil {
+		return linkErr(errors.Unwrap(err))
+	}
+	if oldPath == newPath {
+		return nil
+	}
+	if dst, err := fsys.stat("rename", newname); err == nil && (dst.Type == "dir" || src.Type == "dir") {
+		return linkErr(fs.ErrExist)
+	}
+
+	sameDir := path.Dir(oldPath) == path.Dir(newPath)
+	switch {
+	case src.Type == "dir" && sameDir:
+		_, err = Dir{fsys.api}.Rename(fsys.ctx, NewParameters().SetPid(src.ID).SetName(path.Base(newPath)).Values)
+	case src.Type == "dir":
+		_, err = Dir{fsys.api}.Move(fsys.ctx, NewParameters().SetSrcId(src.ID).SetDst(newPath).Values)
+	case sameDir:
+		params := NewParameters().SetPid(src.ID).SetName(path.Base(newPath)).SetOnExist("overwrite")
+		_, err = File{fsys.api}.Rename(fsys.ctx, params.Values)
+	default:
+		params := NewParameters().SetSrcId(src.ID).SetDst(newPath).SetOnExist("overwrite")
+		_, err = File{fsys.api}.Move(fsys.ctx, params.Values)
+	}
+	if err != nil {
+		return linkErr(errors.Unwrap(newPathError("rename", oldname, err)))
+	}
+	return nil
+}
+
+// Remove removes the named file or empty directory.
+func (fsys *FS) Remove(name string) error {
+	return fsys.remove("remove", name, false)
+}
+
+// RemoveAll removes the named file or directory with all its contents, it returns nil if the name does not exist.
+func (fsys *FS) RemoveAll(name string) error {
+	err := fsys.remove("removeall", name, true)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	return err
+}
+
+func (fsys *FS) remove(op, name string, recursive bool) error {
+	if _, err := fsys.writablePath(op, name); err != nil {
+		return err
+	}
+	obj, err := fsys.stat(op, name)
+	if err != nil {
+		return err
+	}
+
+	params := NewParameters().SetPid(obj.ID)
+	if obj.Type == "dir" {
+		err = Dir{fsys.api}.Delete(fsys.ctx, params.SetRecursive(recursive).Values)
+	} else {
+		err = File{fsys.api}.Delete(fsys.ctx, params.Values)
+	}
+	if err != nil {
+		return newPathError(op, name, err)
+	}
+	return nil
+}
+
+// Chtimes changes the modification time of the named file or directory, `atime` is ignored as HiDrive does not
+// track access time. A zero `mtime` leaves the modification time unchanged.
+func (fsys *FS) Chtimes(name string, atime, mtime time.Time) error {
+	p, err := fsys.writablePath("chtimes", name)
+	if err != nil || mtime.IsZero() {
+		return err
+	}
+	if _, err := (Meta{fsys.api}).Update(fsys.ctx, NewParameters().SetPath(p).SetMTime(mtime).Values); err != nil {
+		return newPathError("chtimes", name, err)
+	}
+	return nil
+}
+
+// checkWritable returns an error if the filesystem is a read-only snapshot view.
+func (fsys *FS) checkWritable(op, name string) error {
+	if fsys.snapshot != "" {
+		return &fs.PathError{Op: op, Path: name, Err: fs.ErrPermission}
+	}
+	return nil
+}
+
+// writablePath returns HiDrive path for the name if the filesystem can be modified.
+func (fsys *FS) writablePath(op, name string) (string, error) {
+	if err := fsys.checkWritable(op, name); err != nil {
+		return "", err
+	}
+	return fsys.remotePath(op, name)
+}
+
+// fsWriter - file opened for writing by [FS].
+type fsWriter struct {
+	fsys   *FS
+	name   string
+	obj    *Object
+	upload *UploadWriter // set when the file is uploaded from scratch
+	offset int64         // position of the next write when the file is patched
+	buf    []byte
+	closed bool
+}
+
+func (w *fsWriter) Write(p []byte) (int, error) {
+	if w.closed {
+		return 0, &fs.PathError{Op: "write", Path: w.name, Err: fs.ErrClosed}
+	}
+	if w.upload != nil {
+		n, err := w.upload.Write(p)
+		w.obj.Size += int64(n)
+		if err != nil {
+			return n, &fs.PathError{Op: "write", Path: w.name, Err: err}
+		}
+		return n, nil
+	}
+
+	w.buf = append(w.buf, p...)
+	if len(w.buf) >= fsWriteChunkSize {
+		if err := w.flush(); err != nil {
+			return 0, err
+		}
+	}
+	return len(p), nil
+}
+
+// flush writes buffered data at the current offset.
+func (w *fsWriter) flush() error {
+	if len(w.buf) == 0 {
+		return nil
+	}
+	if err := (File{w.fsys.api}).patchChunk(w.fsys.ctx, w.obj.ID, w.offset, w.buf, DefaultMaxChunkAttempts); err != nil {
+		return newPathError("write", w.name, err)
+	}
+	w.offset += int64(len(w.buf))
+	if w.offset > w.obj.Size {
+		w.obj.Size = w.offset
+	}
+	w.buf = w.buf[:0]
+	return nil
+}
+
+func (w *fsWriter) Read([]byte) (int, error) {
+	return 0, &fs.PathError{Op: "read", Path: w.name, Err: errors.ErrUnsupported}
+}
+
+func (w *fsWriter) Stat() (fs.FileInfo, error) {
+	return w.fsys.fileInfo(path.Base(w.name), w.obj), nil
+}
+
+// Close finishes writing, it returns an error if the data could not be stored on HiDrive.
+func (w *fsWriter) Close() error {
+	if w.closed {
+		return &fs.PathError{Op: "close", Path: w.name, Err: fs.ErrClosed}
+	}
+	w.closed = true
+
+	if w.upload != nil {
+		if err := w.upload.Close(); err != nil {
+			return newPathError("close", w.name, err)
+		}
+		w.obj = w.upload.Object()
+		return nil
+	}
+	return w.flush()
+}
+
+// Write always fails as files opened by [FS.Open] are read-only.
+func (f *fsFile) Write([]byte) (int, error) {
+	return 0, &fs.PathError{Op: "write", Path: f.info.Name(), Err: errors.ErrUnsupported}
+}
+
+// Write always fails for directories.
+func (d *fsDir) Write([]byte) (int, error) {
+	return 0, &fs.PathError{Op: "write", Path: d.name, Err: errIsDir}
+}
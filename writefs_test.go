@@ -0,0 +1,50 @@
+package go_hidrive_test
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"testing"
+	"time"
+
+	hidrive "github.com/Burmuley/go-hidrive"
+	"github.com/Burmuley/go-hidrive/hidrivetest"
+)
+
+func TestOSFS_Conformance(t *testing.T) {
+	hidrivetest.TestWritableFS(t, func(t *testing.T) hidrive.WritableFS {
+		return hidrive.NewOSFS(t.TempDir())
+	})
+}
+
+func TestFS_Conformance(t *testing.T) {
+	hidrivetest.TestWritableFS(t, func(t *testing.T) hidrive.WritableFS {
+		srv := hidrivetest.NewServer()
+		t.Cleanup(srv.Close)
+		if err := srv.AddDir("/public/root"); err != nil {
+			t.Fatalf("AddDir() error = %v", err)
+		}
+		return hidrive.NewFS(hidrive.NewApi(srv.Client(), srv.Endpoint()), "/public/root")
+	})
+}
+
+func TestFS_SnapshotIsReadOnly(t *testing.T) {
+	srv, fsys := newTestFS(t)
+	defer srv.Close()
+	snap := fsys.Snapshot("daily")
+
+	checks := map[string]error{
+		"OpenFile": func() error { _, err := snap.OpenFile("new.txt", os.O_WRONLY|os.O_CREATE, 0o644); return err }(),
+		"Mkdir":    snap.Mkdir("new", 0o755),
+		"Remove":   snap.Remove("a.txt"),
+		"Chtimes":  snap.Chtimes("a.txt", time.Now(), time.Now()),
+	}
+	for op, err := range checks {
+		if !errors.Is(err, fs.ErrPermission) {
+			t.Errorf("%s() error = %v, want %v", op, err, fs.ErrPermission)
+		}
+	}
+	if !srv.Exists("/public/root/a.txt") {
+		t.Errorf("file removed through snapshot view")
+	}
+}